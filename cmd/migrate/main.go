@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/config"
+	"github.com/SahandMohammed/wallet-service/internal/db"
+	"github.com/SahandMohammed/wallet-service/internal/dialect"
+	"github.com/SahandMohammed/wallet-service/internal/migration"
+)
+
+const usage = `Usage: migrate <command> [arguments]
+
+Commands:
+  up [N]        apply pending migrations, or only the next N
+  down [N]      roll back the last N migrations (default 1)
+  status        list migrations and when they were applied
+  check         exit with an error when migrations are pending
+  create NAME   add empty up and down files for a new migration, in the
+                directory of every database dialect
+
+The database is configured through the same environment as the server.
+`
+
+func main() {
+	dir := flag.String("dir", migration.SourceDir, "migrations directory for create, holding one directory per dialect")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		fmt.Fprintln(os.Stderr, "\nFlags:")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := run(flag.Args(), *dir); err != nil {
+		fmt.Fprintln(os.Stderr, "migrate:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, dir string) error {
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	command, args := args[0], args[1:]
+
+	if command == "create" {
+		if len(args) != 1 {
+			return fmt.Errorf("create takes a migration name")
+		}
+		for _, name := range dialect.All {
+			upPath, downPath, err := migration.Create(filepath.Join(dir, name), args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Println("Created", upPath)
+			fmt.Println("Created", downPath)
+		}
+		return nil
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	database, err := db.NewConnection(cfg)
+	if err != nil {
+		return err
+	}
+	m, err := migration.New(database)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		steps, err := stepsArg(args, 0)
+		if err != nil {
+			return err
+		}
+		applied, err := m.Up(ctx, steps)
+		for _, mig := range applied {
+			fmt.Printf("Applied %06d_%s\n", mig.Version, mig.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("No pending migrations")
+		}
+		return err
+	case "down":
+		steps, err := stepsArg(args, 1)
+		if err != nil {
+			return err
+		}
+		rolledBack, err := m.Down(ctx, steps)
+		for _, mig := range rolledBack {
+			fmt.Printf("Rolled back %06d_%s\n", mig.Version, mig.Name)
+		}
+		if err == nil && len(rolledBack) == 0 {
+			fmt.Println("No applied migrations")
+		}
+		return err
+	case "status":
+		return printStatus(ctx, m)
+	case "check":
+		if err := m.Check(ctx); err != nil {
+			return err
+		}
+		fmt.Println("Schema is up to date")
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
+func stepsArg(args []string, defaultSteps int) (int, error) {
+	if len(args) == 0 {
+		return defaultSteps, nil
+	}
+	steps, err := strconv.Atoi(args[0])
+	if err != nil || steps < 1 || len(args) > 1 {
+		return 0, fmt.Errorf("expected a positive number of migrations")
+	}
+	return steps, nil
+}
+
+func printStatus(ctx context.Context, m *migration.Migrator) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT\tNOTE")
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		note := ""
+		switch {
+		case status.Modified:
+			note = "modified since applied"
+		case status.Unknown:
+			note = "not in this build"
+		}
+		fmt.Fprintf(w, "%06d\t%s\t%s\t%s\n", status.Version, status.Name, appliedAt, note)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/SahandMohammed/wallet-service/internal/config"
+)
+
+const configUsage = `Usage: server config print [--redacted | --show-secrets]
+
+Prints the configuration the server would start with, after the config
+file, environment and _FILE secrets are applied, as a config file.
+Secrets are replaced with ` + config.Redacted + `, which --redacted asks for
+explicitly, unless --show-secrets is given.
+`
+
+// configCommand runs "server config ...", returning the exit code
+func configCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprint(stderr, configUsage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("config print", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { fmt.Fprint(stderr, configUsage) }
+	redacted := fs.Bool("redacted", false, "replace secrets with "+config.Redacted+" (the default)")
+	showSecrets := fs.Bool("show-secrets", false, "print secrets in plain text")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if *redacted && *showSecrets {
+		fmt.Fprintln(stderr, "--redacted and --show-secrets cannot be used together")
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		printConfigError(stderr, err)
+		return 1
+	}
+	if err := cfg.Write(stdout, !*showSecrets); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// printConfigError lists every configuration problem, one per line
+func printConfigError(w io.Writer, err error) {
+	fmt.Fprintln(w, "Invalid configuration:")
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, err := range joined.Unwrap() {
+			fmt.Fprintf(w, "  - %v\n", err)
+		}
+		return
+	}
+	fmt.Fprintf(w, "  - %v\n", err)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/SahandMohammed/wallet-service/internal/config"
+)
+
+func TestConfigPrintRedactsByDefault(t *testing.T) {
+	t.Setenv("MYSQL_PASSWORD", "hunter2")
+
+	var stdout, stderr bytes.Buffer
+	if code := configCommand([]string{"print"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if strings.Contains(stdout.String(), "hunter2") {
+		t.Errorf("secret printed without --show-secrets:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := configCommand([]string{"print", "--show-secrets"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "hunter2") {
+		t.Errorf("expected the secret with --show-secrets:\n%s", stdout.String())
+	}
+}
+
+func TestConfigPrintRedacted(t *testing.T) {
+	t.Setenv("MYSQL_PASSWORD", "hunter2")
+	t.Setenv("APP_JWT_SECRET", "jwtsecret")
+
+	var stdout, stderr bytes.Buffer
+	if code := configCommand([]string{"print", "--redacted"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	printed := stdout.String()
+	for _, secret := range []string{"hunter2", "jwtsecret"} {
+		if strings.Contains(printed, secret) {
+			t.Errorf("secret %q printed with --redacted:\n%s", secret, printed)
+		}
+	}
+	for _, want := range []string{
+		"mysql_password: '" + config.Redacted + "'",
+		"app_jwt_secret: '" + config.Redacted + "'",
+	} {
+		if !strings.Contains(printed, want) {
+			t.Errorf("expected %q in:\n%s", want, printed)
+		}
+	}
+
+	stderr.Reset()
+	if code := configCommand([]string{"print", "--redacted", "--show-secrets"}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit code 2 for conflicting flags, got %d", code)
+	}
+}
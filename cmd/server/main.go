@@ -1,35 +1,69 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/SahandMohammed/wallet-service/internal/cache"
 	"github.com/SahandMohammed/wallet-service/internal/config"
+	"github.com/SahandMohammed/wallet-service/internal/credential"
 	"github.com/SahandMohammed/wallet-service/internal/db"
+	"github.com/SahandMohammed/wallet-service/internal/events"
+	"github.com/SahandMohammed/wallet-service/internal/grpcapi"
 	"github.com/SahandMohammed/wallet-service/internal/http/router"
+	"github.com/SahandMohammed/wallet-service/internal/logging"
+	"github.com/SahandMohammed/wallet-service/internal/metrics"
 	"github.com/SahandMohammed/wallet-service/internal/migration"
+	"github.com/SahandMohammed/wallet-service/internal/notification"
+	"github.com/SahandMohammed/wallet-service/internal/realtime"
+	"github.com/SahandMohammed/wallet-service/internal/service"
+	"github.com/SahandMohammed/wallet-service/internal/tracing"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(configCommand(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatal("Failed to load config:", err)
+		printConfigError(os.Stderr, err)
+		os.Exit(1)
 	}
 
 	// Setup logger
 	logrus.SetFormatter(&logrus.JSONFormatter{})
-	level, err := logrus.ParseLevel(cfg.LogLevel)
+	level, _ := logrus.ParseLevel(cfg.LogLevel) // checked by Validate
+	logrus.SetLevel(level)
+	logrus.AddHook(tracing.LogHook{})
+	logrus.AddHook(logging.NewRedactor(cfg.LogRedactFields))
+
+	// Setup tracing before any connection is instrumented
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:     cfg.TracingExporter,
+		OTLPProtocol: cfg.TracingOTLPProtocol,
+		File:         cfg.TracingFile,
+		SampleRatio:  cfg.TracingSampleRatio,
+	})
 	if err != nil {
-		level = logrus.InfoLevel
+		logrus.Fatal("Failed to setup tracing:", err)
 	}
-	logrus.SetLevel(level)
 
 	// Initialize database connections
-	mysqlDB, err := db.NewMySQLConnection(cfg)
+	database, err := db.NewConnection(cfg)
 	if err != nil {
-		logrus.Fatal("Failed to connect to MySQL:", err)
+		logrus.Fatal("Failed to connect to the database:", err)
 	}
 
 	redisClient, err := db.NewRedisConnection(cfg)
@@ -37,9 +71,33 @@ func main() {
 		logrus.Fatal("Failed to connect to Redis:", err)
 	}
 
-	// Run migrations
-	if err := migration.AutoMigrate(mysqlDB); err != nil {
-		logrus.Fatal("Failed to run migrations:", err)
+	// Export connection pool statistics on /metrics
+	sqlDB, err := database.DB()
+	if err != nil {
+		logrus.Fatal("Failed to get the database connection pool:", err)
+	}
+	if err := metrics.RegisterDB(sqlDB, cfg.DBDriver); err != nil {
+		logrus.Fatal("Failed to register database metrics:", err)
+	}
+	if err := metrics.RegisterRedis(redisClient); err != nil {
+		logrus.Fatal("Failed to register Redis metrics:", err)
+	}
+
+	// Apply or check schema migrations, depending on MIGRATE_ON_START
+	if err := migration.Run(context.Background(), database, cfg.MigrateOnStart); err != nil {
+		logrus.Fatal("Failed to migrate database:", err)
+	}
+
+	// Setup notifier for password reset tokens and security notices
+	notifier, err := notification.New(cfg.NotifierType, cfg.NotifierFile)
+	if err != nil {
+		logrus.Fatal("Failed to setup notifier:", err)
+	}
+
+	// Setup credential policy and password hasher
+	policy, hasher, err := credential.FromConfig(cfg)
+	if err != nil {
+		logrus.Fatal("Failed to setup credentials:", err)
 	}
 
 	// Set Gin mode
@@ -47,17 +105,121 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	// The services are shared by the HTTP and gRPC APIs and the workers
+	services := service.NewServices(database, cache.NewRedisCache(redisClient), notifier, policy, hasher, cfg)
+
+	// Purge audit log entries past the retention period
+	workers := newWorkers()
+	workers.Go(func(ctx context.Context) { services.Audit.RunRetention(ctx, 24*time.Hour) })
+
+	// Outgoing webhooks are fed by the outbox relay alongside the stream
+	workers.Go(func(ctx context.Context) {
+		services.Webhooks.RunDispatcher(ctx, cfg.WebhookPollInterval)
+	})
+
+	// Relay domain events from the outbox
+	streamPublisher, err := events.NewPublisher(cfg.EventPublisher, redisClient, cfg.EventStream)
+	if err != nil {
+		logrus.Fatal("Failed to setup event publisher:", err)
+	}
+	broker := realtime.NewBroker(redisClient, int64(cfg.StreamHistorySize))
+	publisher := events.NewMultiPublisher(streamPublisher, services.Webhooks, broker)
+	relay := events.NewRelay(database, publisher, cfg.OutboxBatchSize, cfg.OutboxMaxAttempts, cfg.OutboxPollInterval)
+	workers.Go(relay.Run)
+
+	// Readiness fails and event streams close once draining starts
+	drainCtx, startDraining := context.WithCancel(context.Background())
+
 	// Setup router
-	r := router.SetupRouter(mysqlDB, redisClient, cfg)
+	r := router.SetupRouter(drainCtx, database, redisClient, services, cfg)
 
-	// Start server
 	port := cfg.AppPort
 	if port == "" {
 		port = "8080"
 	}
+	httpServer := &http.Server{
+		Addr:              ":" + port,
+		Handler:           r,
+		ReadTimeout:       cfg.HTTPReadTimeout,
+		ReadHeaderTimeout: cfg.HTTPReadHeaderTimeout,
+		WriteTimeout:      cfg.HTTPWriteTimeout,
+		IdleTimeout:       cfg.HTTPIdleTimeout,
+	}
+
+	grpcServer := grpcapi.NewServer(redisClient, services)
+	grpcListener, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+	if err != nil {
+		logrus.Fatal("Failed to listen for gRPC:", err)
+	}
+
+	// Either server failing stops the process like a signal does
+	serverErrors := make(chan error, 2)
+	go func() {
+		logrus.WithField("port", cfg.GRPCPort).Info("Starting gRPC server")
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			serverErrors <- fmt.Errorf("gRPC server: %w", err)
+		}
+	}()
+	go func() {
+		logrus.WithField("port", port).Info("Starting server")
+		if err := httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			serverErrors <- fmt.Errorf("HTTP server: %w", err)
+		}
+	}()
 
-	logrus.WithField("port", port).Info("Starting server")
-	if err := r.Run(":" + port); err != nil {
-		logrus.Fatal("Failed to start server:", err)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	exitCode := 0
+	select {
+	case sig := <-signals:
+		logrus.WithField("signal", sig.String()).Info("Shutting down")
+	case err := <-serverErrors:
+		logrus.WithError(err).Error("Server failed, shutting down")
+		exitCode = 1
 	}
+
+	// Fail readiness first and give load balancers time to notice
+	startDraining()
+	grpcServer.Drain()
+	if cfg.ShutdownDelay > 0 {
+		time.Sleep(cfg.ShutdownDelay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	// Wait for in-flight requests, then stop the workers that use the
+	// connections, then close the connections
+	var servers sync.WaitGroup
+	servers.Add(2)
+	go func() {
+		defer servers.Done()
+		if err := httpServer.Shutdown(ctx); err != nil {
+			logrus.WithError(err).Warn("HTTP server did not drain in time")
+			exitCode = 1
+		}
+	}()
+	go func() {
+		defer servers.Done()
+		grpcServer.Shutdown(ctx)
+	}()
+	servers.Wait()
+
+	if err := workers.Stop(ctx); err != nil {
+		logrus.WithError(err).Warn("Background workers did not stop in time")
+		exitCode = 1
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		logrus.WithError(err).Warn("Failed to flush traces")
+	}
+	if err := redisClient.Close(); err != nil {
+		logrus.WithError(err).Warn("Failed to close Redis connection")
+	}
+	if err := sqlDB.Close(); err != nil {
+		logrus.WithError(err).Warn("Failed to close the database connection")
+	}
+
+	logrus.Info("Shutdown complete")
+	os.Exit(exitCode)
 }
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/service"
+	"gorm.io/gorm"
+)
+
+const dateLayout = "2006-01-02"
+
+func (a *app) createAdmin(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("admin create", flag.ContinueOnError)
+	passwordStdin := fs.Bool("password-stdin", false, "read the password from the first line of stdin")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: walletctl admin create [-password-stdin] USERNAME")
+	}
+
+	password := os.Getenv("WALLETCTL_PASSWORD")
+	if *passwordStdin {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("failed to read password: %w", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+	if password == "" {
+		return fmt.Errorf("no password given, use -password-stdin or WALLETCTL_PASSWORD")
+	}
+
+	// Registration enforces the same credential policy as the API
+	user, err := a.authService.Register(ctx, fs.Arg(0), password)
+	if err != nil {
+		return err
+	}
+	if err := a.adminService.SetAdmin(ctx, user.ID, true); err != nil {
+		return fmt.Errorf("user %d was created but could not be made an admin: %w", user.ID, err)
+	}
+	user.IsAdmin = true
+
+	return a.out.user(user)
+}
+
+func (a *app) setAdmin(ctx context.Context, args []string, isAdmin bool) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected a user id or username")
+	}
+	user, err := a.findUser(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if err := a.adminService.SetAdmin(ctx, user.ID, isAdmin); err != nil {
+		return err
+	}
+	user.IsAdmin = isAdmin
+	return a.out.user(user)
+}
+
+func (a *app) getUser(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected a user id or username")
+	}
+	user, err := a.findUser(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return a.out.user(user)
+}
+
+// findUser looks a user up by id, or by username when ref is not a number
+func (a *app) findUser(ctx context.Context, ref string) (*domain.User, error) {
+	id, err := strconv.ParseUint(ref, 10, 32)
+	if err != nil {
+		user, err := a.repos.users.GetByUsername(ctx, ref)
+		if err != nil {
+			return nil, notFound(err, "user %q not found", ref)
+		}
+		id = uint64(user.ID)
+	}
+
+	// GetByID also loads the wallets
+	user, err := a.repos.users.GetByID(ctx, uint(id))
+	if err != nil {
+		return nil, notFound(err, "user %s not found", ref)
+	}
+	return user, nil
+}
+
+func (a *app) getWallet(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected a wallet id")
+	}
+	walletID, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	wallet, err := a.repos.wallets.GetByID(ctx, walletID)
+	if err != nil {
+		return notFound(err, "wallet %d not found", walletID)
+	}
+	return a.out.wallets([]*domain.Wallet{wallet})
+}
+
+func (a *app) adjustWallet(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("wallet adjust", flag.ContinueOnError)
+	reason := fs.String("reason", "", "why the adjustment is made, required")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: walletctl wallet adjust -reason TEXT ID AMOUNT")
+	}
+	walletID, err := parseID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	amount, err := strconv.ParseFloat(fs.Arg(1), 64)
+	if err != nil {
+		return fmt.Errorf("invalid amount %q", fs.Arg(1))
+	}
+
+	transaction, err := a.walletService.Adjust(ctx, walletID, amount, *reason)
+	if err != nil {
+		return err
+	}
+	return a.out.transaction(transaction)
+}
+
+func (a *app) freezeWallet(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("wallet freeze", flag.ContinueOnError)
+	reason := fs.String("reason", "", "why the wallet is frozen, required")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: walletctl wallet freeze -reason TEXT ID")
+	}
+	walletID, err := parseID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	wallet, err := a.walletService.Freeze(ctx, walletID, *reason)
+	if err != nil {
+		return err
+	}
+	return a.out.wallets([]*domain.Wallet{wallet})
+}
+
+func (a *app) unfreezeWallet(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected a wallet id")
+	}
+	walletID, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+
+	wallet, err := a.walletService.Unfreeze(ctx, walletID)
+	if err != nil {
+		return err
+	}
+	return a.out.wallets([]*domain.Wallet{wallet})
+}
+
+func (a *app) reconcile(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	all := fs.Bool("all", false, "list every wallet, not only those that disagree")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ledgers, err := a.adminService.Reconcile(ctx, *all)
+	if err != nil {
+		return err
+	}
+	if err := a.out.ledgers(ledgers); err != nil {
+		return err
+	}
+
+	for _, ledger := range ledgers {
+		if ledger.Difference() != 0 {
+			return errMismatch
+		}
+	}
+	return nil
+}
+
+func (a *app) exportTransactions(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("export transactions", flag.ContinueOnError)
+	userID := fs.Uint("user", 0, "only transactions of this user's wallets")
+	walletID := fs.Uint("wallet", 0, "only transactions of this wallet")
+	txType := fs.String("type", "", "deposit, transfer, withdraw or adjustment")
+	from := fs.String("from", "", "first day to include, YYYY-MM-DD")
+	to := fs.String("to", "", "last day to include, YYYY-MM-DD")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected arguments %v", fs.Args())
+	}
+
+	var filters service.AdminTransactionFilters
+	if *userID != 0 {
+		id := uint(*userID)
+		filters.UserID = &id
+	}
+	if *walletID != 0 {
+		id := uint(*walletID)
+		filters.WalletID = &id
+	}
+	if *txType != "" {
+		t := domain.TransactionType(*txType)
+		switch t {
+		case domain.TransactionTypeDeposit, domain.TransactionTypeTransfer, domain.TransactionTypeWithdraw, domain.TransactionTypeAdjustment:
+			filters.Type = &t
+		default:
+			return fmt.Errorf("unknown transaction type %q", *txType)
+		}
+	}
+	if *from != "" {
+		start, err := time.Parse(dateLayout, *from)
+		if err != nil {
+			return fmt.Errorf("invalid -from date %q", *from)
+		}
+		filters.StartDate = &start
+	}
+	if *to != "" {
+		end, err := time.Parse(dateLayout, *to)
+		if err != nil {
+			return fmt.Errorf("invalid -to date %q", *to)
+		}
+		// Include the whole day, like the admin API
+		end = end.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
+		filters.EndDate = &end
+	}
+
+	stream := a.out.transactionStream()
+	if err := a.adminService.ExportTransactions(ctx, filters, stream.write); err != nil {
+		return err
+	}
+	return stream.close()
+}
+
+func parseID(value string) (uint, error) {
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid id %q", value)
+	}
+	return uint(id), nil
+}
+
+func notFound(err error, format string, args ...interface{}) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf(format, args...)
+	}
+	return err
+}
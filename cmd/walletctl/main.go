@@ -0,0 +1,195 @@
+// Command walletctl runs operations tasks against the wallet service
+// database, using the same configuration, repositories and services as the
+// server. Changes are audited with the operating system user as the actor.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+
+	"github.com/SahandMohammed/wallet-service/internal/audit"
+	"github.com/SahandMohammed/wallet-service/internal/cache"
+	"github.com/SahandMohammed/wallet-service/internal/config"
+	"github.com/SahandMohammed/wallet-service/internal/credential"
+	"github.com/SahandMohammed/wallet-service/internal/db"
+	"github.com/SahandMohammed/wallet-service/internal/notification"
+	"github.com/SahandMohammed/wallet-service/internal/repository"
+	"github.com/SahandMohammed/wallet-service/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+const usage = `Usage: walletctl [-o table|json] <command> [flags] [arguments]
+
+Commands:
+  admin create [-password-stdin] USERNAME
+                          create an admin user, the password is read from
+                          stdin or WALLETCTL_PASSWORD
+  admin grant USER        make an existing user an admin
+  admin revoke USER       remove admin rights from a user
+  user get USER           show a user and their wallets, by id or username
+  wallet get ID           show a wallet
+  wallet adjust -reason TEXT ID AMOUNT
+                          post a manual adjustment in dollars, negative to
+                          remove funds
+  wallet freeze -reason TEXT ID
+                          reject deposits and transfers for a wallet
+  wallet unfreeze ID      accept deposits and transfers again
+  reconcile [-all]        compare wallet balances with their transactions,
+                          exits with status 1 when any disagree
+  export transactions [-user ID] [-wallet ID] [-type TYPE] [-from DATE] [-to DATE]
+                          write transactions in id order, -o also accepts csv
+
+The database is configured through the same environment as the server.
+`
+
+// errMismatch makes reconcile exit with status 1 without an error message
+var errMismatch = errors.New("balances do not reconcile")
+
+type app struct {
+	out   *output
+	repos struct {
+		users        repository.UserRepository
+		wallets      repository.WalletRepository
+		transactions repository.TransactionRepository
+	}
+	authService   service.AuthService
+	walletService service.WalletService
+	adminService  service.AdminService
+}
+
+func main() {
+	format := flag.String("o", "table", "output format: table or json, or csv for export")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *format != formatTable && *format != formatJSON && *format != formatCSV {
+		fmt.Fprintf(os.Stderr, "walletctl: unknown output format %q\n", *format)
+		os.Exit(2)
+	}
+
+	// Keep service logs out of the command output
+	logrus.SetOutput(os.Stderr)
+	logrus.SetLevel(logrus.WarnLevel)
+
+	a, err := newApp(*format)
+	if err == nil {
+		err = a.run(actorContext(), flag.Args())
+	}
+	switch {
+	case err == nil:
+	case errors.Is(err, errMismatch):
+		os.Exit(1)
+	default:
+		fmt.Fprintln(os.Stderr, "walletctl:", err)
+		os.Exit(1)
+	}
+}
+
+func newApp(format string) (*app, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	database, err := db.NewConnection(cfg)
+	if err != nil {
+		return nil, err
+	}
+	redisClient, err := db.NewRedisConnection(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	notifier, err := notification.New(cfg.NotifierType, cfg.NotifierFile)
+	if err != nil {
+		return nil, err
+	}
+	policy, hasher, err := credential.FromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// The same services as the server, so wiring changes reach both
+	services := service.NewServices(database, cache.NewRedisCache(redisClient), notifier, policy, hasher, cfg)
+
+	a := &app{out: newOutput(os.Stdout, format)}
+	a.repos.users = repository.NewUserRepository(database)
+	a.repos.wallets = repository.NewWalletRepository(database)
+	a.repos.transactions = repository.NewTransactionRepository(database)
+	a.authService = services.Auth
+	a.walletService = services.Wallet
+	a.adminService = services.Admin
+	return a, nil
+}
+
+// actorContext names the operating system user in audit log entries
+func actorContext() context.Context {
+	name := "walletctl"
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		name = "walletctl:" + u.Username
+	}
+	if len(name) > 50 {
+		name = name[:50]
+	}
+	return audit.WithMetadata(context.Background(), audit.Metadata{
+		ActorName: name,
+		UserAgent: "walletctl",
+	})
+}
+
+func (a *app) run(ctx context.Context, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("missing command")
+	}
+
+	switch args[0] {
+	case "reconcile":
+		if a.out.format == formatCSV {
+			return fmt.Errorf("csv output is only supported by export transactions")
+		}
+		return a.reconcile(ctx, args[1:])
+	case "admin", "user", "wallet", "export":
+		if len(args) < 2 {
+			return fmt.Errorf("%s needs a subcommand, see walletctl -h", args[0])
+		}
+	default:
+		return fmt.Errorf("unknown command %q, see walletctl -h", args[0])
+	}
+
+	command, rest := args[0]+" "+args[1], args[2:]
+	if a.out.format == formatCSV && command != "export transactions" {
+		return fmt.Errorf("csv output is only supported by export transactions")
+	}
+	switch command {
+	case "admin create":
+		return a.createAdmin(ctx, rest)
+	case "admin grant":
+		return a.setAdmin(ctx, rest, true)
+	case "admin revoke":
+		return a.setAdmin(ctx, rest, false)
+	case "user get":
+		return a.getUser(ctx, rest)
+	case "wallet get":
+		return a.getWallet(ctx, rest)
+	case "wallet adjust":
+		return a.adjustWallet(ctx, rest)
+	case "wallet freeze":
+		return a.freezeWallet(ctx, rest)
+	case "wallet unfreeze":
+		return a.unfreezeWallet(ctx, rest)
+	case "export transactions":
+		return a.exportTransactions(ctx, rest)
+	default:
+		return fmt.Errorf("unknown command %q, see walletctl -h", command)
+	}
+}
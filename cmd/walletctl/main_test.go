@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/cache"
+	"github.com/SahandMohammed/wallet-service/internal/config"
+	"github.com/SahandMohammed/wallet-service/internal/credential"
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/notification"
+	"github.com/SahandMohammed/wallet-service/internal/repository"
+	"github.com/SahandMohammed/wallet-service/internal/repository/memory"
+	"github.com/SahandMohammed/wallet-service/internal/service"
+)
+
+// newTestApp wires the commands to the in-memory repositories, with JSON
+// output collected in the returned buffer
+func newTestApp(t *testing.T) (*app, *memory.Store, *bytes.Buffer) {
+	t.Helper()
+	cfg := config.Defaults()
+	cfg.PasswordHasher = "bcrypt"
+	cfg.BcryptCost = 4
+	policy, hasher, err := credential.FromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notifier, err := notification.New("log", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := memory.NewStore()
+	serviceCache := cache.NewMemory()
+	auditService := service.NewAuditService(store.AuditLogs(), 0)
+
+	var out bytes.Buffer
+	a := &app{out: newOutput(&out, formatJSON)}
+	a.repos.users = store.Users()
+	a.repos.wallets = store.Wallets()
+	a.repos.transactions = store.Transactions()
+	a.authService = service.NewAuthService(
+		store.Users(), store.PasswordResets(), store.Sessions(),
+		auditService, notifier, policy, hasher, cfg, serviceCache, store.UnitOfWork(),
+	)
+	a.walletService = service.NewWalletService(a.repos.wallets, a.repos.transactions, a.repos.users, auditService, serviceCache, store.UnitOfWork(), service.WalletCacheTTL{
+		Wallet:       time.Minute,
+		Transactions: time.Minute,
+	})
+	a.adminService = service.NewAdminService(a.repos.users, a.repos.wallets, a.repos.transactions, auditService)
+	return a, store, &out
+}
+
+// runJSON runs a command and decodes its output into v
+func runJSON(t *testing.T, a *app, out *bytes.Buffer, v interface{}, args ...string) {
+	t.Helper()
+	out.Reset()
+	if err := a.run(context.Background(), args); err != nil {
+		t.Fatalf("walletctl %s: %v", strings.Join(args, " "), err)
+	}
+	if err := json.Unmarshal(out.Bytes(), v); err != nil {
+		t.Fatalf("walletctl %s: decode %q: %v", strings.Join(args, " "), out.String(), err)
+	}
+}
+
+func TestAdminCommands(t *testing.T) {
+	a, _, out := newTestApp(t)
+
+	t.Setenv("WALLETCTL_PASSWORD", "short")
+	if err := a.run(context.Background(), []string{"admin", "create", "root"}); err == nil {
+		t.Error("expected the password policy to apply")
+	}
+
+	t.Setenv("WALLETCTL_PASSWORD", "password123")
+	var user userView
+	runJSON(t, a, out, &user, "admin", "create", "root")
+	if user.Username != "root" || !user.IsAdmin {
+		t.Fatalf("expected an admin named root, got %+v", user)
+	}
+
+	runJSON(t, a, out, &user, "admin", "revoke", "root")
+	if user.IsAdmin {
+		t.Error("expected admin rights to be revoked")
+	}
+	runJSON(t, a, out, &user, "user", "get", "1")
+	if user.Username != "root" || user.IsAdmin {
+		t.Errorf("expected the revocation to be stored, got %+v", user)
+	}
+
+	if err := a.run(context.Background(), []string{"admin", "grant", "nobody"}); err == nil || err.Error() != `user "nobody" not found` {
+		t.Errorf("expected an unknown user to be reported, got %v", err)
+	}
+}
+
+func TestWalletCommands(t *testing.T) {
+	a, store, out := newTestApp(t)
+	ctx := context.Background()
+	user := &domain.User{Username: "alice", Password: "x"}
+	if err := store.Users().Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	wallet, err := a.walletService.CreateWallet(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := strconv.FormatUint(uint64(wallet.ID), 10)
+
+	if err := a.run(ctx, []string{"wallet", "adjust", id, "5"}); err == nil {
+		t.Error("expected a reason to be required")
+	}
+	var transaction transactionView
+	runJSON(t, a, out, &transaction, "wallet", "adjust", "-reason", "goodwill", id, "5")
+	if transaction.WalletID != wallet.ID || transaction.Amount != 5 || transaction.BalanceAfter != 5 {
+		t.Errorf("unexpected adjustment %+v", transaction)
+	}
+
+	var view walletView
+	runJSON(t, a, out, &view, "wallet", "freeze", "-reason", "fraud", id)
+	if view.FrozenAt == nil || view.FrozenReason != "fraud" {
+		t.Errorf("expected a frozen wallet, got %+v", view)
+	}
+	view = walletView{}
+	runJSON(t, a, out, &view, "wallet", "unfreeze", id)
+	if view.FrozenAt != nil || view.Balance != 5 {
+		t.Errorf("expected an unfrozen wallet, got %+v", view)
+	}
+
+	entries, err := store.AuditLogs().List(ctx, repository.AuditLogFilters{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions := map[string]bool{}
+	for _, entry := range entries {
+		actions[entry.Action] = true
+	}
+	for _, action := range []string{service.AuditActionAdjust, service.AuditActionFreeze, service.AuditActionUnfreeze} {
+		if !actions[action] {
+			t.Errorf("expected a %s audit entry", action)
+		}
+	}
+
+	if err := a.run(ctx, []string{"wallet", "get", "99"}); err == nil || err.Error() != "wallet 99 not found" {
+		t.Errorf("expected an unknown wallet to be reported, got %v", err)
+	}
+}
+
+func TestCommandErrors(t *testing.T) {
+	a, _, _ := newTestApp(t)
+	tests := map[string][]string{
+		"unknown command":    {"wallets"},
+		"missing subcommand": {"wallet"},
+		"unknown subcommand": {"wallet", "delete", "1"},
+		"invalid id":         {"wallet", "get", "abc"},
+	}
+	for name, args := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := a.run(context.Background(), args); err == nil {
+				t.Errorf("expected walletctl %s to fail", strings.Join(args, " "))
+			}
+		})
+	}
+
+	a.out.format = formatCSV
+	if err := a.run(context.Background(), []string{"wallet", "get", "1"}); err == nil {
+		t.Error("expected csv output to be limited to export")
+	}
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/repository"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+// Views use dollars, like the API
+
+type walletView struct {
+	ID           uint       `json:"id"`
+	UserID       uint       `json:"user_id"`
+	Username     string     `json:"username,omitempty"`
+	Balance      float64    `json:"balance"`
+	FrozenAt     *time.Time `json:"frozen_at,omitempty"`
+	FrozenReason string     `json:"frozen_reason,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+type userView struct {
+	ID        uint         `json:"id"`
+	Username  string       `json:"username"`
+	IsAdmin   bool         `json:"is_admin"`
+	CreatedAt time.Time    `json:"created_at"`
+	Wallets   []walletView `json:"wallets"`
+}
+
+type transactionView struct {
+	ID              uint      `json:"id"`
+	WalletID        uint      `json:"wallet_id"`
+	UserID          uint      `json:"user_id,omitempty"`
+	Type            string    `json:"type"`
+	Amount          float64   `json:"amount"`
+	BalanceBefore   float64   `json:"balance_before"`
+	BalanceAfter    float64   `json:"balance_after"`
+	FromWalletID    *uint     `json:"from_wallet_id,omitempty"`
+	ToWalletID      *uint     `json:"to_wallet_id,omitempty"`
+	TransactionUUID string    `json:"transaction_uuid"`
+	Description     string    `json:"description"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type ledgerView struct {
+	WalletID     uint    `json:"wallet_id"`
+	UserID       uint    `json:"user_id"`
+	Balance      float64 `json:"balance"`
+	LedgerTotal  float64 `json:"ledger_total"`
+	Difference   float64 `json:"difference"`
+	Transactions int64   `json:"transactions"`
+}
+
+func newWalletView(wallet *domain.Wallet) walletView {
+	return walletView{
+		ID:           wallet.ID,
+		UserID:       wallet.UserID,
+		Username:     wallet.User.Username,
+		Balance:      domain.MinorUnitsToDollars(wallet.Balance),
+		FrozenAt:     wallet.FrozenAt,
+		FrozenReason: wallet.FrozenReason,
+		CreatedAt:    wallet.CreatedAt,
+	}
+}
+
+func newTransactionView(tx *domain.Transaction) transactionView {
+	return transactionView{
+		ID:              tx.ID,
+		WalletID:        tx.WalletID,
+		UserID:          tx.Wallet.UserID,
+		Type:            string(tx.Type),
+		Amount:          domain.MinorUnitsToDollars(tx.Amount),
+		BalanceBefore:   domain.MinorUnitsToDollars(tx.BalanceBefore),
+		BalanceAfter:    domain.MinorUnitsToDollars(tx.BalanceAfter),
+		FromWalletID:    tx.FromWalletID,
+		ToWalletID:      tx.ToWalletID,
+		TransactionUUID: tx.TransactionUUID,
+		Description:     tx.Description,
+		CreatedAt:       tx.CreatedAt,
+	}
+}
+
+type output struct {
+	w      io.Writer
+	format string
+}
+
+func newOutput(w io.Writer, format string) *output {
+	return &output{w: w, format: format}
+}
+
+func (o *output) json(value interface{}) error {
+	encoder := json.NewEncoder(o.w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func (o *output) table(header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(o.w, 0, 0, 2, ' ', 0)
+	writeRow(tw, header)
+	for _, row := range rows {
+		writeRow(tw, row)
+	}
+	return tw.Flush()
+}
+
+func writeRow(w io.Writer, row []string) {
+	for i, cell := range row {
+		if i > 0 {
+			fmt.Fprint(w, "\t")
+		}
+		fmt.Fprint(w, cell)
+	}
+	fmt.Fprintln(w)
+}
+
+func (o *output) user(user *domain.User) error {
+	view := userView{
+		ID:        user.ID,
+		Username:  user.Username,
+		IsAdmin:   user.IsAdmin,
+		CreatedAt: user.CreatedAt,
+		Wallets:   []walletView{},
+	}
+	for i := range user.Wallets {
+		wallet := newWalletView(&user.Wallets[i])
+		wallet.Username = ""
+		view.Wallets = append(view.Wallets, wallet)
+	}
+
+	if o.format == formatJSON {
+		return o.json(view)
+	}
+
+	err := o.table([]string{"ID", "USERNAME", "ADMIN", "CREATED AT"}, [][]string{{
+		uintString(view.ID), view.Username, strconv.FormatBool(view.IsAdmin), timeString(&view.CreatedAt),
+	}})
+	if err != nil || len(view.Wallets) == 0 {
+		return err
+	}
+	fmt.Fprintln(o.w)
+	return o.walletTable(view.Wallets)
+}
+
+func (o *output) wallets(wallets []*domain.Wallet) error {
+	views := make([]walletView, 0, len(wallets))
+	for _, wallet := range wallets {
+		views = append(views, newWalletView(wallet))
+	}
+
+	if o.format == formatJSON {
+		if len(views) == 1 {
+			return o.json(views[0])
+		}
+		return o.json(views)
+	}
+	return o.walletTable(views)
+}
+
+func (o *output) walletTable(wallets []walletView) error {
+	var rows [][]string
+	for _, w := range wallets {
+		rows = append(rows, []string{
+			uintString(w.ID), uintString(w.UserID), w.Username, money(w.Balance),
+			timeString(w.FrozenAt), w.FrozenReason, timeString(&w.CreatedAt),
+		})
+	}
+	return o.table([]string{"WALLET", "USER ID", "USERNAME", "BALANCE", "FROZEN AT", "FROZEN REASON", "CREATED AT"}, rows)
+}
+
+func (o *output) transaction(tx *domain.Transaction) error {
+	if o.format == formatJSON {
+		return o.json(newTransactionView(tx))
+	}
+	stream := o.transactionStream()
+	if err := stream.write([]*domain.Transaction{tx}); err != nil {
+		return err
+	}
+	return stream.close()
+}
+
+func (o *output) ledgers(ledgers []*repository.WalletLedger) error {
+	views := make([]ledgerView, 0, len(ledgers))
+	for _, l := range ledgers {
+		views = append(views, ledgerView{
+			WalletID:     l.WalletID,
+			UserID:       l.UserID,
+			Balance:      domain.MinorUnitsToDollars(l.Balance),
+			LedgerTotal:  domain.MinorUnitsToDollars(l.LedgerTotal),
+			Difference:   domain.MinorUnitsToDollars(l.Difference()),
+			Transactions: l.Transactions,
+		})
+	}
+
+	if o.format == formatJSON {
+		return o.json(views)
+	}
+	if len(views) == 0 {
+		_, err := fmt.Fprintln(o.w, "All wallet balances match their transactions")
+		return err
+	}
+
+	var rows [][]string
+	for _, v := range views {
+		rows = append(rows, []string{
+			uintString(v.WalletID), uintString(v.UserID), money(v.Balance), money(v.LedgerTotal),
+			money(v.Difference), strconv.FormatInt(v.Transactions, 10),
+		})
+	}
+	return o.table([]string{"WALLET", "USER ID", "BALANCE", "LEDGER TOTAL", "DIFFERENCE", "TRANSACTIONS"}, rows)
+}
+
+var transactionHeader = []string{
+	"ID", "WALLET", "USER ID", "TYPE", "AMOUNT", "BALANCE BEFORE", "BALANCE AFTER",
+	"FROM WALLET", "TO WALLET", "UUID", "DESCRIPTION", "CREATED AT",
+}
+
+// csvHeader uses the JSON field names
+var csvHeader = []string{
+	"id", "wallet_id", "user_id", "type", "amount", "balance_before", "balance_after",
+	"from_wallet_id", "to_wallet_id", "transaction_uuid", "description", "created_at",
+}
+
+// transactionStream writes transactions as they are read, so that exports
+// do not have to fit in memory.
+type transactionStream struct {
+	o       *output
+	started bool
+	written int
+	table   *tabwriter.Writer
+	csv     *csv.Writer
+}
+
+func (o *output) transactionStream() *transactionStream {
+	return &transactionStream{o: o}
+}
+
+func (s *transactionStream) write(txs []*domain.Transaction) error {
+	if !s.started {
+		s.started = true
+		switch s.o.format {
+		case formatJSON:
+			if _, err := fmt.Fprint(s.o.w, "["); err != nil {
+				return err
+			}
+		case formatCSV:
+			s.csv = csv.NewWriter(s.o.w)
+			if err := s.csv.Write(csvHeader); err != nil {
+				return err
+			}
+		default:
+			s.table = tabwriter.NewWriter(s.o.w, 0, 0, 2, ' ', 0)
+			writeRow(s.table, transactionHeader)
+		}
+	}
+
+	for _, tx := range txs {
+		view := newTransactionView(tx)
+		switch s.o.format {
+		case formatJSON:
+			data, err := json.Marshal(view)
+			if err != nil {
+				return err
+			}
+			separator := ","
+			if s.written == 0 {
+				separator = ""
+			}
+			if _, err := fmt.Fprintf(s.o.w, "%s\n  %s", separator, data); err != nil {
+				return err
+			}
+		case formatCSV:
+			if err := s.csv.Write(transactionRow(view, true)); err != nil {
+				return err
+			}
+		default:
+			writeRow(s.table, transactionRow(view, false))
+		}
+		s.written++
+	}
+	return nil
+}
+
+func (s *transactionStream) close() error {
+	if !s.started {
+		if err := s.write(nil); err != nil {
+			return err
+		}
+	}
+	switch s.o.format {
+	case formatJSON:
+		closing := "\n]"
+		if s.written == 0 {
+			closing = "]"
+		}
+		_, err := fmt.Fprintln(s.o.w, closing)
+		return err
+	case formatCSV:
+		s.csv.Flush()
+		return s.csv.Error()
+	default:
+		return s.table.Flush()
+	}
+}
+
+func transactionRow(v transactionView, machine bool) []string {
+	created := timeString(&v.CreatedAt)
+	if machine {
+		created = v.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return []string{
+		uintString(v.ID), uintString(v.WalletID), uintString(v.UserID), v.Type,
+		money(v.Amount), money(v.BalanceBefore), money(v.BalanceAfter),
+		optionalUint(v.FromWalletID), optionalUint(v.ToWalletID),
+		v.TransactionUUID, v.Description, created,
+	}
+}
+
+func money(dollars float64) string {
+	return strconv.FormatFloat(dollars, 'f', 2, 64)
+}
+
+func uintString(v uint) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.FormatUint(uint64(v), 10)
+}
+
+func optionalUint(v *uint) string {
+	if v == nil {
+		return ""
+	}
+	return uintString(*v)
+}
+
+func timeString(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
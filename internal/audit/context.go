@@ -0,0 +1,33 @@
+package audit
+
+import "context"
+
+// Metadata describes who performed a request and where it came from. It is
+// attached to the request context by HTTP middleware and read by services
+// when they write audit log entries.
+type Metadata struct {
+	ActorID   *uint
+	ActorName string
+	IPAddress string
+	UserAgent string
+	RequestID string
+}
+
+type contextKey struct{}
+
+func WithMetadata(ctx context.Context, md Metadata) context.Context {
+	return context.WithValue(ctx, contextKey{}, md)
+}
+
+// WithActor returns a copy of ctx whose metadata names the authenticated user
+func WithActor(ctx context.Context, userID uint, username string) context.Context {
+	md := FromContext(ctx)
+	md.ActorID = &userID
+	md.ActorName = username
+	return WithMetadata(ctx, md)
+}
+
+func FromContext(ctx context.Context) Metadata {
+	md, _ := ctx.Value(contextKey{}).(Metadata)
+	return md
+}
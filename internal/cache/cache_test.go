@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestCaches(t *testing.T) {
+	server := miniredis.RunT(t)
+	memory := NewMemory()
+	now := time.Now()
+	memory.now = func() time.Time { return now }
+
+	backends := map[string]struct {
+		cache   Cache
+		advance func(time.Duration)
+	}{
+		"memory": {memory, func(d time.Duration) { now = now.Add(d) }},
+		"redis": {
+			NewRedisCache(redis.NewClient(&redis.Options{Addr: server.Addr()})),
+			server.FastForward,
+		},
+	}
+
+	for name, backend := range backends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			c := backend.cache
+
+			if _, err := c.Get(ctx, "missing"); !errors.Is(err, ErrMiss) {
+				t.Errorf("expected ErrMiss, got %v", err)
+			}
+
+			if err := c.Set(ctx, "wallet:1", []byte("one"), time.Minute); err != nil {
+				t.Fatal(err)
+			}
+			if value, err := c.Get(ctx, "wallet:1"); err != nil || string(value) != "one" {
+				t.Errorf("expected cached value, got %q, %v", value, err)
+			}
+
+			backend.advance(2 * time.Minute)
+			if _, err := c.Get(ctx, "wallet:1"); !errors.Is(err, ErrMiss) {
+				t.Errorf("expected expired entry to miss, got %v", err)
+			}
+
+			for _, key := range []string{"wallet:1:transactions:10:0", "wallet:1:transactions:10:10", "wallet:12:transactions:10:0", "wallet:1"} {
+				if err := c.Set(ctx, key, []byte(key), 0); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := c.DeletePrefix(ctx, "wallet:1:transactions:"); err != nil {
+				t.Fatal(err)
+			}
+			for key, cached := range map[string]bool{
+				"wallet:1:transactions:10:0":  false,
+				"wallet:1:transactions:10:10": false,
+				"wallet:12:transactions:10:0": true,
+				"wallet:1":                    true,
+			} {
+				_, err := c.Get(ctx, key)
+				if cached != (err == nil) {
+					t.Errorf("%s: expected cached=%v, got %v", key, cached, err)
+				}
+			}
+
+			if err := c.Delete(ctx, "wallet:1", "wallet:12:transactions:10:0"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := c.Get(ctx, "wallet:1"); !errors.Is(err, ErrMiss) {
+				t.Errorf("expected deleted key to miss, got %v", err)
+			}
+		})
+	}
+}
+
+func TestEscapePattern(t *testing.T) {
+	if got := escapePattern(`a*b?[c]\`); got != `a\*b\?\[c\]\\` {
+		t.Errorf("unexpected pattern %q", got)
+	}
+}
@@ -1,55 +1,136 @@
 package config
 
 import (
-	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// Config holds every setting of the service. Each field is read, in order
+// of precedence, from its env variable, from a file named by the variable
+// with a _FILE suffix, from the CONFIG_FILE config file under the lower-case
+// variable name, and finally from its default.
 type Config struct {
-	AppEnv       string
-	AppPort      string
-	AppJWTSecret string
+	AppEnv       string `env:"APP_ENV" default:"development"`
+	AppPort      string `env:"APP_PORT" default:"8080"`
+	GRPCPort     string `env:"GRPC_PORT" default:"9090"`
+	AppJWTSecret string `env:"APP_JWT_SECRET" default:"supersecret" secret:"true"`
 
-	MySQLHost     string
-	MySQLPort     string
-	MySQLUser     string
-	MySQLPassword string
-	MySQLDB       string
+	// HTTP server timeouts and shutdown
+	HTTPReadTimeout       time.Duration `env:"HTTP_READ_TIMEOUT" default:"15s"`
+	HTTPReadHeaderTimeout time.Duration `env:"HTTP_READ_HEADER_TIMEOUT" default:"5s"`
+	HTTPWriteTimeout      time.Duration `env:"HTTP_WRITE_TIMEOUT" default:"30s"`
+	HTTPIdleTimeout       time.Duration `env:"HTTP_IDLE_TIMEOUT" default:"2m"`
+	ShutdownDelay         time.Duration `env:"SHUTDOWN_DELAY" default:"0"`
+	ShutdownTimeout       time.Duration `env:"SHUTDOWN_TIMEOUT" default:"30s"`
 
-	RedisAddr     string
-	RedisDB       string
-	RedisPassword string
+	RequestSigningSecret  string        `env:"REQUEST_SIGNING_SECRET" default:"signingsecret" secret:"true"`
+	RequestSigningMaxSkew time.Duration `env:"REQUEST_SIGNING_MAX_SKEW" default:"5m"`
 
-	LogLevel string
+	// DBDriver selects the database: mysql, postgres or sqlite
+	DBDriver          string        `env:"DB_DRIVER" default:"mysql"`
+	DBMaxOpenConns    int           `env:"DB_MAX_OPEN_CONNS" default:"100"`
+	DBMaxIdleConns    int           `env:"DB_MAX_IDLE_CONNS" default:"10"`
+	DBConnMaxLifetime time.Duration `env:"DB_CONN_MAX_LIFETIME" default:"1h"`
+	DBConnMaxIdleTime time.Duration `env:"DB_CONN_MAX_IDLE_TIME" default:"0"`
+
+	MySQLHost     string `env:"MYSQL_HOST" default:"127.0.0.1"`
+	MySQLPort     string `env:"MYSQL_PORT" default:"3306"`
+	MySQLUser     string `env:"MYSQL_USER" default:"wallet"`
+	MySQLPassword string `env:"MYSQL_PASSWORD" default:"walletpw" secret:"true"`
+	MySQLDB       string `env:"MYSQL_DB" default:"walletdb"`
+
+	PostgresHost     string `env:"POSTGRES_HOST" default:"127.0.0.1"`
+	PostgresPort     string `env:"POSTGRES_PORT" default:"5432"`
+	PostgresUser     string `env:"POSTGRES_USER" default:"wallet"`
+	PostgresPassword string `env:"POSTGRES_PASSWORD" default:"walletpw" secret:"true"`
+	PostgresDB       string `env:"POSTGRES_DB" default:"walletdb"`
+	PostgresSSLMode  string `env:"POSTGRES_SSLMODE" default:"disable"`
+
+	SQLitePath string `env:"SQLITE_PATH" default:"wallet.db"`
+
+	RedisAddr         string        `env:"REDIS_ADDR" default:"127.0.0.1:6379"`
+	RedisDB           int           `env:"REDIS_DB" default:"0"`
+	RedisPassword     string        `env:"REDIS_PASSWORD" secret:"true"`
+	RedisPoolSize     int           `env:"REDIS_POOL_SIZE" default:"0"` // 0 uses go-redis' default
+	RedisDialTimeout  time.Duration `env:"REDIS_DIAL_TIMEOUT" default:"5s"`
+	RedisReadTimeout  time.Duration `env:"REDIS_READ_TIMEOUT" default:"3s"`
+	RedisWriteTimeout time.Duration `env:"REDIS_WRITE_TIMEOUT" default:"3s"`
+
+	WalletCacheTTL       time.Duration `env:"WALLET_CACHE_TTL" default:"5m"`
+	TransactionsCacheTTL time.Duration `env:"TRANSACTIONS_CACHE_TTL" default:"2m"`
+
+	LogLevel        string   `env:"LOG_LEVEL" default:"info"`
+	LogRedactFields []string `env:"LOG_REDACT_FIELDS" default:"password,current_password,new_password,token,refresh_token,reset_token,secret,authorization,api_key,signature"`
+
+	TracingExporter     string  `env:"TRACING_EXPORTER" default:"none"`
+	TracingOTLPProtocol string  `env:"TRACING_OTLP_PROTOCOL" default:"grpc"`
+	TracingFile         string  `env:"TRACING_FILE"`
+	TracingSampleRatio  float64 `env:"TRACING_SAMPLE_RATIO" default:"1"`
+
+	MigrateOnStart string `env:"MIGRATE_ON_START" default:"up"`
+
+	AuditRetention time.Duration `env:"AUDIT_RETENTION" default:"8760h"`
+
+	EventPublisher     string        `env:"EVENT_PUBLISHER" default:"redis"`
+	EventStream        string        `env:"EVENT_STREAM" default:"wallet-events"`
+	OutboxPollInterval time.Duration `env:"OUTBOX_POLL_INTERVAL" default:"1s"`
+	OutboxBatchSize    int           `env:"OUTBOX_BATCH_SIZE" default:"100"`
+	OutboxMaxAttempts  int           `env:"OUTBOX_MAX_ATTEMPTS" default:"10"`
+
+	WebhookMaxAttempts  int           `env:"WEBHOOK_MAX_ATTEMPTS" default:"8"`
+	WebhookTimeout      time.Duration `env:"WEBHOOK_TIMEOUT" default:"10s"`
+	WebhookPollInterval time.Duration `env:"WEBHOOK_POLL_INTERVAL" default:"1s"`
+	// Lets endpoints point at loopback and private addresses, for local
+	// development only
+	WebhookAllowPrivateNetworks bool `env:"WEBHOOK_ALLOW_PRIVATE_NETWORKS" default:"false"`
+
+	StreamHeartbeat   time.Duration `env:"STREAM_HEARTBEAT" default:"15s"`
+	StreamHistorySize int           `env:"STREAM_HISTORY_SIZE" default:"1000"`
+
+	NotifierType string `env:"NOTIFIER_TYPE" default:"log"`
+	NotifierFile string `env:"NOTIFIER_FILE"`
+
+	UsernamePattern       string `env:"USERNAME_PATTERN" default:"^[A-Za-z]+$"`
+	PasswordMinLength     int    `env:"PASSWORD_MIN_LENGTH" default:"8"`
+	PasswordMaxLength     int    `env:"PASSWORD_MAX_LENGTH" default:"64"`
+	PasswordRequireUpper  bool   `env:"PASSWORD_REQUIRE_UPPER" default:"false"`
+	PasswordRequireLower  bool   `env:"PASSWORD_REQUIRE_LOWER" default:"false"`
+	PasswordRequireDigit  bool   `env:"PASSWORD_REQUIRE_DIGIT" default:"false"`
+	PasswordRequireSymbol bool   `env:"PASSWORD_REQUIRE_SYMBOL" default:"false"`
+	PasswordBreachedList  string `env:"PASSWORD_BREACHED_LIST"`
+	PasswordHasher        string `env:"PASSWORD_HASHER" default:"argon2id"`
+	Argon2MemoryKB        int    `env:"ARGON2_MEMORY_KB" default:"65536"`
+	Argon2Iterations      int    `env:"ARGON2_ITERATIONS" default:"3"`
+	Argon2Parallelism     int    `env:"ARGON2_PARALLELISM" default:"2"`
+	BcryptCost            int    `env:"BCRYPT_COST" default:"10"`
 }
 
+// FileEnv names the optional YAML or TOML config file
+const FileEnv = "CONFIG_FILE"
+
+// Load reads the configuration from the environment, a .env file and the
+// file named by CONFIG_FILE, and validates it.
 func Load() (*Config, error) {
 	// Load .env file if it exists
 	_ = godotenv.Load()
 
-	return &Config{
-		AppEnv:       getEnv("APP_ENV", "development"),
-		AppPort:      getEnv("APP_PORT", "8080"),
-		AppJWTSecret: getEnv("APP_JWT_SECRET", "supersecret"),
-
-		MySQLHost:     getEnv("MYSQL_HOST", "127.0.0.1"),
-		MySQLPort:     getEnv("MYSQL_PORT", "3306"),
-		MySQLUser:     getEnv("MYSQL_USER", "wallet"),
-		MySQLPassword: getEnv("MYSQL_PASSWORD", "walletpw"),
-		MySQLDB:       getEnv("MYSQL_DB", "walletdb"),
-
-		RedisAddr:     getEnv("REDIS_ADDR", "127.0.0.1:6379"),
-		RedisDB:       getEnv("REDIS_DB", "0"),
-		RedisPassword: getEnv("REDIS_PASSWORD", ""),
-
-		LogLevel: getEnv("LOG_LEVEL", "info"),
-	}, nil
+	cfg, err := load(environ{})
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// Defaults returns the configuration with every default applied and nothing
+// read from the environment, for tests that build the service in process
+func Defaults() *Config {
+	cfg, err := load(noEnviron{})
+	if err != nil {
+		panic("config: invalid defaults: " + err.Error())
 	}
-	return defaultValue
+	return cfg
 }
@@ -0,0 +1,236 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type mapEnv map[string]string
+
+func (m mapEnv) Lookup(key string) (string, bool) {
+	value, ok := m[key]
+	return value, ok
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDefaults(t *testing.T) {
+	t.Setenv("APP_PORT", "9000")
+	cfg := Defaults()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("defaults do not validate: %v", err)
+	}
+	if cfg.AppPort != "8080" || cfg.RedisDB != 0 || cfg.WalletCacheTTL != 5*time.Minute || cfg.TracingSampleRatio != 1 {
+		t.Errorf("unexpected defaults: %+v", cfg)
+	}
+	if cfg.HTTPReadTimeout != 15*time.Second || cfg.RequestSigningMaxSkew != 5*time.Minute || cfg.AuditRetention != 365*24*time.Hour {
+		t.Errorf("unexpected default durations: %+v", cfg)
+	}
+	if len(cfg.LogRedactFields) == 0 || cfg.LogRedactFields[0] != "password" {
+		t.Errorf("expected default redact fields, got %v", cfg.LogRedactFields)
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	yamlFile := writeFile(t, "config.yaml", `
+app_port: 9000
+log_level: debug
+mysql:
+  host: db.internal
+db_max_open_conns: 20
+wallet_cache_ttl: 1m
+log_redact_fields: [password, pin]
+`)
+	tomlFile := writeFile(t, "config.toml", `
+app_port = 9000
+log_level = "debug"
+wallet_cache_ttl = "1m"
+log_redact_fields = ["password", "pin"]
+db_max_open_conns = 20
+
+[mysql]
+host = "db.internal"
+`)
+
+	for _, file := range []string{yamlFile, tomlFile} {
+		t.Run(filepath.Ext(file), func(t *testing.T) {
+			cfg, err := load(mapEnv{
+				FileEnv:     file,
+				"LOG_LEVEL": "warn",
+				"REDIS_DB":  "3",
+				"GRPC_PORT": "",
+			})
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			if cfg.AppPort != "9000" || cfg.MySQLHost != "db.internal" || cfg.DBMaxOpenConns != 20 {
+				t.Errorf("file values not applied: %+v", cfg)
+			}
+			if cfg.LogLevel != "warn" || cfg.RedisDB != 3 {
+				t.Errorf("env should override the file, got log_level=%s redis_db=%d", cfg.LogLevel, cfg.RedisDB)
+			}
+			if cfg.GRPCPort != "9090" {
+				t.Errorf("empty env should keep the default, got %q", cfg.GRPCPort)
+			}
+			if cfg.WalletCacheTTL != time.Minute {
+				t.Errorf("expected 1m wallet cache TTL, got %s", cfg.WalletCacheTTL)
+			}
+			if !reflect.DeepEqual(cfg.LogRedactFields, []string{"password", "pin"}) {
+				t.Errorf("unexpected redact fields %v", cfg.LogRedactFields)
+			}
+		})
+	}
+}
+
+func TestFileErrors(t *testing.T) {
+	tests := map[string]struct {
+		name, content, want string
+	}{
+		"unknown key":   {"config.yaml", "mysql_hots: x\n", `unknown setting "mysql_hots"`},
+		"bad duration":  {"config.yaml", "wallet_cache_ttl: 300\n", "WALLET_CACHE_TTL"},
+		"bad extension": {"config.json", "{}", "must be .yaml"},
+		"list":          {"config.toml", "app_port = [1, 2]\n", "got a list"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := load(mapEnv{FileEnv: writeFile(t, tt.name, tt.content)})
+			if err == nil || !strings.Contains(strings.ToLower(err.Error()), strings.ToLower(tt.want)) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestDurationNeedsUnit(t *testing.T) {
+	_, err := load(mapEnv{"REQUEST_SIGNING_MAX_SKEW": "300"})
+	if err == nil || !strings.Contains(err.Error(), "REQUEST_SIGNING_MAX_SKEW: invalid duration") {
+		t.Errorf("expected a duration error, got %v", err)
+	}
+
+	cfg, err := load(mapEnv{"REQUEST_SIGNING_MAX_SKEW": "90s", "OUTBOX_POLL_INTERVAL": "250ms"})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.RequestSigningMaxSkew != 90*time.Second || cfg.OutboxPollInterval != 250*time.Millisecond {
+		t.Errorf("durations not parsed: skew=%s poll=%s", cfg.RequestSigningMaxSkew, cfg.OutboxPollInterval)
+	}
+}
+
+func TestSecretFiles(t *testing.T) {
+	secret := writeFile(t, "jwt", "from-a-file\n")
+
+	cfg, err := load(mapEnv{"APP_JWT_SECRET_FILE": secret})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.AppJWTSecret != "from-a-file" {
+		t.Errorf("expected secret from file without trailing newline, got %q", cfg.AppJWTSecret)
+	}
+
+	_, err = load(mapEnv{"APP_JWT_SECRET_FILE": secret, "APP_JWT_SECRET": "inline"})
+	if err == nil || !strings.Contains(err.Error(), "both set") {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+
+	_, err = load(mapEnv{"MYSQL_PASSWORD_FILE": filepath.Join(t.TempDir(), "missing")})
+	if err == nil || !strings.Contains(err.Error(), "MYSQL_PASSWORD_FILE") {
+		t.Errorf("expected missing file error, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg, err := load(mapEnv{
+		"APP_ENV":          "production",
+		"APP_PORT":         "70000",
+		"MIGRATE_ON_START": "sometimes",
+		"NOTIFIER_TYPE":    "file",
+		"BCRYPT_COST":      "3",
+		"LOG_LEVEL":        "loud",
+		"WEBHOOK_TIMEOUT":  "0s",
+		"SHUTDOWN_DELAY":   "-5s",
+	})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	err = cfg.Validate()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{
+		"APP_PORT",
+		"APP_JWT_SECRET must not use the default value in production",
+		"REQUEST_SIGNING_SECRET must be at least 32 characters",
+		"MIGRATE_ON_START",
+		"NOTIFIER_FILE must be set",
+		"BCRYPT_COST",
+		"LOG_LEVEL",
+		"WEBHOOK_TIMEOUT must be greater than 0, got 0s",
+		"SHUTDOWN_DELAY must not be negative",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in:\n%v", want, err)
+		}
+	}
+
+	cfg.AppJWTSecret = strings.Repeat("j", 32)
+	cfg.RequestSigningSecret = strings.Repeat("s", 32)
+	cfg.AppPort, cfg.MigrateOnStart, cfg.NotifierType, cfg.BcryptCost, cfg.LogLevel = "8080", "up", "log", 10, "info"
+	cfg.WebhookTimeout, cfg.ShutdownDelay = 10*time.Second, 5*time.Second
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected valid production config, got %v", err)
+	}
+}
+
+func TestWriteRedacted(t *testing.T) {
+	cfg, err := load(mapEnv{"MYSQL_PASSWORD": "hunter2", "REDIS_PASSWORD": ""})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := cfg.Write(&out, true); err != nil {
+		t.Fatal(err)
+	}
+	printed := out.String()
+	if strings.Contains(printed, "hunter2") || strings.Contains(printed, "supersecret") {
+		t.Errorf("secret leaked:\n%s", printed)
+	}
+	for _, want := range []string{
+		"mysql_password: '" + Redacted + "'",
+		`redis_password: ""`,
+		"wallet_cache_ttl: 5m0s",
+		"mysql_host: 127.0.0.1",
+	} {
+		if !strings.Contains(printed, want) {
+			t.Errorf("expected %q in:\n%s", want, printed)
+		}
+	}
+
+	// The printed config loads back to the same values
+	reloaded, err := load(mapEnv{FileEnv: writeFile(t, "printed.yaml", printed)})
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	original := cfg.fields()
+	for i, f := range reloaded.fields() {
+		if f.secret {
+			f.value.Set(original[i].value)
+		}
+	}
+	if !reflect.DeepEqual(cfg, reloaded) {
+		t.Errorf("reloaded config differs:\n%+v\n%+v", cfg, reloaded)
+	}
+}
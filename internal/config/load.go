@@ -0,0 +1,249 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// lookup reads variables, os.LookupEnv outside of tests
+type lookup interface {
+	Lookup(key string) (string, bool)
+}
+
+type environ struct{}
+
+func (environ) Lookup(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+type noEnviron struct{}
+
+func (noEnviron) Lookup(string) (string, bool) {
+	return "", false
+}
+
+// field is a Config field with its tags
+type field struct {
+	env          string
+	defaultValue string
+	secret       bool
+	value        reflect.Value
+}
+
+// key names the field in config files
+func (f field) key() string {
+	return strings.ToLower(f.env)
+}
+
+func (c *Config) fields() []field {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	fields := make([]field, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag
+		fields = append(fields, field{
+			env:          tag.Get("env"),
+			defaultValue: tag.Get("default"),
+			secret:       tag.Get("secret") == "true",
+			value:        v.Field(i),
+		})
+	}
+	return fields
+}
+
+func load(env lookup) (*Config, error) {
+	cfg := &Config{}
+	fields := cfg.fields()
+	var errs []error
+
+	for _, f := range fields {
+		if f.defaultValue != "" {
+			if err := setString(f.value, f.defaultValue); err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid default: %w", f.env, err))
+			}
+		}
+	}
+
+	if path, ok := env.Lookup(FileEnv); ok && path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		byKey := make(map[string]field, len(fields))
+		for _, f := range fields {
+			byKey[f.key()] = f
+		}
+		for key, value := range values {
+			f, ok := byKey[key]
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s: unknown setting %q", path, key))
+				continue
+			}
+			if err := setValue(f.value, value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %s: %w", path, key, err))
+			}
+		}
+	}
+
+	// Empty variables count as unset, like blank lines in .env files
+	for _, f := range fields {
+		value, hasValue := env.Lookup(f.env)
+		hasValue = hasValue && value != ""
+		path, hasFile := env.Lookup(f.env + "_FILE")
+		hasFile = hasFile && path != ""
+
+		switch {
+		case hasValue && hasFile:
+			errs = append(errs, fmt.Errorf("%s and %s_FILE are both set", f.env, f.env))
+			continue
+		case hasFile:
+			data, err := os.ReadFile(path)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s_FILE: %w", f.env, err))
+				continue
+			}
+			value = strings.TrimRight(string(data), "\r\n")
+		case !hasValue:
+			continue
+		}
+
+		if err := setString(f.value, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.env, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return cfg, nil
+}
+
+// readFile decodes a YAML or TOML file. Nested tables are flattened by
+// joining their keys with underscores, so that
+//
+//	mysql:
+//	  host: db
+//
+// sets mysql_host.
+func readFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	raw := map[string]interface{}{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("config file %s must be .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	values := map[string]interface{}{}
+	flatten("", raw, values)
+	return values, nil
+}
+
+func flatten(prefix string, in map[string]interface{}, out map[string]interface{}) {
+	for key, value := range in {
+		key = strings.ToLower(key)
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			flatten(key, nested, out)
+			continue
+		}
+		out[key] = value
+	}
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setValue sets v from a decoded file value
+func setValue(v reflect.Value, value interface{}) error {
+	list, isList := value.([]interface{})
+	if v.Kind() == reflect.Slice {
+		if !isList {
+			return setString(v, fmt.Sprint(value))
+		}
+		items := make([]string, 0, len(list))
+		for _, item := range list {
+			items = append(items, fmt.Sprint(item))
+		}
+		v.Set(reflect.ValueOf(items))
+		return nil
+	}
+	if isList {
+		return fmt.Errorf("expected a single value, got a list")
+	}
+
+	switch value := value.(type) {
+	case string:
+		return setString(v, value)
+	case bool, int, int64, uint64, float64:
+		return setString(v, fmt.Sprint(value))
+	default:
+		return fmt.Errorf("unsupported value %v", value)
+	}
+}
+
+// setString parses s into v according to v's type
+func setString(v reflect.Value, s string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q, use a unit such as 30s or 5m", s)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+		v.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"io"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Redacted replaces non-empty secrets
+const Redacted = "[REDACTED]"
+
+// Write prints the configuration as a YAML config file, in field order.
+// With redact set, secrets are replaced by [REDACTED].
+func (c *Config) Write(w io.Writer, redact bool) error {
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	for _, f := range c.fields() {
+		value := &yaml.Node{}
+		switch v := f.value.Interface().(type) {
+		case time.Duration:
+			value.SetString(v.String())
+		case []string:
+			value.Kind = yaml.SequenceNode
+			value.Style = yaml.FlowStyle
+			for _, item := range v {
+				child := &yaml.Node{}
+				child.SetString(item)
+				value.Content = append(value.Content, child)
+			}
+		case string:
+			if redact && f.secret && v != "" {
+				v = Redacted
+			}
+			value.SetString(v)
+		case float64:
+			value.Kind, value.Tag, value.Value = yaml.ScalarNode, "!!float", strconv.FormatFloat(v, 'g', -1, 64)
+		default:
+			if err := value.Encode(v); err != nil {
+				return err
+			}
+		}
+
+		key := &yaml.Node{}
+		key.SetString(f.key())
+		doc.Content = append(doc.Content, key, value)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// minProductionSecretLength is the shortest JWT or signing secret accepted
+// when APP_ENV is production
+const minProductionSecretLength = 32
+
+// Validate reports every invalid setting at once, so that a misconfigured
+// deployment fails on start instead of on first use.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	oneOf := func(env, value string, allowed ...string) {
+		for _, a := range allowed {
+			if value == a {
+				return
+			}
+		}
+		errs = append(errs, fmt.Errorf("%s must be one of %v, got %q", env, allowed, value))
+	}
+	port := func(env, value string) {
+		n, err := strconv.Atoi(value)
+		check(err == nil && n > 0 && n <= 65535, "%s must be a port between 1 and 65535, got %q", env, value)
+	}
+	positive := func(env string, value int) {
+		check(value > 0, "%s must be greater than 0, got %d", env, value)
+	}
+	positiveDuration := func(env string, value time.Duration) {
+		check(value > 0, "%s must be greater than 0, got %s", env, value)
+	}
+	notNegative := func(env string, value int64) {
+		check(value >= 0, "%s must not be negative", env)
+	}
+
+	oneOf("APP_ENV", c.AppEnv, "development", "test", "staging", "production")
+	port("APP_PORT", c.AppPort)
+	port("GRPC_PORT", c.GRPCPort)
+
+	secret := func(env, value, defaultValue string) {
+		if value == "" {
+			errs = append(errs, fmt.Errorf("%s must be set", env))
+			return
+		}
+		if c.AppEnv != "production" {
+			return
+		}
+		check(value != defaultValue, "%s must not use the default value in production", env)
+		check(len(value) >= minProductionSecretLength, "%s must be at least %d characters in production", env, minProductionSecretLength)
+	}
+	secret("APP_JWT_SECRET", c.AppJWTSecret, "supersecret")
+	secret("REQUEST_SIGNING_SECRET", c.RequestSigningSecret, "signingsecret")
+	positiveDuration("REQUEST_SIGNING_MAX_SKEW", c.RequestSigningMaxSkew)
+
+	positiveDuration("HTTP_READ_TIMEOUT", c.HTTPReadTimeout)
+	positiveDuration("HTTP_READ_HEADER_TIMEOUT", c.HTTPReadHeaderTimeout)
+	notNegative("HTTP_WRITE_TIMEOUT", int64(c.HTTPWriteTimeout))
+	notNegative("HTTP_IDLE_TIMEOUT", int64(c.HTTPIdleTimeout))
+	notNegative("SHUTDOWN_DELAY", int64(c.ShutdownDelay))
+	positiveDuration("SHUTDOWN_TIMEOUT", c.ShutdownTimeout)
+
+	oneOf("DB_DRIVER", c.DBDriver, "mysql", "postgres", "sqlite")
+	switch c.DBDriver {
+	case "mysql":
+		check(c.MySQLHost != "", "MYSQL_HOST must be set")
+		port("MYSQL_PORT", c.MySQLPort)
+		check(c.MySQLDB != "", "MYSQL_DB must be set")
+	case "postgres":
+		check(c.PostgresHost != "", "POSTGRES_HOST must be set")
+		port("POSTGRES_PORT", c.PostgresPort)
+		check(c.PostgresDB != "", "POSTGRES_DB must be set")
+		oneOf("POSTGRES_SSLMODE", c.PostgresSSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+	case "sqlite":
+		check(c.SQLitePath != "", "SQLITE_PATH must be set")
+	}
+	positive("DB_MAX_OPEN_CONNS", c.DBMaxOpenConns)
+	notNegative("DB_MAX_IDLE_CONNS", int64(c.DBMaxIdleConns))
+	check(c.DBMaxIdleConns <= c.DBMaxOpenConns, "DB_MAX_IDLE_CONNS must not exceed DB_MAX_OPEN_CONNS")
+	notNegative("DB_CONN_MAX_LIFETIME", int64(c.DBConnMaxLifetime))
+	notNegative("DB_CONN_MAX_IDLE_TIME", int64(c.DBConnMaxIdleTime))
+
+	check(c.RedisAddr != "", "REDIS_ADDR must be set")
+	check(c.RedisDB >= 0 && c.RedisDB <= 15, "REDIS_DB must be between 0 and 15, got %d", c.RedisDB)
+	notNegative("REDIS_POOL_SIZE", int64(c.RedisPoolSize))
+	notNegative("REDIS_DIAL_TIMEOUT", int64(c.RedisDialTimeout))
+	notNegative("REDIS_READ_TIMEOUT", int64(c.RedisReadTimeout))
+	notNegative("REDIS_WRITE_TIMEOUT", int64(c.RedisWriteTimeout))
+
+	positiveDuration("WALLET_CACHE_TTL", c.WalletCacheTTL)
+	positiveDuration("TRANSACTIONS_CACHE_TTL", c.TransactionsCacheTTL)
+
+	_, err := logrus.ParseLevel(c.LogLevel)
+	check(err == nil, "LOG_LEVEL %q is not a log level", c.LogLevel)
+
+	oneOf("TRACING_EXPORTER", c.TracingExporter, "none", "otlp", "stdout")
+	oneOf("TRACING_OTLP_PROTOCOL", c.TracingOTLPProtocol, "grpc", "http")
+	check(c.TracingSampleRatio >= 0 && c.TracingSampleRatio <= 1, "TRACING_SAMPLE_RATIO must be between 0 and 1, got %g", c.TracingSampleRatio)
+
+	oneOf("MIGRATE_ON_START", c.MigrateOnStart, "up", "check", "off")
+	notNegative("AUDIT_RETENTION", int64(c.AuditRetention))
+
+	oneOf("EVENT_PUBLISHER", c.EventPublisher, "redis", "memory")
+	check(c.EventStream != "", "EVENT_STREAM must be set")
+	positiveDuration("OUTBOX_POLL_INTERVAL", c.OutboxPollInterval)
+	positive("OUTBOX_BATCH_SIZE", c.OutboxBatchSize)
+	positive("OUTBOX_MAX_ATTEMPTS", c.OutboxMaxAttempts)
+
+	positive("WEBHOOK_MAX_ATTEMPTS", c.WebhookMaxAttempts)
+	positiveDuration("WEBHOOK_TIMEOUT", c.WebhookTimeout)
+	positiveDuration("WEBHOOK_POLL_INTERVAL", c.WebhookPollInterval)
+
+	positiveDuration("STREAM_HEARTBEAT", c.StreamHeartbeat)
+	positive("STREAM_HISTORY_SIZE", c.StreamHistorySize)
+
+	oneOf("NOTIFIER_TYPE", c.NotifierType, "log", "file")
+	check(c.NotifierType != "file" || c.NotifierFile != "", "NOTIFIER_FILE must be set when NOTIFIER_TYPE is file")
+
+	_, err = regexp.Compile(c.UsernamePattern)
+	check(err == nil, "USERNAME_PATTERN does not compile: %v", err)
+	positive("PASSWORD_MIN_LENGTH", c.PasswordMinLength)
+	check(c.PasswordMaxLength >= c.PasswordMinLength, "PASSWORD_MAX_LENGTH must not be less than PASSWORD_MIN_LENGTH")
+
+	oneOf("PASSWORD_HASHER", c.PasswordHasher, "argon2id", "bcrypt")
+	positive("ARGON2_MEMORY_KB", c.Argon2MemoryKB)
+	positive("ARGON2_ITERATIONS", c.Argon2Iterations)
+	check(c.Argon2Parallelism > 0 && c.Argon2Parallelism <= 255, "ARGON2_PARALLELISM must be between 1 and 255, got %d", c.Argon2Parallelism)
+	check(c.BcryptCost >= 4 && c.BcryptCost <= 31, "BCRYPT_COST must be between 4 and 31, got %d", c.BcryptCost)
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,39 @@
+package credential
+
+import (
+	"fmt"
+
+	"github.com/SahandMohammed/wallet-service/internal/config"
+)
+
+// FromConfig builds the credential policy and password hasher from the
+// service configuration, for every command that creates or checks passwords
+func FromConfig(cfg *config.Config) (*Policy, Hasher, error) {
+	policy, err := NewPolicy(PolicyConfig{
+		UsernamePattern:   cfg.UsernamePattern,
+		PasswordMinLength: cfg.PasswordMinLength,
+		PasswordMaxLength: cfg.PasswordMaxLength,
+		RequireUpper:      cfg.PasswordRequireUpper,
+		RequireLower:      cfg.PasswordRequireLower,
+		RequireDigit:      cfg.PasswordRequireDigit,
+		RequireSymbol:     cfg.PasswordRequireSymbol,
+		BreachedListPath:  cfg.PasswordBreachedList,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("credential policy: %w", err)
+	}
+
+	hasher, err := NewHasher(HasherConfig{
+		Algorithm: cfg.PasswordHasher,
+		Argon2: Argon2Params{
+			Memory:      uint32(cfg.Argon2MemoryKB),
+			Iterations:  uint32(cfg.Argon2Iterations),
+			Parallelism: uint8(cfg.Argon2Parallelism),
+		},
+		BcryptCost: cfg.BcryptCost,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("password hasher: %w", err)
+	}
+	return policy, hasher, nil
+}
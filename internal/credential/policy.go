@@ -0,0 +1,152 @@
+package credential
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Policy holds the rules usernames and passwords must satisfy on registration
+// and whenever a password is changed or reset.
+type Policy struct {
+	UsernameMinLength int
+	UsernameMaxLength int
+	UsernamePattern   *regexp.Regexp
+
+	PasswordMinLength int
+	PasswordMaxLength int
+	RequireUpper      bool
+	RequireLower      bool
+	RequireDigit      bool
+	RequireSymbol     bool
+
+	breached map[string]struct{}
+}
+
+type PolicyConfig struct {
+	UsernamePattern   string
+	PasswordMinLength int
+	PasswordMaxLength int
+	RequireUpper      bool
+	RequireLower      bool
+	RequireDigit      bool
+	RequireSymbol     bool
+	BreachedListPath  string
+}
+
+func NewPolicy(cfg PolicyConfig) (*Policy, error) {
+	pattern, err := regexp.Compile(cfg.UsernamePattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid username pattern: %w", err)
+	}
+
+	if cfg.PasswordMinLength < 1 || cfg.PasswordMaxLength < cfg.PasswordMinLength {
+		return nil, fmt.Errorf("invalid password length bounds %d-%d", cfg.PasswordMinLength, cfg.PasswordMaxLength)
+	}
+
+	policy := &Policy{
+		UsernameMinLength: 3,
+		UsernameMaxLength: 50,
+		UsernamePattern:   pattern,
+		PasswordMinLength: cfg.PasswordMinLength,
+		PasswordMaxLength: cfg.PasswordMaxLength,
+		RequireUpper:      cfg.RequireUpper,
+		RequireLower:      cfg.RequireLower,
+		RequireDigit:      cfg.RequireDigit,
+		RequireSymbol:     cfg.RequireSymbol,
+	}
+
+	if cfg.BreachedListPath != "" {
+		policy.breached, err = loadBreachedList(cfg.BreachedListPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return policy, nil
+}
+
+func (p *Policy) ValidateUsername(username string) error {
+	if len(username) < p.UsernameMinLength || len(username) > p.UsernameMaxLength {
+		return fmt.Errorf("username must be between %d and %d characters", p.UsernameMinLength, p.UsernameMaxLength)
+	}
+
+	if !p.UsernamePattern.MatchString(username) {
+		return errors.New("username contains invalid characters")
+	}
+
+	return nil
+}
+
+func (p *Policy) ValidatePassword(password string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.PasswordMinLength || length > p.PasswordMaxLength {
+		return fmt.Errorf("password must be between %d and %d characters", p.PasswordMinLength, p.PasswordMaxLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	var missing []string
+	if p.RequireUpper && !hasUpper {
+		missing = append(missing, "an uppercase letter")
+	}
+	if p.RequireLower && !hasLower {
+		missing = append(missing, "a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		missing = append(missing, "a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		missing = append(missing, "a symbol")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("password must contain %s", strings.Join(missing, ", "))
+	}
+
+	if _, found := p.breached[strings.ToLower(password)]; found {
+		return errors.New("password has appeared in a data breach, please choose another one")
+	}
+
+	return nil
+}
+
+// loadBreachedList reads one password per line. Matching is case-insensitive.
+func loadBreachedList(path string) (map[string]struct{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password list: %w", err)
+	}
+	defer f.Close()
+
+	breached := make(map[string]struct{})
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		breached[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read breached password list: %w", err)
+	}
+
+	return breached, nil
+}
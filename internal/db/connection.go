@@ -2,24 +2,27 @@ package db
 
 import (
 	"fmt"
-	"strconv"
-	"time"
+	"net"
+	"net/url"
 
 	"github.com/SahandMohammed/wallet-service/internal/config"
+	"github.com/SahandMohammed/wallet-service/internal/dialect"
+	"github.com/SahandMohammed/wallet-service/internal/tracing"
+	"github.com/glebarez/sqlite"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
-func NewMySQLConnection(cfg *config.Config) (*gorm.DB, error) {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		cfg.MySQLUser,
-		cfg.MySQLPassword,
-		cfg.MySQLHost,
-		cfg.MySQLPort,
-		cfg.MySQLDB,
-	)
+// NewConnection opens the database selected by DB_DRIVER and configures
+// its connection pool
+func NewConnection(cfg *config.Config) (*gorm.DB, error) {
+	dialector, err := Dialector(cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	var logLevel logger.LogLevel
 	if cfg.AppEnv == "development" {
@@ -28,11 +31,16 @@ func NewMySQLConnection(cfg *config.Config) (*gorm.DB, error) {
 		logLevel = logger.Error
 	}
 
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger: logger.Default.LogMode(logLevel),
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to MySQL: %w", err)
+		return nil, fmt.Errorf("failed to connect to %s: %w", cfg.DBDriver, err)
+	}
+
+	// Trace every query
+	if err := db.Use(tracing.GORMPlugin{}); err != nil {
+		return nil, err
 	}
 
 	// Configure connection pool
@@ -41,24 +49,65 @@ func NewMySQLConnection(cfg *config.Config) (*gorm.DB, error) {
 		return nil, err
 	}
 
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
-	sqlDB.SetConnMaxLifetime(time.Hour)
+	sqlDB.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	sqlDB.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	sqlDB.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.DBConnMaxIdleTime)
 
 	return db, nil
 }
 
-func NewRedisConnection(cfg *config.Config) (*redis.Client, error) {
-	db, err := strconv.Atoi(cfg.RedisDB)
-	if err != nil {
-		db = 0
+// Dialector returns the GORM dialector for DB_DRIVER
+func Dialector(cfg *config.Config) (gorm.Dialector, error) {
+	switch cfg.DBDriver {
+	case dialect.MySQL:
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+			cfg.MySQLUser,
+			cfg.MySQLPassword,
+			cfg.MySQLHost,
+			cfg.MySQLPort,
+			cfg.MySQLDB,
+		)
+		return mysql.Open(dsn), nil
+	case dialect.Postgres:
+		dsn := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(cfg.PostgresUser, cfg.PostgresPassword),
+			Host:     net.JoinHostPort(cfg.PostgresHost, cfg.PostgresPort),
+			Path:     "/" + cfg.PostgresDB,
+			RawQuery: url.Values{"sslmode": {cfg.PostgresSSLMode}}.Encode(),
+		}
+		return postgres.Open(dsn.String()), nil
+	case dialect.SQLite:
+		return sqlite.Open(SQLiteDSN(cfg.SQLitePath)), nil
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", cfg.DBDriver)
 	}
+}
+
+// SQLiteDSN opens the database file at path with foreign keys enforced.
+// Transactions take the write lock when they begin, standing in for the row
+// locks SQLite lacks, and wait up to 10 seconds for it. WAL lets reads
+// proceed meanwhile.
+func SQLiteDSN(path string) string {
+	return "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+}
 
+func NewRedisConnection(cfg *config.Config) (*redis.Client, error) {
 	client := redis.NewClient(&redis.Options{
-		Addr:     cfg.RedisAddr,
-		Password: cfg.RedisPassword,
-		DB:       db,
+		Addr:         cfg.RedisAddr,
+		Password:     cfg.RedisPassword,
+		DB:           cfg.RedisDB,
+		PoolSize:     cfg.RedisPoolSize,
+		DialTimeout:  cfg.RedisDialTimeout,
+		ReadTimeout:  cfg.RedisReadTimeout,
+		WriteTimeout: cfg.RedisWriteTimeout,
 	})
 
+	// Trace every command
+	if err := tracing.InstrumentRedis(client); err != nil {
+		return nil, err
+	}
+
 	return client, nil
 }
@@ -0,0 +1,91 @@
+// Package dialect hides the differences between the supported databases:
+// row locking and the classification of driver errors.
+package dialect
+
+import (
+	"errors"
+
+	"github.com/glebarez/go-sqlite"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Names as reported by gorm.Dialector.Name
+const (
+	MySQL    = "mysql"
+	Postgres = "postgres"
+	SQLite   = "sqlite"
+)
+
+// All lists the supported dialects
+var All = []string{MySQL, Postgres, SQLite}
+
+// ForUpdate locks the rows read by the query until the transaction ends.
+// Use it as a scope: tx.Scopes(dialect.ForUpdate).First(&wallet, id).
+//
+// SQLite has no row locks. Its connections begin transactions with the
+// database write lock instead, which serializes them.
+func ForUpdate(db *gorm.DB) *gorm.DB {
+	if db.Dialector.Name() == SQLite {
+		return db
+	}
+	return db.Clauses(clause.Locking{Strength: "UPDATE"})
+}
+
+// ForUpdateSkipLocked is ForUpdate that skips rows locked by another
+// transaction, so that workers can claim rows concurrently.
+func ForUpdateSkipLocked(db *gorm.DB) *gorm.DB {
+	if db.Dialector.Name() == SQLite {
+		return db
+	}
+	return db.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+}
+
+// IsUniqueViolation reports whether err was caused by a duplicate key
+func IsUniqueViolation(err error) bool {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+
+	var (
+		mysqlErr  *mysql.MySQLError
+		pgErr     *pgconn.PgError
+		sqliteErr *sqlite.Error
+	)
+	switch {
+	case errors.As(err, &mysqlErr):
+		return mysqlErr.Number == 1062 // ER_DUP_ENTRY
+	case errors.As(err, &pgErr):
+		return pgErr.Code == "23505" // unique_violation
+	case errors.As(err, &sqliteErr):
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE ||
+			sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+	}
+	return false
+}
+
+// IsRetryable reports whether err aborted a transaction because of a
+// deadlock, a lock timeout or a serialization failure. The transaction had
+// no effect and can be run again.
+func IsRetryable(err error) bool {
+	var (
+		mysqlErr  *mysql.MySQLError
+		pgErr     *pgconn.PgError
+		sqliteErr *sqlite.Error
+	)
+	switch {
+	case errors.As(err, &mysqlErr):
+		// ER_LOCK_DEADLOCK, ER_LOCK_WAIT_TIMEOUT
+		return mysqlErr.Number == 1213 || mysqlErr.Number == 1205
+	case errors.As(err, &pgErr):
+		// deadlock_detected, serialization_failure, lock_not_available
+		return pgErr.Code == "40P01" || pgErr.Code == "40001" || pgErr.Code == "55P03"
+	case errors.As(err, &sqliteErr):
+		primary := sqliteErr.Code() & 0xff
+		return primary == sqlite3.SQLITE_BUSY || primary == sqlite3.SQLITE_LOCKED
+	}
+	return false
+}
@@ -14,9 +14,25 @@ type User struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 
+	// Tokens issued before this moment are rejected
+	PasswordChangedAt *time.Time `json:"password_changed_at,omitempty"`
+
+	// Admins can use the /admin API. Granted with walletctl.
+	IsAdmin bool `json:"is_admin" gorm:"not null;default:false"`
+
 	Wallets []Wallet `json:"wallets,omitempty" gorm:"foreignKey:UserID"`
 }
 
+// PasswordResetToken stores only the SHA-256 hash of the token sent to the user.
+type PasswordResetToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex;not null;size:64"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
 type Wallet struct {
 	ID        uint           `json:"id" gorm:"primaryKey"`
 	UserID    uint           `json:"user_id" gorm:"not null;index"`
@@ -25,6 +41,10 @@ type Wallet struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 
+	// Frozen wallets reject deposits and transfers until unfrozen
+	FrozenAt     *time.Time `json:"frozen_at,omitempty"`
+	FrozenReason string     `json:"frozen_reason,omitempty" gorm:"size:255"`
+
 	User         User          `json:"user,omitempty" gorm:"foreignKey:UserID"`
 	Transactions []Transaction `json:"transactions,omitempty" gorm:"foreignKey:WalletID"`
 }
@@ -35,6 +55,8 @@ const (
 	TransactionTypeDeposit  TransactionType = "deposit"
 	TransactionTypeTransfer TransactionType = "transfer"
 	TransactionTypeWithdraw TransactionType = "withdraw"
+	// Manual corrections made by operators, positive or negative
+	TransactionTypeAdjustment TransactionType = "adjustment"
 )
 
 type Transaction struct {
@@ -72,6 +94,10 @@ func (t *Transaction) SetAmountFromDollars(dollars float64) {
 	t.Amount = int64(dollars * 100)
 }
 
+// Currency is the currency of every wallet. Amounts are stored in its minor
+// units.
+const Currency = "USD"
+
 func DollarsToMinorUnits(dollars float64) int64 {
 	return int64(dollars * 100)
 }
@@ -79,3 +105,170 @@ func DollarsToMinorUnits(dollars float64) int64 {
 func MinorUnitsToDollars(minorUnits int64) float64 {
 	return float64(minorUnits) / 100.0
 }
+
+// Session is created for every login and backs the JWT issued for it.
+// DeviceHash identifies the client device across sessions.
+type Session struct {
+	ID         string     `json:"id" gorm:"primaryKey;size:36"`
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	UserAgent  string     `json:"user_agent" gorm:"size:255"`
+	IPAddress  string     `json:"ip_address" gorm:"size:45"`
+	DeviceHash string     `json:"-" gorm:"size:64;index"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+type APIKeyScope string
+
+const (
+	ScopeWalletsRead     APIKeyScope = "wallets:read"
+	ScopeWalletsCreate   APIKeyScope = "wallets:create"
+	ScopeWalletsDeposit  APIKeyScope = "wallets:deposit"
+	ScopeWalletsTransfer APIKeyScope = "wallets:transfer"
+	ScopeAdminRead       APIKeyScope = "admin:read"
+)
+
+var APIKeyScopes = []APIKeyScope{
+	ScopeWalletsRead,
+	ScopeWalletsCreate,
+	ScopeWalletsDeposit,
+	ScopeWalletsTransfer,
+	ScopeAdminRead,
+}
+
+// APIKey lets server-to-server clients authenticate without the login flow.
+// Only the SHA-256 hash of the secret is stored; Prefix identifies the key.
+type APIKey struct {
+	ID         uint          `json:"id" gorm:"primaryKey"`
+	UserID     uint          `json:"user_id" gorm:"not null;index"`
+	Name       string        `json:"name" gorm:"not null;size:100"`
+	Prefix     string        `json:"prefix" gorm:"uniqueIndex;not null;size:16"`
+	KeyHash    string        `json:"-" gorm:"not null;size:64"`
+	Scopes     []APIKeyScope `json:"scopes" gorm:"serializer:json;type:text"`
+	WalletIDs  []uint        `json:"wallet_ids,omitempty" gorm:"serializer:json;type:text"` // Empty means all of the user's wallets
+	ExpiresAt  *time.Time    `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time    `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time    `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time     `json:"created_at"`
+
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+func (k *APIKey) HasScope(scope APIKeyScope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (k *APIKey) AllowsWallet(walletID uint) bool {
+	if len(k.WalletIDs) == 0 {
+		return true
+	}
+	for _, id := range k.WalletIDs {
+		if id == walletID {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditLog is an append-only record of a security relevant or administrative
+// action. Before and After hold JSON snapshots of the affected target.
+type AuditLog struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	ActorID    *uint     `json:"actor_id,omitempty" gorm:"index"`
+	ActorName  string    `json:"actor_name" gorm:"size:50"`
+	Action     string    `json:"action" gorm:"not null;size:64;index"`
+	TargetType string    `json:"target_type" gorm:"size:32;index:idx_audit_target"`
+	TargetID   string    `json:"target_id" gorm:"size:64;index:idx_audit_target"`
+	Before     string    `json:"before,omitempty" gorm:"type:text"`
+	After      string    `json:"after,omitempty" gorm:"type:text"`
+	IPAddress  string    `json:"ip_address" gorm:"size:45"`
+	UserAgent  string    `json:"user_agent" gorm:"size:255"`
+	RequestID  string    `json:"request_id" gorm:"size:64;index"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+}
+
+// OutboxEvent is a domain event written in the same database transaction as
+// the state change it describes. The relay publishes it afterwards and sets
+// PublishedAt, giving at-least-once delivery. Failed events are retried from
+// NextAttemptAt and set aside with ParkedAt after too many attempts.
+type OutboxEvent struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	EventID       string     `json:"event_id" gorm:"uniqueIndex;not null;size:36"`
+	Type          string     `json:"type" gorm:"not null;size:64"`
+	AggregateType string     `json:"aggregate_type" gorm:"not null;size:32"`
+	AggregateID   uint       `json:"aggregate_id" gorm:"not null;index"`
+	Payload       string     `json:"payload" gorm:"type:text;not null"`
+	CreatedAt     time.Time  `json:"created_at"`
+	PublishedAt   *time.Time `json:"published_at,omitempty" gorm:"index"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	LastError     string     `json:"last_error,omitempty" gorm:"size:255"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	ParkedAt      *time.Time `json:"parked_at,omitempty"`
+}
+
+// WebhookEndpoint receives signed event notifications for a user's wallets,
+// or for a single wallet when WalletID is set. An empty EventTypes list
+// subscribes to every event type.
+type WebhookEndpoint struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	UserID     uint           `json:"user_id" gorm:"not null;index"`
+	WalletID   *uint          `json:"wallet_id,omitempty" gorm:"index"`
+	URL        string         `json:"url" gorm:"not null;size:2048"`
+	Secret     string         `json:"-" gorm:"not null;size:64"`
+	EventTypes []string       `json:"event_types" gorm:"serializer:json;type:text"`
+	Active     bool           `json:"active" gorm:"not null;default:true"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (e *WebhookEndpoint) Subscribes(eventType string) bool {
+	if len(e.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range e.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryDead      WebhookDeliveryStatus = "dead"
+)
+
+// WebhookDelivery is one event to be sent to one endpoint, together with the
+// outcome of the latest attempt.
+type WebhookDelivery struct {
+	ID             uint                  `json:"id" gorm:"primaryKey"`
+	EndpointID     uint                  `json:"endpoint_id" gorm:"not null;uniqueIndex:idx_webhook_delivery_event"`
+	EventID        string                `json:"event_id" gorm:"not null;size:36;uniqueIndex:idx_webhook_delivery_event"`
+	EventType      string                `json:"event_type" gorm:"not null;size:64"`
+	Payload        string                `json:"payload" gorm:"type:text;not null"`
+	Status         WebhookDeliveryStatus `json:"status" gorm:"not null;size:20;index:idx_webhook_delivery_due"`
+	Attempts       int                   `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at" gorm:"index:idx_webhook_delivery_due"`
+	LastStatusCode int                   `json:"last_status_code,omitempty"`
+	LastError      string                `json:"last_error,omitempty" gorm:"size:255"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+
+	Endpoint WebhookEndpoint `json:"-" gorm:"foreignKey:EndpointID"`
+}
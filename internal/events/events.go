@@ -0,0 +1,147 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/google/uuid"
+)
+
+const (
+	TypeWalletCreated     = "WalletCreated"
+	TypeDepositCompleted  = "DepositCompleted"
+	TypeTransferCompleted = "TransferCompleted"
+	TypeBalanceAdjusted   = "BalanceAdjusted"
+)
+
+const AggregateWallet = "wallet"
+
+// Amounts in payloads are in minor units (cents), like the database.
+
+type WalletCreated struct {
+	WalletID uint `json:"wallet_id"`
+	UserID   uint `json:"user_id"`
+}
+
+type DepositCompleted struct {
+	WalletID        uint   `json:"wallet_id"`
+	UserID          uint   `json:"user_id"`
+	Amount          int64  `json:"amount"`
+	BalanceBefore   int64  `json:"balance_before"`
+	BalanceAfter    int64  `json:"balance_after"`
+	TransactionID   uint   `json:"transaction_id"`
+	TransactionUUID string `json:"transaction_uuid"`
+	Description     string `json:"description"`
+}
+
+type TransferCompleted struct {
+	FromWalletID        uint   `json:"from_wallet_id"`
+	ToWalletID          uint   `json:"to_wallet_id"`
+	FromUserID          uint   `json:"from_user_id"`
+	ToUserID            uint   `json:"to_user_id"`
+	Amount              int64  `json:"amount"`
+	FromBalanceAfter    int64  `json:"from_balance_after"`
+	ToBalanceAfter      int64  `json:"to_balance_after"`
+	FromTransactionUUID string `json:"from_transaction_uuid"`
+	ToTransactionUUID   string `json:"to_transaction_uuid"`
+	Description         string `json:"description"`
+}
+
+// BalanceAdjusted is a manual correction by an operator. Amount is negative
+// when funds were removed.
+type BalanceAdjusted struct {
+	WalletID        uint   `json:"wallet_id"`
+	UserID          uint   `json:"user_id"`
+	Amount          int64  `json:"amount"`
+	BalanceBefore   int64  `json:"balance_before"`
+	BalanceAfter    int64  `json:"balance_after"`
+	TransactionID   uint   `json:"transaction_id"`
+	TransactionUUID string `json:"transaction_uuid"`
+	Reason          string `json:"reason"`
+}
+
+// Envelope is the published form of an event. Consumers should deduplicate
+// on EventID since delivery is at-least-once.
+type Envelope struct {
+	EventID       string          `json:"event_id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   uint            `json:"aggregate_id"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// NewOutboxEvent builds an outbox row for the given payload. The caller
+// persists it inside the transaction that makes the change.
+func NewOutboxEvent(eventType, aggregateType string, aggregateID uint, payload interface{}) (*domain.OutboxEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.OutboxEvent{
+		EventID:       uuid.New().String(),
+		Type:          eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Payload:       string(data),
+	}, nil
+}
+
+func EnvelopeFromOutbox(event *domain.OutboxEvent) Envelope {
+	return Envelope{
+		EventID:       event.EventID,
+		Type:          event.Type,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		OccurredAt:    event.CreatedAt.UTC(),
+		Payload:       json.RawMessage(event.Payload),
+	}
+}
+
+// Subject is a wallet affected by an event, together with its owner
+type Subject struct {
+	UserID   uint
+	WalletID uint
+}
+
+// Subjects lists the wallets an event concerns. A transfer concerns both the
+// source and the destination wallet.
+func Subjects(envelope Envelope) ([]Subject, error) {
+	switch envelope.Type {
+	case TypeWalletCreated:
+		var payload WalletCreated
+		if err := json.Unmarshal(envelope.Payload, &payload); err != nil {
+			return nil, err
+		}
+		return []Subject{{UserID: payload.UserID, WalletID: payload.WalletID}}, nil
+
+	case TypeDepositCompleted:
+		var payload DepositCompleted
+		if err := json.Unmarshal(envelope.Payload, &payload); err != nil {
+			return nil, err
+		}
+		return []Subject{{UserID: payload.UserID, WalletID: payload.WalletID}}, nil
+
+	case TypeBalanceAdjusted:
+		var payload BalanceAdjusted
+		if err := json.Unmarshal(envelope.Payload, &payload); err != nil {
+			return nil, err
+		}
+		return []Subject{{UserID: payload.UserID, WalletID: payload.WalletID}}, nil
+
+	case TypeTransferCompleted:
+		var payload TransferCompleted
+		if err := json.Unmarshal(envelope.Payload, &payload); err != nil {
+			return nil, err
+		}
+		return []Subject{
+			{UserID: payload.FromUserID, WalletID: payload.FromWalletID},
+			{UserID: payload.ToUserID, WalletID: payload.ToWalletID},
+		}, nil
+
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,117 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type Publisher interface {
+	Publish(ctx context.Context, envelope Envelope) error
+}
+
+type redisStreamPublisher struct {
+	client *redis.Client
+	stream string
+	maxLen int64
+}
+
+// NewRedisStreamPublisher appends events to a Redis stream, trimmed to about
+// maxLen entries. Consumers read it with consumer groups (XREADGROUP).
+func NewRedisStreamPublisher(client *redis.Client, stream string, maxLen int64) Publisher {
+	return &redisStreamPublisher{
+		client: client,
+		stream: stream,
+		maxLen: maxLen,
+	}
+}
+
+func (p *redisStreamPublisher) Publish(ctx context.Context, envelope Envelope) error {
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	return p.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: p.stream,
+		MaxLen: p.maxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"event_id":       envelope.EventID,
+			"type":           envelope.Type,
+			"aggregate_type": envelope.AggregateType,
+			"aggregate_id":   strconv.FormatUint(uint64(envelope.AggregateID), 10),
+			"data":           string(data),
+		},
+	}).Err()
+}
+
+// MemoryPublisher keeps published events in memory, for tests
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []Envelope
+	err    error
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (p *MemoryPublisher) Publish(ctx context.Context, envelope Envelope) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	p.events = append(p.events, envelope)
+	return nil
+}
+
+// FailWith makes Publish return err, until it is called again with nil
+func (p *MemoryPublisher) FailWith(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+// Events returns a copy of everything published so far
+func (p *MemoryPublisher) Events() []Envelope {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Envelope(nil), p.events...)
+}
+
+type multiPublisher struct {
+	publishers []Publisher
+}
+
+// NewMultiPublisher fans every event out to all publishers. If any of them
+// fails the relay retries the event, so the others may see it twice.
+func NewMultiPublisher(publishers ...Publisher) Publisher {
+	return &multiPublisher{publishers: publishers}
+}
+
+func (p *multiPublisher) Publish(ctx context.Context, envelope Envelope) error {
+	for _, publisher := range p.publishers {
+		if err := publisher.Publish(ctx, envelope); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewPublisher builds the publisher selected by configuration
+func NewPublisher(publisherType string, client *redis.Client, stream string) (Publisher, error) {
+	switch publisherType {
+	case "", "redis":
+		return NewRedisStreamPublisher(client, stream, 100000), nil
+	case "memory":
+		return NewMemoryPublisher(), nil
+	default:
+		return nil, fmt.Errorf("unknown event publisher %q", publisherType)
+	}
+}
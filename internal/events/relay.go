@@ -0,0 +1,182 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/dialect"
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	relayClaimLease  = time.Minute
+	relayBaseBackoff = 5 * time.Second
+	relayMaxBackoff  = 10 * time.Minute
+)
+
+// Relay publishes outbox events. Rows are claimed with SKIP LOCKED so several
+// instances can run relays side by side without publishing the same batch.
+// An event is only marked published after the publisher accepted it; a crash
+// in between means it is published again, so delivery is at-least-once.
+type Relay struct {
+	db          *gorm.DB
+	publisher   Publisher
+	batchSize   int
+	maxAttempts int
+	interval    time.Duration
+}
+
+func NewRelay(db *gorm.DB, publisher Publisher, batchSize, maxAttempts int, interval time.Duration) *Relay {
+	return &Relay{
+		db:          db,
+		publisher:   publisher,
+		batchSize:   batchSize,
+		maxAttempts: maxAttempts,
+		interval:    interval,
+	}
+}
+
+// Run polls the outbox until ctx is cancelled
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		published, err := r.ProcessBatch(ctx)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to relay outbox events")
+		}
+
+		// Keep draining while there is a backlog
+		if err == nil && published == r.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessBatch publishes up to batchSize due events in insertion order and
+// returns how many were published. The batch is claimed in a short
+// transaction and published after it committed, so no row locks are held
+// while the publisher is called.
+//
+// A failed event is retried with backoff and parked after maxAttempts, so
+// it does not hold up the events behind it for long. The batch stops at the
+// first failure and hands the rest back, so that an outage of the publisher
+// costs one attempt per poll rather than one per event.
+func (r *Relay) ProcessBatch(ctx context.Context) (int, error) {
+	pending, err := r.claim(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for i, event := range pending {
+		if err := r.publisher.Publish(ctx, EnvelopeFromOutbox(event)); err != nil {
+			if err := r.recordFailure(ctx, event, err); err != nil {
+				return published, err
+			}
+			return published, r.release(ctx, pending[i+1:])
+		}
+
+		if err := r.db.WithContext(ctx).Model(event).Updates(map[string]interface{}{
+			"published_at": time.Now(),
+			"attempts":     gorm.Expr("attempts + 1"),
+		}).Error; err != nil {
+			return published, err
+		}
+		published++
+	}
+
+	return published, nil
+}
+
+// claim picks the due events and pushes their next attempt out by a lease,
+// so other relays skip them while they are being published. A relay that
+// dies mid-batch leaves them to be retried once the lease runs out.
+func (r *Relay) claim(ctx context.Context, now time.Time) ([]*domain.OutboxEvent, error) {
+	var pending []*domain.OutboxEvent
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Scopes(dialect.ForUpdateSkipLocked).
+			Where("published_at IS NULL AND parked_at IS NULL").
+			Where("next_attempt_at IS NULL OR next_attempt_at <= ?", now).
+			Order("id").
+			Limit(r.batchSize).
+			Find(&pending).Error; err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+
+		return tx.Model(&domain.OutboxEvent{}).
+			Where("id IN ?", eventIDs(pending)).
+			Update("next_attempt_at", now.Add(relayClaimLease)).Error
+	})
+
+	return pending, err
+}
+
+// release makes claimed events due again without counting an attempt
+func (r *Relay) release(ctx context.Context, events []*domain.OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&domain.OutboxEvent{}).
+		Where("id IN ?", eventIDs(events)).
+		Update("next_attempt_at", nil).Error
+}
+
+// recordFailure schedules the next attempt of an event, or parks it when it
+// has run out of attempts
+func (r *Relay) recordFailure(ctx context.Context, event *domain.OutboxEvent, publishErr error) error {
+	now := time.Now()
+	attempts := event.Attempts + 1
+
+	lastError := publishErr.Error()
+	if len(lastError) > 255 {
+		lastError = lastError[:255]
+	}
+	updates := map[string]interface{}{
+		"attempts":   attempts,
+		"last_error": lastError,
+	}
+
+	log := logrus.WithError(publishErr).WithFields(logrus.Fields{
+		"event_id":   event.EventID,
+		"event_type": event.Type,
+		"attempts":   attempts,
+	})
+	if attempts >= r.maxAttempts {
+		updates["parked_at"] = now
+		log.Error("Parked outbox event after too many failed attempts")
+	} else {
+		updates["next_attempt_at"] = now.Add(relayBackoff(attempts))
+		log.Warn("Failed to publish outbox event")
+	}
+
+	return r.db.WithContext(ctx).Model(event).Updates(updates).Error
+}
+
+func relayBackoff(attempt int) time.Duration {
+	if attempt >= 20 {
+		return relayMaxBackoff
+	}
+	return min(relayBaseBackoff<<(attempt-1), relayMaxBackoff)
+}
+
+func eventIDs(events []*domain.OutboxEvent) []uint {
+	ids := make([]uint, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
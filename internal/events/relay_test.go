@@ -0,0 +1,225 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/db"
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/migration"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const relayTestAttempts = 3
+
+func openOutbox(t *testing.T) *gorm.DB {
+	t.Helper()
+	gormDB, err := gorm.Open(sqlite.Open(db.SQLiteDSN(filepath.Join(t.TempDir(), "wallet.db"))), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := gormDB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	m, err := migration.New(gormDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	return gormDB
+}
+
+// addEvents writes n WalletCreated events and returns them in id order
+func addEvents(t *testing.T, gormDB *gorm.DB, n int) []*domain.OutboxEvent {
+	t.Helper()
+	var added []*domain.OutboxEvent
+	for i := 1; i <= n; i++ {
+		event, err := NewOutboxEvent(TypeWalletCreated, AggregateWallet, uint(i), WalletCreated{WalletID: uint(i), UserID: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := gormDB.Create(event).Error; err != nil {
+			t.Fatal(err)
+		}
+		added = append(added, event)
+	}
+	return added
+}
+
+func reload(t *testing.T, gormDB *gorm.DB, event *domain.OutboxEvent) *domain.OutboxEvent {
+	t.Helper()
+	var stored domain.OutboxEvent
+	if err := gormDB.First(&stored, event.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &stored
+}
+
+// makeDue ends the backoff of every waiting event
+func makeDue(t *testing.T, gormDB *gorm.DB) {
+	t.Helper()
+	if err := gormDB.Model(&domain.OutboxEvent{}).
+		Where("next_attempt_at IS NOT NULL").
+		Update("next_attempt_at", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func processBatch(t *testing.T, relay *Relay, want int) {
+	t.Helper()
+	published, err := relay.ProcessBatch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if published != want {
+		t.Fatalf("expected %d events to be published, got %d", want, published)
+	}
+}
+
+func publishedIDs(publisher *MemoryPublisher) []string {
+	var ids []string
+	for _, envelope := range publisher.Events() {
+		ids = append(ids, envelope.EventID)
+	}
+	return ids
+}
+
+func TestRelayRecovers(t *testing.T) {
+	gormDB := openOutbox(t)
+	publisher := NewMemoryPublisher()
+	relay := NewRelay(gormDB, publisher, 10, relayTestAttempts, time.Second)
+	added := addEvents(t, gormDB, 3)
+
+	// The batch stops at the first failure and hands the rest back
+	publisher.FailWith(errors.New("stream unavailable"))
+	before := time.Now()
+	processBatch(t, relay, 0)
+
+	first := reload(t, gormDB, added[0])
+	if first.Attempts != 1 || first.LastError != "stream unavailable" || first.NextAttemptAt == nil || first.PublishedAt != nil {
+		t.Fatalf("expected the failure to be recorded, got %+v", first)
+	}
+	if wait := first.NextAttemptAt.Sub(before); wait < relayBaseBackoff || wait > relayBaseBackoff+time.Second {
+		t.Errorf("expected a retry in %s, got %s", relayBaseBackoff, wait)
+	}
+	for _, event := range added[1:] {
+		if stored := reload(t, gormDB, event); stored.Attempts != 0 || stored.NextAttemptAt != nil {
+			t.Errorf("expected event %d to be released untouched, got %+v", event.ID, stored)
+		}
+	}
+
+	// Events in backoff are skipped, so the ones behind them get their turn
+	processBatch(t, relay, 0)
+	if stored := reload(t, gormDB, added[1]); stored.Attempts != 1 {
+		t.Errorf("expected the second event to be tried, got %d attempts", stored.Attempts)
+	}
+
+	publisher.FailWith(nil)
+	processBatch(t, relay, 1)
+	if ids := publishedIDs(publisher); len(ids) != 1 || ids[0] != added[2].EventID {
+		t.Fatalf("expected only the due event to be published, got %v", ids)
+	}
+
+	makeDue(t, gormDB)
+	processBatch(t, relay, 2)
+	processBatch(t, relay, 0)
+
+	wantAttempts := []int{2, 2, 1}
+	for i, event := range added {
+		stored := reload(t, gormDB, event)
+		if stored.PublishedAt == nil || stored.ParkedAt != nil || stored.Attempts != wantAttempts[i] {
+			t.Errorf("expected event %d published after %d attempts, got %+v", event.ID, wantAttempts[i], stored)
+		}
+	}
+	if got := len(publisher.Events()); got != 3 {
+		t.Errorf("expected 3 events to be published once each, got %d", got)
+	}
+}
+
+// poisonPublisher rejects one event and publishes the others
+type poisonPublisher struct {
+	*MemoryPublisher
+	poison string
+	calls  int
+}
+
+func (p *poisonPublisher) Publish(ctx context.Context, envelope Envelope) error {
+	if envelope.EventID == p.poison {
+		p.calls++
+		return errors.New("payload rejected")
+	}
+	return p.MemoryPublisher.Publish(ctx, envelope)
+}
+
+func TestRelayParksPoisonEvent(t *testing.T) {
+	gormDB := openOutbox(t)
+	added := addEvents(t, gormDB, 2)
+	publisher := &poisonPublisher{MemoryPublisher: NewMemoryPublisher(), poison: added[0].EventID}
+	relay := NewRelay(gormDB, publisher, 10, relayTestAttempts, time.Second)
+
+	processBatch(t, relay, 0)
+	processBatch(t, relay, 1)
+	for i := 1; i < relayTestAttempts; i++ {
+		makeDue(t, gormDB)
+		processBatch(t, relay, 0)
+	}
+
+	poison := reload(t, gormDB, added[0])
+	if poison.ParkedAt == nil || poison.PublishedAt != nil || poison.Attempts != relayTestAttempts || poison.LastError != "payload rejected" {
+		t.Fatalf("expected the event to be parked, got %+v", poison)
+	}
+
+	// Parked events are not tried again
+	makeDue(t, gormDB)
+	processBatch(t, relay, 0)
+	if publisher.calls != relayTestAttempts {
+		t.Errorf("expected %d attempts, got %d", relayTestAttempts, publisher.calls)
+	}
+	if ids := publishedIDs(publisher.MemoryPublisher); len(ids) != 1 || ids[0] != added[1].EventID {
+		t.Errorf("expected the other event to be published, got %v", ids)
+	}
+}
+
+func TestRelayClaimsBatch(t *testing.T) {
+	gormDB := openOutbox(t)
+	publisher := NewMemoryPublisher()
+	relay := NewRelay(gormDB, publisher, 10, relayTestAttempts, time.Second)
+	addEvents(t, gormDB, 2)
+
+	// A relay that claimed the batch and died holds it until the lease ends
+	claimed, err := relay.claim(context.Background(), time.Now())
+	if err != nil || len(claimed) != 2 {
+		t.Fatalf("expected to claim 2 events, got %d, %v", len(claimed), err)
+	}
+	processBatch(t, relay, 0)
+
+	makeDue(t, gormDB)
+	processBatch(t, relay, 2)
+}
+
+func TestRelayBackoff(t *testing.T) {
+	tests := map[int]time.Duration{
+		1:  5 * time.Second,
+		2:  10 * time.Second,
+		7:  320 * time.Second,
+		8:  relayMaxBackoff,
+		30: relayMaxBackoff,
+	}
+	for attempt, want := range tests {
+		if got := relayBackoff(attempt); got != want {
+			t.Errorf("attempt %d: expected %s, got %s", attempt, want, got)
+		}
+	}
+}
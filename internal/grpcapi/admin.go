@@ -0,0 +1,123 @@
+package grpcapi
+
+import (
+	"context"
+
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/repository"
+	"github.com/SahandMohammed/wallet-service/internal/service"
+	walletv1 "github.com/SahandMohammed/wallet-service/proto/wallet/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type adminServer struct {
+	walletv1.UnimplementedAdminServiceServer
+	adminService service.AdminService
+}
+
+// requireAdmin rejects callers that are not admin users, like the /admin routes
+func (s *adminServer) requireAdmin(ctx context.Context) error {
+	c, _ := callerFromContext(ctx)
+	isAdmin, err := s.adminService.IsAdmin(ctx, c.UserID)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if !isAdmin {
+		return status.Error(codes.PermissionDenied, "admin access required")
+	}
+	return nil
+}
+
+func (s *adminServer) ListUsers(ctx context.Context, req *walletv1.ListUsersRequest) (*walletv1.ListUsersResponse, error) {
+	if err := s.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	pageReq, err := pageRequest(req.GetLimit(), req.GetCursor(), req.GetIncludeTotal())
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := s.adminService.PageUsers(ctx, pageReq)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	response := &walletv1.ListUsersResponse{Total: page.Total}
+	response.NextCursor, response.PrevCursor = pageCursors(page)
+	for _, user := range page.Items {
+		response.Users = append(response.Users, toProtoUser(user))
+	}
+	return response, nil
+}
+
+func (s *adminServer) ListTransactions(ctx context.Context, req *walletv1.AdminListTransactionsRequest) (*walletv1.AdminListTransactionsResponse, error) {
+	if err := s.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	pageReq, err := pageRequest(req.GetLimit(), req.GetCursor(), req.GetIncludeTotal())
+	if err != nil {
+		return nil, err
+	}
+
+	filters := service.AdminTransactionFilters{
+		UserID:    optionalUint(req.UserId),
+		StartDate: optionalTime(req.GetStartDate()),
+		EndDate:   optionalTime(req.GetEndDate()),
+	}
+
+	if req.GetType() != "" {
+		txType := domain.TransactionType(req.GetType())
+		switch txType {
+		case domain.TransactionTypeDeposit, domain.TransactionTypeTransfer, domain.TransactionTypeWithdraw, domain.TransactionTypeAdjustment:
+			filters.Type = &txType
+		default:
+			return nil, status.Error(codes.InvalidArgument, "unknown transaction type")
+		}
+	}
+
+	page, err := s.adminService.PageTransactions(ctx, filters, pageReq)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	response := &walletv1.AdminListTransactionsResponse{Total: page.Total}
+	response.NextCursor, response.PrevCursor = pageCursors(page)
+	for _, tx := range page.Items {
+		response.Transactions = append(response.Transactions, toProtoTransaction(tx))
+	}
+	return response, nil
+}
+
+func (s *adminServer) ListAuditLogs(ctx context.Context, req *walletv1.ListAuditLogsRequest) (*walletv1.ListAuditLogsResponse, error) {
+	if err := s.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	pageReq, err := pageRequest(req.GetLimit(), req.GetCursor(), req.GetIncludeTotal())
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := s.adminService.PageAuditLogs(ctx, repository.AuditLogFilters{
+		ActorID:    optionalUint(req.ActorId),
+		Action:     req.GetAction(),
+		TargetType: req.GetTargetType(),
+		TargetID:   req.GetTargetId(),
+		RequestID:  req.GetRequestId(),
+		StartDate:  optionalTime(req.GetStartDate()),
+		EndDate:    optionalTime(req.GetEndDate()),
+	}, pageReq)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	response := &walletv1.ListAuditLogsResponse{Total: page.Total}
+	response.NextCursor, response.PrevCursor = pageCursors(page)
+	for _, entry := range page.Items {
+		response.AuditLogs = append(response.AuditLogs, toProtoAuditLog(entry))
+	}
+	return response, nil
+}
@@ -0,0 +1,114 @@
+package grpcapi
+
+import (
+	"context"
+
+	"github.com/SahandMohammed/wallet-service/internal/audit"
+	"github.com/SahandMohammed/wallet-service/internal/service"
+	walletv1 "github.com/SahandMohammed/wallet-service/proto/wallet/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type authServer struct {
+	walletv1.UnimplementedAuthServiceServer
+	authService service.AuthService
+}
+
+func (s *authServer) Register(ctx context.Context, req *walletv1.RegisterRequest) (*walletv1.RegisterResponse, error) {
+	if req.GetUsername() == "" || req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "username and password are required")
+	}
+
+	user, err := s.authService.Register(ctx, req.GetUsername(), req.GetPassword())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return &walletv1.RegisterResponse{User: toProtoUser(user)}, nil
+}
+
+func (s *authServer) Login(ctx context.Context, req *walletv1.LoginRequest) (*walletv1.LoginResponse, error) {
+	if req.GetUsername() == "" || req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "username and password are required")
+	}
+
+	token, err := s.authService.Login(ctx, req.GetUsername(), req.GetPassword(), clientInfo(ctx))
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return &walletv1.LoginResponse{Token: token}, nil
+}
+
+func (s *authServer) ChangePassword(ctx context.Context, req *walletv1.ChangePasswordRequest) (*walletv1.ChangePasswordResponse, error) {
+	c, _ := callerFromContext(ctx)
+	if req.GetCurrentPassword() == "" || req.GetNewPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "current_password and new_password are required")
+	}
+
+	token, err := s.authService.ChangePassword(ctx, c.UserID, req.GetCurrentPassword(), req.GetNewPassword(), clientInfo(ctx))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return &walletv1.ChangePasswordResponse{Token: token}, nil
+}
+
+// RequestPasswordReset succeeds whether or not the user exists
+func (s *authServer) RequestPasswordReset(ctx context.Context, req *walletv1.RequestPasswordResetRequest) (*walletv1.RequestPasswordResetResponse, error) {
+	if req.GetUsername() == "" {
+		return nil, status.Error(codes.InvalidArgument, "username is required")
+	}
+
+	if err := s.authService.RequestPasswordReset(ctx, req.GetUsername()); err != nil {
+		return nil, status.Error(codes.Internal, "failed to process password reset request")
+	}
+
+	return &walletv1.RequestPasswordResetResponse{}, nil
+}
+
+func (s *authServer) ResetPassword(ctx context.Context, req *walletv1.ResetPasswordRequest) (*walletv1.ResetPasswordResponse, error) {
+	if req.GetToken() == "" || req.GetNewPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "token and new_password are required")
+	}
+
+	if err := s.authService.ResetPassword(ctx, req.GetToken(), req.GetNewPassword()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return &walletv1.ResetPasswordResponse{}, nil
+}
+
+func (s *authServer) ListSessions(ctx context.Context, req *walletv1.ListSessionsRequest) (*walletv1.ListSessionsResponse, error) {
+	c, _ := callerFromContext(ctx)
+
+	sessions, err := s.authService.ListSessions(ctx, c.UserID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	response := &walletv1.ListSessionsResponse{}
+	for _, session := range sessions {
+		response.Sessions = append(response.Sessions, toProtoSession(session, c.SessionID))
+	}
+	return response, nil
+}
+
+func (s *authServer) RevokeSession(ctx context.Context, req *walletv1.RevokeSessionRequest) (*walletv1.RevokeSessionResponse, error) {
+	c, _ := callerFromContext(ctx)
+
+	if err := s.authService.RevokeSession(ctx, c.UserID, req.GetSessionId()); err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	return &walletv1.RevokeSessionResponse{}, nil
+}
+
+func clientInfo(ctx context.Context) service.ClientInfo {
+	md := audit.FromContext(ctx)
+	return service.ClientInfo{
+		UserAgent: md.UserAgent,
+		IPAddress: md.IPAddress,
+	}
+}
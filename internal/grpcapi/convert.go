@@ -0,0 +1,130 @@
+package grpcapi
+
+import (
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/repository"
+	walletv1 "github.com/SahandMohammed/wallet-service/proto/wallet/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toProtoUser(user *domain.User) *walletv1.User {
+	pb := &walletv1.User{
+		Id:        uint64(user.ID),
+		Username:  user.Username,
+		CreatedAt: timestamppb.New(user.CreatedAt),
+	}
+	for i := range user.Wallets {
+		pb.Wallets = append(pb.Wallets, toProtoWallet(&user.Wallets[i]))
+	}
+	return pb
+}
+
+func toProtoWallet(wallet *domain.Wallet) *walletv1.Wallet {
+	return &walletv1.Wallet{
+		Id:        uint64(wallet.ID),
+		UserId:    uint64(wallet.UserID),
+		Balance:   domain.MinorUnitsToDollars(wallet.Balance),
+		CreatedAt: timestamppb.New(wallet.CreatedAt),
+	}
+}
+
+func toProtoTransaction(tx *domain.Transaction) *walletv1.Transaction {
+	pb := &walletv1.Transaction{
+		Id:              uint64(tx.ID),
+		WalletId:        uint64(tx.WalletID),
+		Type:            string(tx.Type),
+		Amount:          domain.MinorUnitsToDollars(tx.Amount),
+		BalanceBefore:   domain.MinorUnitsToDollars(tx.BalanceBefore),
+		BalanceAfter:    domain.MinorUnitsToDollars(tx.BalanceAfter),
+		TransactionUuid: tx.TransactionUUID,
+		Description:     tx.Description,
+		CreatedAt:       timestamppb.New(tx.CreatedAt),
+	}
+	if tx.FromWalletID != nil {
+		id := uint64(*tx.FromWalletID)
+		pb.FromWalletId = &id
+	}
+	if tx.ToWalletID != nil {
+		id := uint64(*tx.ToWalletID)
+		pb.ToWalletId = &id
+	}
+	return pb
+}
+
+func toProtoSession(session *domain.Session, currentSessionID string) *walletv1.Session {
+	return &walletv1.Session{
+		Id:         session.ID,
+		UserAgent:  session.UserAgent,
+		IpAddress:  session.IPAddress,
+		CreatedAt:  timestamppb.New(session.CreatedAt),
+		LastSeenAt: timestamppb.New(session.LastSeenAt),
+		ExpiresAt:  timestamppb.New(session.ExpiresAt),
+		Current:    session.ID == currentSessionID,
+	}
+}
+
+func toProtoAuditLog(entry *domain.AuditLog) *walletv1.AuditLog {
+	pb := &walletv1.AuditLog{
+		Id:         uint64(entry.ID),
+		ActorName:  entry.ActorName,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetId:   entry.TargetID,
+		Before:     entry.Before,
+		After:      entry.After,
+		IpAddress:  entry.IPAddress,
+		UserAgent:  entry.UserAgent,
+		RequestId:  entry.RequestID,
+		CreatedAt:  timestamppb.New(entry.CreatedAt),
+	}
+	if entry.ActorID != nil {
+		id := uint64(*entry.ActorID)
+		pb.ActorId = &id
+	}
+	return pb
+}
+
+func optionalUint(value *uint64) *uint {
+	if value == nil {
+		return nil
+	}
+	v := uint(*value)
+	return &v
+}
+
+func optionalTime(value *timestamppb.Timestamp) *time.Time {
+	if value == nil {
+		return nil
+	}
+	t := value.AsTime()
+	return &t
+}
+
+// pageRequest applies the same bounds as the HTTP handlers
+func pageRequest(limit int32, cursor string, includeTotal bool) (repository.PageRequest, error) {
+	req := repository.PageRequest{Limit: int(limit), IncludeTotal: includeTotal}
+	if req.Limit <= 0 || req.Limit > 100 {
+		req.Limit = 10
+	}
+	if cursor != "" {
+		if err := req.SetCursor(cursor); err != nil {
+			return req, status.Error(codes.InvalidArgument, "invalid cursor")
+		}
+	}
+	return req, nil
+}
+
+// pageCursors encodes the cursors leading to the pages around page
+func pageCursors[T any](page *repository.Page[T]) (next, prev string) {
+	if page.Next != nil {
+		next = repository.EncodeCursor(*page.Next, false)
+	}
+	if page.Prev != nil {
+		prev = repository.EncodeCursor(*page.Prev, true)
+	}
+	return next, prev
+}
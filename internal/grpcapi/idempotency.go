@@ -0,0 +1,152 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/logging"
+	walletv1 "github.com/SahandMohammed/wallet-service/proto/wallet/v1"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	idempotencyKeyMetadata      = "idempotency-key"
+	idempotentReplayedMetadata  = "idempotent-replayed"
+	idempotencyTTL              = 24 * time.Hour
+	idempotencyStateRunning     = "processing"
+	idempotencyStateCompleted   = "completed"
+	idempotencyKeyMinLength     = 8
+	idempotencyKeyMaxLength     = 255
+	idempotencyUnavailableError = "unable to process idempotency key"
+)
+
+// idempotentResponses returns an empty response of each money method, to
+// decode a stored one into
+var idempotentResponses = map[string]func() proto.Message{
+	walletv1.WalletService_Deposit_FullMethodName:  func() proto.Message { return &walletv1.DepositResponse{} },
+	walletv1.WalletService_Transfer_FullMethodName: func() proto.Message { return &walletv1.TransferResponse{} },
+}
+
+type idempotencyRecord struct {
+	State       string     `json:"state"`
+	Fingerprint string     `json:"fingerprint"`
+	Code        codes.Code `json:"code,omitempty"`
+	Message     string     `json:"message,omitempty"`
+	Response    []byte     `json:"response,omitempty"`
+}
+
+// idempotencyInterceptor makes money calls carrying an "idempotency-key"
+// metadata entry safe to retry, like IdempotencyMiddleware does for the
+// HTTP routes. The first outcome for a key is stored for 24 hours and
+// replayed for later calls with the same key and request. A call with the
+// same key but a different request is rejected, as is one arriving while
+// the first is still running. Keys are scoped to the caller and method.
+func idempotencyInterceptor(redisClient *redis.Client) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		newResponse, ok := idempotentResponses[info.FullMethod]
+		idempotencyKey := firstMetadata(ctx, idempotencyKeyMetadata)
+		if !ok || idempotencyKey == "" {
+			return handler(ctx, req)
+		}
+		if len(idempotencyKey) < idempotencyKeyMinLength || len(idempotencyKey) > idempotencyKeyMaxLength {
+			return nil, status.Errorf(codes.InvalidArgument, "idempotency-key must be between %d and %d characters", idempotencyKeyMinLength, idempotencyKeyMaxLength)
+		}
+
+		message, _ := req.(proto.Message)
+		body, err := proto.MarshalOptions{Deterministic: true}.Marshal(message)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid request")
+		}
+		bodyHash := sha256.Sum256(body)
+		fingerprint := hex.EncodeToString(bodyHash[:])
+
+		c, _ := callerFromContext(ctx)
+		storeKey := fmt.Sprintf("idempotency:%d:grpc:%s:%s", c.UserID, info.FullMethod, idempotencyKey)
+
+		running, _ := json.Marshal(idempotencyRecord{State: idempotencyStateRunning, Fingerprint: fingerprint})
+		acquired, err := redisClient.SetNX(ctx, storeKey, running, idempotencyTTL).Result()
+		if err != nil {
+			// Fail closed, these calls move money
+			logging.FromContext(ctx).WithError(err).Error("Failed to reserve idempotency key")
+			return nil, status.Error(codes.Unavailable, idempotencyUnavailableError)
+		}
+		if !acquired {
+			return replayIdempotent(ctx, redisClient, storeKey, fingerprint, newResponse)
+		}
+
+		resp, handlerErr := handler(ctx, req)
+
+		record := idempotencyRecord{State: idempotencyStateCompleted, Fingerprint: fingerprint}
+		if handlerErr != nil {
+			st := status.Convert(handlerErr)
+			// Server errors are not stored so that the client can retry
+			if retryable(st.Code()) {
+				redisClient.Del(ctx, storeKey)
+				return resp, handlerErr
+			}
+			record.Code, record.Message = st.Code(), st.Message()
+		} else {
+			respMessage, _ := resp.(proto.Message)
+			if record.Response, err = proto.Marshal(respMessage); err != nil {
+				redisClient.Del(ctx, storeKey)
+				return resp, handlerErr
+			}
+		}
+
+		completed, _ := json.Marshal(record)
+		if err := redisClient.Set(ctx, storeKey, completed, idempotencyTTL).Err(); err != nil {
+			logging.FromContext(ctx).WithError(err).Error("Failed to store idempotent response")
+		}
+		return resp, handlerErr
+	}
+}
+
+func replayIdempotent(ctx context.Context, redisClient *redis.Client, storeKey, fingerprint string, newResponse func() proto.Message) (interface{}, error) {
+	data, err := redisClient.Get(ctx, storeKey).Bytes()
+	if err != nil {
+		return nil, status.Error(codes.Aborted, "request with this idempotency key is in progress")
+	}
+
+	var record idempotencyRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, status.Error(codes.Unavailable, idempotencyUnavailableError)
+	}
+	if record.Fingerprint != fingerprint {
+		return nil, status.Error(codes.InvalidArgument, "idempotency key was already used with a different request")
+	}
+	if record.State != idempotencyStateCompleted {
+		return nil, status.Error(codes.Aborted, "request with this idempotency key is in progress")
+	}
+
+	if err := grpc.SetHeader(ctx, metadata.Pairs(idempotentReplayedMetadata, "true")); err != nil {
+		logging.FromContext(ctx).WithError(err).Warn("Failed to set idempotent replay header")
+	}
+	if record.Code != codes.OK {
+		return nil, status.Error(record.Code, record.Message)
+	}
+
+	resp := newResponse()
+	if err := proto.Unmarshal(record.Response, resp); err != nil {
+		return nil, status.Error(codes.Unavailable, idempotencyUnavailableError)
+	}
+	return resp, nil
+}
+
+// retryable reports whether a failure is on the server side, the equivalent
+// of a 5xx response
+func retryable(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.Internal, codes.Unavailable, codes.DataLoss, codes.DeadlineExceeded, codes.Canceled:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/SahandMohammed/wallet-service/internal/audit"
+	"github.com/SahandMohammed/wallet-service/internal/http/middleware"
+	"github.com/SahandMohammed/wallet-service/internal/logging"
+	"github.com/SahandMohammed/wallet-service/internal/service"
+	walletv1 "github.com/SahandMohammed/wallet-service/proto/wallet/v1"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// publicMethods can be called without a token, like the /auth routes
+var publicMethods = map[string]bool{
+	walletv1.AuthService_Register_FullMethodName:             true,
+	walletv1.AuthService_Login_FullMethodName:                true,
+	walletv1.AuthService_RequestPasswordReset_FullMethodName: true,
+	walletv1.AuthService_ResetPassword_FullMethodName:        true,
+}
+
+type contextKey struct{}
+
+// caller is the authenticated user of a call
+type caller struct {
+	UserID    uint
+	Username  string
+	SessionID string
+}
+
+func callerFromContext(ctx context.Context) (caller, bool) {
+	c, ok := ctx.Value(contextKey{}).(caller)
+	return c, ok
+}
+
+func recoveryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logging.FromContext(ctx).WithField("method", info.FullMethod).Errorf("Recovered from panic in gRPC handler: %v", r)
+				err = status.Error(codes.Internal, "internal error")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// metadataInterceptor attaches the caller's address, user agent and request
+// id to the context for audit logging and to the logger, like
+// AuditContextMiddleware and RequestIDMiddleware. The request id is echoed
+// in the response header.
+func metadataInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		requestID := logging.RequestID(firstMetadata(ctx, "x-request-id"))
+		if err := grpc.SetHeader(ctx, metadata.Pairs("x-request-id", requestID)); err != nil {
+			logging.FromContext(ctx).WithError(err).Warn("Failed to set request id header")
+		}
+
+		ctx = logging.WithFields(ctx, logrus.Fields{
+			"request_id": requestID,
+			"method":     info.FullMethod,
+		})
+		return handler(audit.WithMetadata(ctx, audit.Metadata{
+			IPAddress: clientIP(ctx),
+			UserAgent: firstMetadata(ctx, "user-agent"),
+			RequestID: requestID,
+		}), req)
+	}
+}
+
+// moneyMethods move money. They get the money rate limit and accept
+// idempotency keys, like the deposit and transfer routes.
+var moneyMethods = map[string]bool{
+	walletv1.WalletService_Deposit_FullMethodName:  true,
+	walletv1.WalletService_Transfer_FullMethodName: true,
+}
+
+// rateLimitInterceptor applies the policies of the matching HTTP routes:
+// the auth limit to AuthService per client address, and the money or read
+// limit to everything else per user. The keys are the ones the HTTP API
+// uses, so a user has one budget across both.
+func rateLimitInterceptor(limiter middleware.RateLimiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if strings.HasPrefix(info.FullMethod, "/grpc.") {
+			return handler(ctx, req)
+		}
+
+		policy := middleware.ReadRateLimitPolicy
+		switch {
+		case strings.HasPrefix(info.FullMethod, "/"+walletv1.AuthService_ServiceDesc.ServiceName+"/"):
+			policy = middleware.AuthRateLimitPolicy
+		case moneyMethods[info.FullMethod]:
+			policy = middleware.MoneyRateLimitPolicy
+		}
+
+		key := "ip:" + clientIP(ctx)
+		if c, ok := callerFromContext(ctx); ok && policy != middleware.AuthRateLimitPolicy {
+			key = fmt.Sprintf("user:%d", c.UserID)
+		}
+
+		result, err := limiter.Allow(ctx, key, policy)
+		if err != nil {
+			// Never reject traffic because the limiter itself failed
+			logging.FromContext(ctx).WithError(err).WithField("policy", policy.Name).Error("Rate limiter failed")
+			return handler(ctx, req)
+		}
+		if !result.Allowed {
+			return nil, status.Error(codes.ResourceExhausted, "Rate limit exceeded")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// authInterceptor validates the bearer token from the "authorization"
+// metadata entry with AuthService.ValidateToken. Only login tokens are
+// accepted: API keys, with their scopes, wallet restrictions and request
+// signatures, are limited to the HTTP API.
+func authInterceptor(authService service.AuthService) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if publicMethods[info.FullMethod] || strings.HasPrefix(info.FullMethod, "/grpc.") {
+			return handler(ctx, req)
+		}
+
+		authHeader := firstMetadata(ctx, "authorization")
+		if authHeader == "" {
+			return nil, status.Error(codes.Unauthenticated, "authorization metadata required")
+		}
+
+		parts := strings.SplitN(authHeader, " ", 2)
+		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+			return nil, status.Error(codes.Unauthenticated, "invalid authorization metadata format")
+		}
+
+		claims, err := authService.ValidateToken(ctx, parts[1])
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+
+		ctx = audit.WithActor(ctx, claims.UserID, claims.Username)
+		ctx = logging.WithFields(ctx, logrus.Fields{"user_id": claims.UserID})
+		ctx = context.WithValue(ctx, contextKey{}, caller{
+			UserID:    claims.UserID,
+			Username:  claims.Username,
+			SessionID: claims.SessionID,
+		})
+		return handler(ctx, req)
+	}
+}
+
+func firstMetadata(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func clientIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
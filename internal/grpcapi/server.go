@@ -0,0 +1,79 @@
+package grpcapi
+
+import (
+	"context"
+
+	"github.com/SahandMohammed/wallet-service/internal/http/middleware"
+	"github.com/SahandMohammed/wallet-service/internal/service"
+	walletv1 "github.com/SahandMohammed/wallet-service/proto/wallet/v1"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// Server is the gRPC server with its health service
+type Server struct {
+	*grpc.Server
+	health *health.Server
+}
+
+// NewServer builds the gRPC server exposing the auth, wallet and admin
+// services, the standard health service and server reflection. It shares
+// the services and the Redis backed rate limits and idempotency keys with
+// the HTTP API.
+func NewServer(redisClient *redis.Client, services *service.Services) *Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			recoveryInterceptor(),
+			metadataInterceptor(),
+			authInterceptor(services.Auth),
+			rateLimitInterceptor(middleware.NewRateLimiter(redisClient)),
+			idempotencyInterceptor(redisClient),
+		),
+	)
+
+	walletv1.RegisterAuthServiceServer(server, &authServer{authService: services.Auth})
+	walletv1.RegisterWalletServiceServer(server, &walletServer{walletService: services.Wallet})
+	walletv1.RegisterAdminServiceServer(server, &adminServer{adminService: services.Admin})
+
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	for _, name := range []string{
+		walletv1.AuthService_ServiceDesc.ServiceName,
+		walletv1.WalletService_ServiceDesc.ServiceName,
+		walletv1.AdminService_ServiceDesc.ServiceName,
+	} {
+		healthServer.SetServingStatus(name, healthpb.HealthCheckResponse_SERVING)
+	}
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+
+	reflection.Register(server)
+
+	return &Server{Server: server, health: healthServer}
+}
+
+// Drain reports NOT_SERVING to health checks while calls are still served
+func (s *Server) Drain() {
+	s.health.Shutdown()
+}
+
+// Shutdown drains the server and waits for in-flight calls to finish.
+// Calls still running when ctx is done are cancelled.
+func (s *Server) Shutdown(ctx context.Context) {
+	s.Drain()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.Stop()
+		<-stopped
+	}
+}
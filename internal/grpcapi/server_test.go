@@ -0,0 +1,432 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/cache"
+	"github.com/SahandMohammed/wallet-service/internal/config"
+	"github.com/SahandMohammed/wallet-service/internal/credential"
+	"github.com/SahandMohammed/wallet-service/internal/db"
+	"github.com/SahandMohammed/wallet-service/internal/dialect"
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/migration"
+	"github.com/SahandMohammed/wallet-service/internal/notification"
+	"github.com/SahandMohammed/wallet-service/internal/repository"
+	"github.com/SahandMohammed/wallet-service/internal/service"
+	walletv1 "github.com/SahandMohammed/wallet-service/proto/wallet/v1"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"gorm.io/gorm"
+)
+
+// testServer is the gRPC server on a SQLite database and miniredis,
+// reached over an in-memory connection
+type testServer struct {
+	t        *testing.T
+	db       *gorm.DB
+	server   *Server
+	services *service.Services
+	auth     walletv1.AuthServiceClient
+	wallets  walletv1.WalletServiceClient
+	admin    walletv1.AdminServiceClient
+	health   healthpb.HealthClient
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	cfg := config.Defaults()
+	cfg.DBDriver = dialect.SQLite
+	cfg.SQLitePath = filepath.Join(t.TempDir(), "wallet.db")
+	cfg.PasswordHasher = "bcrypt"
+	cfg.BcryptCost = 4
+
+	database, err := db.NewConnection(cfg)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := database.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	m, err := migration.New(database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(context.Background(), 0); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	redisClient := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+
+	policy, err := credential.NewPolicy(credential.PolicyConfig{
+		UsernamePattern:   cfg.UsernamePattern,
+		PasswordMinLength: cfg.PasswordMinLength,
+		PasswordMaxLength: cfg.PasswordMaxLength,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hasher, err := credential.NewHasher(credential.HasherConfig{Algorithm: cfg.PasswordHasher, BcryptCost: cfg.BcryptCost})
+	if err != nil {
+		t.Fatal(err)
+	}
+	services := service.NewServices(database, cache.NewRedisCache(redisClient), notification.NewLogNotifier(), policy, hasher, cfg)
+
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(redisClient, services)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return &testServer{
+		t:        t,
+		db:       database,
+		server:   server,
+		services: services,
+		auth:     walletv1.NewAuthServiceClient(conn),
+		wallets:  walletv1.NewWalletServiceClient(conn),
+		admin:    walletv1.NewAdminServiceClient(conn),
+		health:   healthpb.NewHealthClient(conn),
+	}
+}
+
+// user is a registered user with a wallet
+type user struct {
+	id       uint
+	token    string
+	walletID uint64
+}
+
+func (s *testServer) register(username string) user {
+	s.t.Helper()
+	ctx := context.Background()
+
+	registered, err := s.auth.Register(ctx, &walletv1.RegisterRequest{Username: username, Password: "password123"})
+	if err != nil {
+		s.t.Fatalf("register %s: %v", username, err)
+	}
+	login, err := s.auth.Login(ctx, &walletv1.LoginRequest{Username: username, Password: "password123"})
+	if err != nil {
+		s.t.Fatalf("login %s: %v", username, err)
+	}
+
+	u := user{id: uint(registered.GetUser().GetId()), token: login.GetToken()}
+	created, err := s.wallets.CreateWallet(u.ctx(), &walletv1.CreateWalletRequest{})
+	if err != nil {
+		s.t.Fatalf("create wallet for %s: %v", username, err)
+	}
+	u.walletID = created.GetWallet().GetId()
+	return u
+}
+
+// ctx returns a context carrying the user's token
+func (u user) ctx() context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+u.token)
+}
+
+func expectCode(t *testing.T, err error, want codes.Code) {
+	t.Helper()
+	if got := status.Code(err); got != want {
+		t.Fatalf("expected %s, got %v", want, err)
+	}
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+
+	_, err := s.wallets.ListWallets(context.Background(), &walletv1.ListWalletsRequest{})
+	expectCode(t, err, codes.Unauthenticated)
+
+	invalid := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer invalid")
+	_, err = s.wallets.ListWallets(invalid, &walletv1.ListWalletsRequest{})
+	expectCode(t, err, codes.Unauthenticated)
+
+	basic := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Basic "+alice.token)
+	_, err = s.wallets.ListWallets(basic, &walletv1.ListWalletsRequest{})
+	expectCode(t, err, codes.Unauthenticated)
+
+	// API keys, and with them their scopes and wallet restrictions, are
+	// not accepted over gRPC
+	_, apiKey, err := s.services.APIKeys.CreateAPIKey(context.Background(), alice.id, service.CreateAPIKeyInput{
+		Name:      "ci",
+		Scopes:    []domain.APIKeyScope{domain.ScopeWalletsRead, domain.ScopeWalletsDeposit},
+		WalletIDs: []uint{uint(alice.walletID)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	withKey := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+apiKey)
+	_, err = s.wallets.Deposit(withKey, &walletv1.DepositRequest{WalletId: alice.walletID, Amount: 10})
+	expectCode(t, err, codes.Unauthenticated)
+
+	listed, err := s.wallets.ListWallets(alice.ctx(), &walletv1.ListWalletsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed.GetWallets()) != 1 || listed.GetWallets()[0].GetId() != alice.walletID {
+		t.Fatalf("expected alice's wallet, got %v", listed.GetWallets())
+	}
+}
+
+func TestWalletOwnership(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+
+	_, err := s.wallets.GetWallet(bob.ctx(), &walletv1.GetWalletRequest{WalletId: alice.walletID})
+	expectCode(t, err, codes.PermissionDenied)
+
+	_, err = s.wallets.Deposit(bob.ctx(), &walletv1.DepositRequest{WalletId: alice.walletID, Amount: 10})
+	expectCode(t, err, codes.PermissionDenied)
+
+	_, err = s.wallets.Transfer(bob.ctx(), &walletv1.TransferRequest{FromWalletId: alice.walletID, ToWalletId: bob.walletID, Amount: 10})
+	expectCode(t, err, codes.PermissionDenied)
+
+	_, err = s.wallets.ListTransactions(bob.ctx(), &walletv1.ListTransactionsRequest{WalletId: alice.walletID})
+	expectCode(t, err, codes.PermissionDenied)
+
+	_, err = s.wallets.GetWallet(bob.ctx(), &walletv1.GetWalletRequest{WalletId: 9999})
+	expectCode(t, err, codes.NotFound)
+
+	// Transfers only need the source wallet to be the caller's
+	if _, err := s.wallets.Deposit(bob.ctx(), &walletv1.DepositRequest{WalletId: bob.walletID, Amount: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.wallets.Transfer(bob.ctx(), &walletv1.TransferRequest{FromWalletId: bob.walletID, ToWalletId: alice.walletID, Amount: 4}); err != nil {
+		t.Fatal(err)
+	}
+	wallet, err := s.wallets.GetWallet(alice.ctx(), &walletv1.GetWalletRequest{WalletId: alice.walletID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wallet.GetWallet().GetBalance() != 4 {
+		t.Fatalf("expected a balance of 4, got %v", wallet.GetWallet().GetBalance())
+	}
+}
+
+func TestAdminAccess(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+
+	_, err := s.admin.ListUsers(context.Background(), &walletv1.ListUsersRequest{})
+	expectCode(t, err, codes.Unauthenticated)
+
+	_, err = s.admin.ListUsers(alice.ctx(), &walletv1.ListUsersRequest{})
+	expectCode(t, err, codes.PermissionDenied)
+	_, err = s.admin.ListAuditLogs(alice.ctx(), &walletv1.ListAuditLogsRequest{})
+	expectCode(t, err, codes.PermissionDenied)
+
+	if err := repository.NewUserRepository(s.db).SetAdmin(context.Background(), alice.id, true); err != nil {
+		t.Fatal(err)
+	}
+	users, err := s.admin.ListUsers(alice.ctx(), &walletv1.ListUsersRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users.GetUsers()) != 1 {
+		t.Fatalf("expected one user, got %d", len(users.GetUsers()))
+	}
+
+	if err := repository.NewUserRepository(s.db).SetAdmin(context.Background(), alice.id, false); err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.admin.ListUsers(alice.ctx(), &walletv1.ListUsersRequest{})
+	expectCode(t, err, codes.PermissionDenied)
+}
+
+func TestIdempotentDeposit(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+
+	ctx := metadata.AppendToOutgoingContext(alice.ctx(), idempotencyKeyMetadata, "deposit-0001")
+	request := &walletv1.DepositRequest{WalletId: alice.walletID, Amount: 25}
+
+	first, err := s.wallets.Deposit(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var header metadata.MD
+	replayed, err := s.wallets.Deposit(ctx, request, grpc.Header(&header))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := header.Get(idempotentReplayedMetadata); len(got) != 1 || got[0] != "true" {
+		t.Fatalf("expected the replay header, got %v", header)
+	}
+	if replayed.GetTransaction().GetId() != first.GetTransaction().GetId() {
+		t.Fatalf("expected transaction %d to be replayed, got %d", first.GetTransaction().GetId(), replayed.GetTransaction().GetId())
+	}
+
+	wallet, err := s.wallets.GetWallet(alice.ctx(), &walletv1.GetWalletRequest{WalletId: alice.walletID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wallet.GetWallet().GetBalance() != 25 {
+		t.Fatalf("expected the deposit to apply once, balance is %v", wallet.GetWallet().GetBalance())
+	}
+
+	_, err = s.wallets.Deposit(ctx, &walletv1.DepositRequest{WalletId: alice.walletID, Amount: 30})
+	expectCode(t, err, codes.InvalidArgument)
+
+	short := metadata.AppendToOutgoingContext(alice.ctx(), idempotencyKeyMetadata, "short")
+	_, err = s.wallets.Deposit(short, request)
+	expectCode(t, err, codes.InvalidArgument)
+
+	// Client errors are replayed too
+	failing := metadata.AppendToOutgoingContext(alice.ctx(), idempotencyKeyMetadata, "deposit-0002")
+	_, err = s.wallets.Deposit(failing, &walletv1.DepositRequest{WalletId: alice.walletID, Amount: -1})
+	expectCode(t, err, codes.InvalidArgument)
+	header = nil
+	_, err = s.wallets.Deposit(failing, &walletv1.DepositRequest{WalletId: alice.walletID, Amount: -1}, grpc.Header(&header))
+	expectCode(t, err, codes.InvalidArgument)
+	if len(header.Get(idempotentReplayedMetadata)) != 1 {
+		t.Fatalf("expected the failure to be replayed, got %v", header)
+	}
+}
+
+func TestMoneyRateLimit(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+
+	request := &walletv1.DepositRequest{WalletId: alice.walletID, Amount: 1}
+	for i := 0; i < 30; i++ {
+		if _, err := s.wallets.Deposit(alice.ctx(), request); err != nil {
+			t.Fatalf("deposit %d: %v", i+1, err)
+		}
+	}
+	_, err := s.wallets.Deposit(alice.ctx(), request)
+	expectCode(t, err, codes.ResourceExhausted)
+
+	// Reads have their own budget
+	if _, err := s.wallets.GetWallet(alice.ctx(), &walletv1.GetWalletRequest{WalletId: alice.walletID}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListPagination(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+
+	for i := 0; i < 3; i++ {
+		if _, err := s.wallets.Deposit(alice.ctx(), &walletv1.DepositRequest{WalletId: alice.walletID, Amount: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	first, err := s.wallets.ListTransactions(alice.ctx(), &walletv1.ListTransactionsRequest{WalletId: alice.walletID, Limit: 2, IncludeTotal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.GetTransactions()) != 2 || first.GetNextCursor() == "" || first.GetPrevCursor() != "" || first.GetTotal() != 3 {
+		t.Fatalf("unexpected first page: %v", first)
+	}
+
+	last, err := s.wallets.ListTransactions(alice.ctx(), &walletv1.ListTransactionsRequest{WalletId: alice.walletID, Limit: 2, Cursor: first.GetNextCursor()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(last.GetTransactions()) != 1 || last.GetNextCursor() != "" || last.GetPrevCursor() == "" || last.Total != nil {
+		t.Fatalf("unexpected last page: %v", last)
+	}
+	if last.GetTransactions()[0].GetId() >= first.GetTransactions()[1].GetId() {
+		t.Fatal("expected the last page to hold older transactions")
+	}
+
+	back, err := s.wallets.ListTransactions(alice.ctx(), &walletv1.ListTransactionsRequest{WalletId: alice.walletID, Limit: 2, Cursor: last.GetPrevCursor()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(back.GetTransactions()) != 2 || back.GetTransactions()[0].GetId() != first.GetTransactions()[0].GetId() {
+		t.Fatalf("expected prev_cursor to lead back to the first page, got %v", back)
+	}
+
+	_, err = s.wallets.ListTransactions(alice.ctx(), &walletv1.ListTransactionsRequest{WalletId: alice.walletID, Cursor: "invalid"})
+	expectCode(t, err, codes.InvalidArgument)
+
+	if err := repository.NewUserRepository(s.db).SetAdmin(context.Background(), alice.id, true); err != nil {
+		t.Fatal(err)
+	}
+	transactions, err := s.admin.ListTransactions(alice.ctx(), &walletv1.AdminListTransactionsRequest{Limit: 2, IncludeTotal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions.GetTransactions()) != 2 || transactions.GetNextCursor() == "" || transactions.GetTotal() != 3 {
+		t.Fatalf("unexpected admin page: %v", transactions)
+	}
+	entries, err := s.admin.ListAuditLogs(alice.ctx(), &walletv1.ListAuditLogsRequest{Limit: 1, IncludeTotal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries.GetAuditLogs()) != 1 || entries.GetNextCursor() == "" || entries.GetTotal() < 2 {
+		t.Fatalf("unexpected audit log page: %v", entries)
+	}
+}
+
+// healthStatus checks a service, "" for the server as a whole
+func (s *testServer) healthStatus(service string) healthpb.HealthCheckResponse_ServingStatus {
+	s.t.Helper()
+	res, err := s.health.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		s.t.Fatalf("health check %q: %v", service, err)
+	}
+	return res.GetStatus()
+}
+
+func TestDrainAndShutdown(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+
+	services := []string{"", walletv1.WalletService_ServiceDesc.ServiceName, walletv1.AdminService_ServiceDesc.ServiceName}
+	for _, name := range services {
+		if got := s.healthStatus(name); got != healthpb.HealthCheckResponse_SERVING {
+			t.Fatalf("expected %q SERVING before shutdown, got %s", name, got)
+		}
+	}
+
+	// Draining fails health checks but keeps serving calls, so that the
+	// load balancer can move traffic away first
+	s.server.Drain()
+	for _, name := range services {
+		if got := s.healthStatus(name); got != healthpb.HealthCheckResponse_NOT_SERVING {
+			t.Errorf("expected %q NOT_SERVING after Drain, got %s", name, got)
+		}
+	}
+	if _, err := s.wallets.Deposit(alice.ctx(), &walletv1.DepositRequest{WalletId: alice.walletID, Amount: 10}); err != nil {
+		t.Fatalf("expected calls to be served while draining, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s.server.Shutdown(ctx)
+	if ctx.Err() != nil {
+		t.Fatal("Shutdown did not return before its deadline with no calls running")
+	}
+	_, err := s.wallets.GetWallet(alice.ctx(), &walletv1.GetWalletRequest{WalletId: alice.walletID})
+	expectCode(t, err, codes.Unavailable)
+}
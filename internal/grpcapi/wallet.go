@@ -0,0 +1,126 @@
+package grpcapi
+
+import (
+	"context"
+
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/service"
+	walletv1 "github.com/SahandMohammed/wallet-service/proto/wallet/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type walletServer struct {
+	walletv1.UnimplementedWalletServiceServer
+	walletService service.WalletService
+}
+
+func (s *walletServer) CreateWallet(ctx context.Context, req *walletv1.CreateWalletRequest) (*walletv1.CreateWalletResponse, error) {
+	c, _ := callerFromContext(ctx)
+
+	wallet, err := s.walletService.CreateWallet(ctx, c.UserID)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return &walletv1.CreateWalletResponse{Wallet: toProtoWallet(wallet)}, nil
+}
+
+func (s *walletServer) GetWallet(ctx context.Context, req *walletv1.GetWalletRequest) (*walletv1.GetWalletResponse, error) {
+	wallet, err := s.ownedWallet(ctx, req.GetWalletId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &walletv1.GetWalletResponse{Wallet: toProtoWallet(wallet)}, nil
+}
+
+func (s *walletServer) ListWallets(ctx context.Context, req *walletv1.ListWalletsRequest) (*walletv1.ListWalletsResponse, error) {
+	c, _ := callerFromContext(ctx)
+
+	wallets, err := s.walletService.GetUserWallets(ctx, c.UserID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	response := &walletv1.ListWalletsResponse{}
+	for _, wallet := range wallets {
+		response.Wallets = append(response.Wallets, toProtoWallet(wallet))
+	}
+	return response, nil
+}
+
+func (s *walletServer) Deposit(ctx context.Context, req *walletv1.DepositRequest) (*walletv1.DepositResponse, error) {
+	if req.GetAmount() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "amount must be greater than zero")
+	}
+
+	if _, err := s.ownedWallet(ctx, req.GetWalletId()); err != nil {
+		return nil, err
+	}
+
+	transaction, err := s.walletService.Deposit(ctx, uint(req.GetWalletId()), req.GetAmount(), req.GetDescription())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return &walletv1.DepositResponse{Transaction: toProtoTransaction(transaction)}, nil
+}
+
+func (s *walletServer) Transfer(ctx context.Context, req *walletv1.TransferRequest) (*walletv1.TransferResponse, error) {
+	if req.GetAmount() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "amount must be greater than zero")
+	}
+	if req.GetToWalletId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "to_wallet_id is required")
+	}
+
+	// Only the source wallet has to belong to the caller
+	if _, err := s.ownedWallet(ctx, req.GetFromWalletId()); err != nil {
+		return nil, err
+	}
+
+	transaction, err := s.walletService.Transfer(ctx, uint(req.GetFromWalletId()), uint(req.GetToWalletId()), req.GetAmount(), req.GetDescription())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return &walletv1.TransferResponse{Transaction: toProtoTransaction(transaction)}, nil
+}
+
+func (s *walletServer) ListTransactions(ctx context.Context, req *walletv1.ListTransactionsRequest) (*walletv1.ListTransactionsResponse, error) {
+	if _, err := s.ownedWallet(ctx, req.GetWalletId()); err != nil {
+		return nil, err
+	}
+
+	pageReq, err := pageRequest(req.GetLimit(), req.GetCursor(), req.GetIncludeTotal())
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := s.walletService.PageTransactions(ctx, uint(req.GetWalletId()), pageReq)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	response := &walletv1.ListTransactionsResponse{Total: page.Total}
+	response.NextCursor, response.PrevCursor = pageCursors(page)
+	for _, tx := range page.Items {
+		response.Transactions = append(response.Transactions, toProtoTransaction(tx))
+	}
+	return response, nil
+}
+
+// ownedWallet loads a wallet and checks that it belongs to the caller
+func (s *walletServer) ownedWallet(ctx context.Context, walletID uint64) (*domain.Wallet, error) {
+	c, _ := callerFromContext(ctx)
+
+	wallet, err := s.walletService.GetWallet(ctx, uint(walletID))
+	if err != nil {
+		return nil, status.Error(codes.NotFound, "wallet not found")
+	}
+	if wallet.UserID != c.UserID {
+		return nil, status.Error(codes.PermissionDenied, "access denied")
+	}
+	return wallet, nil
+}
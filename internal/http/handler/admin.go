@@ -1,11 +1,13 @@
 package handler
 
 import (
+	"encoding/json"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/repository"
 	"github.com/SahandMohammed/wallet-service/internal/service"
 	"github.com/gin-gonic/gin"
 )
@@ -26,28 +28,23 @@ type AdminResponse struct {
 }
 
 func (h *AdminHandler) ListUsers(c *gin.Context) {
-	// Get pagination parameters
-	limitStr := c.DefaultQuery("limit", "10")
-	offsetStr := c.DefaultQuery("offset", "0")
-
-	limit, _ := strconv.Atoi(limitStr)
-	offset, _ := strconv.Atoi(offsetStr)
-
-	if limit <= 0 || limit > 100 {
-		limit = 10
+	req, ok := pageRequest(c)
+	if !ok {
+		return
 	}
 
-	users, err := h.adminService.ListUsers(c.Request.Context(), limit, offset)
+	users, err := h.adminService.PageUsers(c.Request.Context(), req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, AdminResponse{Error: err.Error()})
 		return
 	}
 
 	var response []map[string]interface{}
-	for _, user := range users {
+	for _, user := range users.Items {
 		userData := map[string]interface{}{
 			"id":         user.ID,
 			"username":   user.Username,
+			"is_admin":   user.IsAdmin,
 			"created_at": user.CreatedAt,
 		}
 
@@ -55,11 +52,16 @@ func (h *AdminHandler) ListUsers(c *gin.Context) {
 		if len(user.Wallets) > 0 {
 			var wallets []map[string]interface{}
 			for _, wallet := range user.Wallets {
-				wallets = append(wallets, map[string]interface{}{
+				walletData := map[string]interface{}{
 					"id":         wallet.ID,
 					"balance":    domain.MinorUnitsToDollars(wallet.Balance),
 					"created_at": wallet.CreatedAt,
-				})
+				}
+				if wallet.FrozenAt != nil {
+					walletData["frozen_at"] = wallet.FrozenAt
+					walletData["frozen_reason"] = wallet.FrozenReason
+				}
+				wallets = append(wallets, walletData)
 			}
 			userData["wallets"] = wallets
 		}
@@ -67,26 +69,17 @@ func (h *AdminHandler) ListUsers(c *gin.Context) {
 		response = append(response, userData)
 	}
 
-	c.JSON(http.StatusOK, AdminResponse{Data: response})
+	c.JSON(http.StatusOK, listResponse(req, users, response))
 }
 
 func (h *AdminHandler) ListTransactions(c *gin.Context) {
-	// Get pagination parameters
-	limitStr := c.DefaultQuery("limit", "10")
-	offsetStr := c.DefaultQuery("offset", "0")
-
-	limit, _ := strconv.Atoi(limitStr)
-	offset, _ := strconv.Atoi(offsetStr)
-
-	if limit <= 0 || limit > 100 {
-		limit = 10
+	req, ok := pageRequest(c)
+	if !ok {
+		return
 	}
 
 	// Parse filters
-	filters := service.AdminTransactionFilters{
-		Limit:  limit,
-		Offset: offset,
-	}
+	filters := service.AdminTransactionFilters{}
 
 	// User ID filter
 	if userIDStr := c.Query("user_id"); userIDStr != "" {
@@ -101,7 +94,8 @@ func (h *AdminHandler) ListTransactions(c *gin.Context) {
 		txType := domain.TransactionType(typeStr)
 		if txType == domain.TransactionTypeDeposit ||
 			txType == domain.TransactionTypeTransfer ||
-			txType == domain.TransactionTypeWithdraw {
+			txType == domain.TransactionTypeWithdraw ||
+			txType == domain.TransactionTypeAdjustment {
 			filters.Type = &txType
 		}
 	}
@@ -121,14 +115,14 @@ func (h *AdminHandler) ListTransactions(c *gin.Context) {
 		}
 	}
 
-	transactions, err := h.adminService.ListTransactions(c.Request.Context(), filters)
+	transactions, err := h.adminService.PageTransactions(c.Request.Context(), filters, req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, AdminResponse{Error: err.Error()})
 		return
 	}
 
 	var response []map[string]interface{}
-	for _, tx := range transactions {
+	for _, tx := range transactions.Items {
 		txData := map[string]interface{}{
 			"transaction_id":   tx.ID,
 			"wallet_id":        tx.WalletID,
@@ -166,5 +160,78 @@ func (h *AdminHandler) ListTransactions(c *gin.Context) {
 		response = append(response, txData)
 	}
 
-	c.JSON(http.StatusOK, AdminResponse{Data: response})
+	c.JSON(http.StatusOK, listResponse(req, transactions, response))
+}
+
+func (h *AdminHandler) ListAuditLogs(c *gin.Context) {
+	req, ok := pageRequest(c)
+	if !ok {
+		return
+	}
+
+	// Parse filters
+	filters := repository.AuditLogFilters{
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+		RequestID:  c.Query("request_id"),
+	}
+
+	// Actor filter
+	if actorIDStr := c.Query("actor_id"); actorIDStr != "" {
+		if actorID, err := strconv.ParseUint(actorIDStr, 10, 32); err == nil {
+			aid := uint(actorID)
+			filters.ActorID = &aid
+		}
+	}
+
+	// Date range filters
+	if startDateStr := c.Query("start_date"); startDateStr != "" {
+		if startDate, err := time.Parse("2006-01-02", startDateStr); err == nil {
+			filters.StartDate = &startDate
+		}
+	}
+
+	if endDateStr := c.Query("end_date"); endDateStr != "" {
+		if endDate, err := time.Parse("2006-01-02", endDateStr); err == nil {
+			// Set to end of day
+			endDate = endDate.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
+			filters.EndDate = &endDate
+		}
+	}
+
+	entries, err := h.adminService.PageAuditLogs(c.Request.Context(), filters, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, AdminResponse{Error: err.Error()})
+		return
+	}
+
+	var response []map[string]interface{}
+	for _, entry := range entries.Items {
+		entryData := map[string]interface{}{
+			"id":          entry.ID,
+			"actor_name":  entry.ActorName,
+			"action":      entry.Action,
+			"target_type": entry.TargetType,
+			"target_id":   entry.TargetID,
+			"ip_address":  entry.IPAddress,
+			"user_agent":  entry.UserAgent,
+			"request_id":  entry.RequestID,
+			"created_at":  entry.CreatedAt,
+		}
+
+		if entry.ActorID != nil {
+			entryData["actor_id"] = *entry.ActorID
+		}
+		if entry.Before != "" {
+			entryData["before"] = json.RawMessage(entry.Before)
+		}
+		if entry.After != "" {
+			entryData["after"] = json.RawMessage(entry.After)
+		}
+
+		response = append(response, entryData)
+	}
+
+	c.JSON(http.StatusOK, listResponse(req, entries, response))
 }
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type APIKeyHandler struct {
+	apiKeyService service.APIKeyService
+	validator     *validator.Validate
+}
+
+func NewAPIKeyHandler(apiKeyService service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+		validator:     validator.New(),
+	}
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string               `json:"name" validate:"required,max=100"`
+	Scopes    []domain.APIKeyScope `json:"scopes" validate:"required,min=1"`
+	WalletIDs []uint               `json:"wallet_ids"`
+	ExpiresAt *time.Time           `json:"expires_at"`
+}
+
+type APIKeyResponse struct {
+	Data  interface{} `json:"data,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, APIKeyResponse{Error: "User not authenticated"})
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, APIKeyResponse{Error: "Invalid request format"})
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, APIKeyResponse{Error: "Validation failed: " + err.Error()})
+		return
+	}
+
+	key, rawKey, err := h.apiKeyService.CreateAPIKey(c.Request.Context(), userID.(uint), service.CreateAPIKeyInput{
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		WalletIDs: req.WalletIDs,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIKeyResponse{Error: err.Error()})
+		return
+	}
+
+	// The plaintext key and signing secret are only ever returned here
+	response := apiKeyData(key)
+	response["key"] = rawKey
+	response["signing_secret"] = h.apiKeyService.SigningSecret(key)
+
+	c.JSON(http.StatusCreated, APIKeyResponse{Data: response})
+}
+
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, APIKeyResponse{Error: "User not authenticated"})
+		return
+	}
+
+	keys, err := h.apiKeyService.ListAPIKeys(c.Request.Context(), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, APIKeyResponse{Error: err.Error()})
+		return
+	}
+
+	var response []map[string]interface{}
+	for _, key := range keys {
+		response = append(response, apiKeyData(key))
+	}
+
+	c.JSON(http.StatusOK, APIKeyResponse{Data: response})
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, APIKeyResponse{Error: "User not authenticated"})
+		return
+	}
+
+	keyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIKeyResponse{Error: "Invalid API key ID"})
+		return
+	}
+
+	if err := h.apiKeyService.RevokeAPIKey(c.Request.Context(), userID.(uint), uint(keyID)); err != nil {
+		c.JSON(http.StatusNotFound, APIKeyResponse{Error: err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func apiKeyData(key *domain.APIKey) map[string]interface{} {
+	return map[string]interface{}{
+		"id":           key.ID,
+		"name":         key.Name,
+		"prefix":       key.Prefix,
+		"scopes":       key.Scopes,
+		"wallet_ids":   key.WalletIDs,
+		"expires_at":   key.ExpiresAt,
+		"revoked_at":   key.RevokedAt,
+		"last_used_at": key.LastUsedAt,
+		"created_at":   key.CreatedAt,
+	}
+}
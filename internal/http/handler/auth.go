@@ -22,7 +22,7 @@ func NewAuthHandler(authService service.AuthService) *AuthHandler {
 
 type RegisterRequest struct {
 	Username string `json:"username" validate:"required,min=3,max=50"`
-	Password string `json:"password" validate:"required,min=8,max=15"`
+	Password string `json:"password" validate:"required"`
 }
 
 type LoginRequest struct {
@@ -30,6 +30,20 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+type PasswordResetRequest struct {
+	Username string `json:"username" validate:"required"`
+}
+
+type PasswordResetConfirmRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
 type AuthResponse struct {
 	Data  interface{} `json:"data,omitempty"`
 	Error string      `json:"error,omitempty"`
@@ -75,7 +89,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := h.authService.Login(c.Request.Context(), req.Username, req.Password)
+	token, err := h.authService.Login(c.Request.Context(), req.Username, req.Password, clientInfo(c))
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, AuthResponse{Error: err.Error()})
 		return
@@ -87,3 +101,136 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	c.JSON(http.StatusOK, AuthResponse{Data: response})
 }
+
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, AuthResponse{Error: "User not authenticated"})
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, AuthResponse{Error: "Invalid request format"})
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, AuthResponse{Error: "Validation failed: " + err.Error()})
+		return
+	}
+
+	token, err := h.authService.ChangePassword(c.Request.Context(), userID.(uint), req.CurrentPassword, req.NewPassword, clientInfo(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, AuthResponse{Error: err.Error()})
+		return
+	}
+
+	response := map[string]interface{}{
+		"token": token,
+	}
+
+	c.JSON(http.StatusOK, AuthResponse{Data: response})
+}
+
+func (h *AuthHandler) RequestPasswordReset(c *gin.Context) {
+	var req PasswordResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, AuthResponse{Error: "Invalid request format"})
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, AuthResponse{Error: "Validation failed: " + err.Error()})
+		return
+	}
+
+	if err := h.authService.RequestPasswordReset(c.Request.Context(), req.Username); err != nil {
+		c.JSON(http.StatusInternalServerError, AuthResponse{Error: "Failed to process password reset request"})
+		return
+	}
+
+	// Same response whether or not the user exists
+	response := map[string]interface{}{
+		"message": "If the account exists, a password reset token has been sent",
+	}
+
+	c.JSON(http.StatusAccepted, AuthResponse{Data: response})
+}
+
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req PasswordResetConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, AuthResponse{Error: "Invalid request format"})
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, AuthResponse{Error: "Validation failed: " + err.Error()})
+		return
+	}
+
+	if err := h.authService.ResetPassword(c.Request.Context(), req.Token, req.NewPassword); err != nil {
+		c.JSON(http.StatusBadRequest, AuthResponse{Error: err.Error()})
+		return
+	}
+
+	response := map[string]interface{}{
+		"message": "Password has been reset",
+	}
+
+	c.JSON(http.StatusOK, AuthResponse{Data: response})
+}
+
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, AuthResponse{Error: "User not authenticated"})
+		return
+	}
+
+	sessions, err := h.authService.ListSessions(c.Request.Context(), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, AuthResponse{Error: err.Error()})
+		return
+	}
+
+	currentSessionID := c.GetString("session_id")
+
+	var response []map[string]interface{}
+	for _, session := range sessions {
+		response = append(response, map[string]interface{}{
+			"id":           session.ID,
+			"user_agent":   session.UserAgent,
+			"ip_address":   session.IPAddress,
+			"created_at":   session.CreatedAt,
+			"last_seen_at": session.LastSeenAt,
+			"expires_at":   session.ExpiresAt,
+			"current":      session.ID == currentSessionID,
+		})
+	}
+
+	c.JSON(http.StatusOK, AuthResponse{Data: response})
+}
+
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, AuthResponse{Error: "User not authenticated"})
+		return
+	}
+
+	if err := h.authService.RevokeSession(c.Request.Context(), userID.(uint), c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, AuthResponse{Error: err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func clientInfo(c *gin.Context) service.ClientInfo {
+	return service.ClientInfo{
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}
+}
@@ -11,8 +11,9 @@ import (
 )
 
 type HealthHandler struct {
-	db    *gorm.DB
-	redis *redis.Client
+	db       *gorm.DB
+	redis    *redis.Client
+	draining <-chan struct{}
 }
 
 type HealthResponse struct {
@@ -22,10 +23,13 @@ type HealthResponse struct {
 	Version   string            `json:"version"`
 }
 
-func NewHealthHandler(db *gorm.DB, redis *redis.Client) *HealthHandler {
+// NewHealthHandler reports not ready once draining is closed, at the start
+// of shutdown, so that load balancers stop sending new requests.
+func NewHealthHandler(db *gorm.DB, redis *redis.Client, draining <-chan struct{}) *HealthHandler {
 	return &HealthHandler{
-		db:    db,
-		redis: redis,
+		db:       db,
+		redis:    redis,
+		draining: draining,
 	}
 }
 
@@ -82,6 +86,18 @@ func (h *HealthHandler) Health(c *gin.Context) {
 
 // Readiness check endpoint
 func (h *HealthHandler) Ready(c *gin.Context) {
+	select {
+	case <-h.draining:
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"ready":         false,
+			"shutting_down": true,
+			"timestamp":     time.Now().UTC().Format(time.RFC3339),
+			"services":      map[string]string{},
+		})
+		return
+	default:
+	}
+
 	// Check if all critical services are ready
 	ready := true
 	services := make(map[string]string)
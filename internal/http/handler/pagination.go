@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/SahandMohammed/wallet-service/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// Pagination tells clients where a page sits in its listing. The cursors are
+// opaque and are sent back in the cursor query parameter, next_cursor for
+// older entries and prev_cursor for newer ones.
+type Pagination struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	Total      *int64 `json:"total,omitempty"`
+}
+
+// ListResponse is the envelope of every paginated listing
+type ListResponse struct {
+	Data       interface{} `json:"data"`
+	Pagination Pagination  `json:"pagination"`
+}
+
+// pageRequest reads limit, cursor and include_total from the query. It
+// responds with 400 and returns false when the cursor is invalid.
+func pageRequest(c *gin.Context) (repository.PageRequest, bool) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+
+	req := repository.PageRequest{Limit: limit}
+	req.IncludeTotal, _ = strconv.ParseBool(c.Query("include_total"))
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		if err := req.SetCursor(cursor); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return req, false
+		}
+	}
+	return req, true
+}
+
+// listResponse wraps data, the rendered items of page, in the envelope
+func listResponse[T any](req repository.PageRequest, page *repository.Page[T], data []map[string]interface{}) ListResponse {
+	if data == nil {
+		data = []map[string]interface{}{}
+	}
+
+	pagination := Pagination{Limit: req.Limit, Total: page.Total}
+	if page.Next != nil {
+		pagination.NextCursor = repository.EncodeCursor(*page.Next, false)
+	}
+	if page.Prev != nil {
+		pagination.PrevCursor = repository.EncodeCursor(*page.Prev, true)
+	}
+	return ListResponse{Data: data, Pagination: pagination}
+}
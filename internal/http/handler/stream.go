@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/logging"
+	"github.com/SahandMohammed/wallet-service/internal/realtime"
+	"github.com/SahandMohammed/wallet-service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type StreamHandler struct {
+	walletService service.WalletService
+	broker        *realtime.Broker
+	heartbeat     time.Duration
+	draining      <-chan struct{}
+}
+
+// NewStreamHandler ends open streams once draining is closed, so that
+// shutdown does not wait for them. Clients resume on another instance with
+// Last-Event-ID.
+func NewStreamHandler(walletService service.WalletService, broker *realtime.Broker, heartbeat time.Duration, draining <-chan struct{}) *StreamHandler {
+	return &StreamHandler{
+		walletService: walletService,
+		broker:        broker,
+		heartbeat:     heartbeat,
+		draining:      draining,
+	}
+}
+
+// StreamWallet pushes balance and transaction updates for a wallet as
+// Server-Sent Events. Reconnecting clients send Last-Event-ID (or the
+// last_event_id query parameter) to receive the updates they missed.
+func (h *StreamHandler) StreamWallet(c *gin.Context) {
+	walletID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, WalletResponse{Error: "Invalid wallet ID"})
+		return
+	}
+
+	wallet, err := h.walletService.GetWallet(c.Request.Context(), uint(walletID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, WalletResponse{Error: "Wallet not found"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	if wallet.UserID != userID.(uint) || !walletAllowed(c, wallet.ID) {
+		c.JSON(http.StatusForbidden, WalletResponse{Error: "Access denied"})
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	if lastEventID != "" && !realtime.ValidEventID(lastEventID) {
+		c.JSON(http.StatusBadRequest, WalletResponse{Error: "Invalid Last-Event-ID"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	updates, err := h.broker.Subscribe(ctx, wallet.ID, lastEventID)
+	if err != nil {
+		logging.FromContext(c.Request.Context()).WithError(err).WithField("wallet_id", wallet.ID).Error("Failed to subscribe to wallet updates")
+		c.JSON(http.StatusServiceUnavailable, WalletResponse{Error: "Stream unavailable"})
+		return
+	}
+
+	// Streams outlive the server's write timeout
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		logging.FromContext(ctx).WithError(err).Warn("Failed to clear the stream write deadline")
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	// A fresh connection starts from the current balance. Resumed ones only
+	// get what they missed.
+	if lastEventID == "" {
+		writeEvent(c, "", realtime.UpdateBalance, map[string]interface{}{
+			"wallet_id":   wallet.ID,
+			"balance":     domain.MinorUnitsToDollars(wallet.Balance),
+			"occurred_at": time.Now().UTC(),
+		})
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.draining:
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+			c.Writer.Flush()
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			writeEvent(c, update.ID, update.Kind, update)
+		}
+	}
+}
+
+func writeEvent(c *gin.Context, id, event string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	if id != "" {
+		fmt.Fprintf(c.Writer, "id: %s\n", id)
+	}
+	fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event, payload)
+	c.Writer.Flush()
+}
@@ -83,7 +83,7 @@ func (h *WalletHandler) GetWallet(c *gin.Context) {
 
 	// Check if user owns this wallet
 	userID, _ := c.Get("user_id")
-	if wallet.UserID != userID.(uint) {
+	if wallet.UserID != userID.(uint) || !walletAllowed(c, wallet.ID) {
 		c.JSON(http.StatusForbidden, WalletResponse{Error: "Access denied"})
 		return
 	}
@@ -113,6 +113,9 @@ func (h *WalletHandler) GetUserWallets(c *gin.Context) {
 
 	var response []map[string]interface{}
 	for _, wallet := range wallets {
+		if !walletAllowed(c, wallet.ID) {
+			continue
+		}
 		response = append(response, map[string]interface{}{
 			"id":         wallet.ID,
 			"user_id":    wallet.UserID,
@@ -144,7 +147,7 @@ func (h *WalletHandler) Deposit(c *gin.Context) {
 		return
 	}
 
-	if wallet.UserID != userID.(uint) {
+	if wallet.UserID != userID.(uint) || !walletAllowed(c, wallet.ID) {
 		c.JSON(http.StatusForbidden, WalletResponse{Error: "Access denied"})
 		return
 	}
@@ -190,7 +193,7 @@ func (h *WalletHandler) Transfer(c *gin.Context) {
 		return
 	}
 
-	if fromWallet.UserID != userID.(uint) {
+	if fromWallet.UserID != userID.(uint) || !walletAllowed(c, fromWallet.ID) {
 		c.JSON(http.StatusForbidden, WalletResponse{Error: "Access denied to source wallet"})
 		return
 	}
@@ -234,30 +237,24 @@ func (h *WalletHandler) GetTransactions(c *gin.Context) {
 		return
 	}
 
-	if wallet.UserID != userID.(uint) {
+	if wallet.UserID != userID.(uint) || !walletAllowed(c, wallet.ID) {
 		c.JSON(http.StatusForbidden, WalletResponse{Error: "Access denied"})
 		return
 	}
 
-	// Get pagination parameters
-	limitStr := c.DefaultQuery("limit", "10")
-	offsetStr := c.DefaultQuery("offset", "0")
-
-	limit, _ := strconv.Atoi(limitStr)
-	offset, _ := strconv.Atoi(offsetStr)
-
-	if limit <= 0 || limit > 100 {
-		limit = 10
+	req, ok := pageRequest(c)
+	if !ok {
+		return
 	}
 
-	transactions, err := h.walletService.GetTransactions(c.Request.Context(), uint(walletID), limit, offset)
+	transactions, err := h.walletService.PageTransactions(c.Request.Context(), uint(walletID), req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, WalletResponse{Error: err.Error()})
 		return
 	}
 
 	var response []map[string]interface{}
-	for _, tx := range transactions {
+	for _, tx := range transactions.Items {
 		txData := map[string]interface{}{
 			"transaction_id":   tx.ID,
 			"wallet_id":        tx.WalletID,
@@ -280,5 +277,14 @@ func (h *WalletHandler) GetTransactions(c *gin.Context) {
 		response = append(response, txData)
 	}
 
-	c.JSON(http.StatusOK, WalletResponse{Data: response})
+	c.JSON(http.StatusOK, listResponse(req, transactions, response))
+}
+
+// walletAllowed reports whether the API key used for the request, if any,
+// is allowed to act on the given wallet.
+func walletAllowed(c *gin.Context, walletID uint) bool {
+	if value, exists := c.Get("api_key"); exists {
+		return value.(*domain.APIKey).AllowsWallet(walletID)
+	}
+	return true
 }
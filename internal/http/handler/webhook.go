@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type WebhookHandler struct {
+	webhookService service.WebhookService
+	validator      *validator.Validate
+}
+
+func NewWebhookHandler(webhookService service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+		validator:      validator.New(),
+	}
+}
+
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" validate:"required,url,max=2048"`
+	WalletID   *uint    `json:"wallet_id"`
+	EventTypes []string `json:"event_types"`
+}
+
+type WebhookResponse struct {
+	Data  interface{} `json:"data,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, WebhookResponse{Error: "User not authenticated"})
+		return
+	}
+
+	var req CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, WebhookResponse{Error: "Invalid request format"})
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, WebhookResponse{Error: "Validation failed: " + err.Error()})
+		return
+	}
+
+	endpoint, err := h.webhookService.CreateEndpoint(c.Request.Context(), userID.(uint), service.CreateWebhookInput{
+		URL:        req.URL,
+		WalletID:   req.WalletID,
+		EventTypes: req.EventTypes,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, WebhookResponse{Error: err.Error()})
+		return
+	}
+
+	// The signing secret is only ever returned here
+	response := webhookData(endpoint)
+	response["secret"] = endpoint.Secret
+
+	c.JSON(http.StatusCreated, WebhookResponse{Data: response})
+}
+
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, WebhookResponse{Error: "User not authenticated"})
+		return
+	}
+
+	endpoints, err := h.webhookService.ListEndpoints(c.Request.Context(), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, WebhookResponse{Error: err.Error()})
+		return
+	}
+
+	var response []map[string]interface{}
+	for _, endpoint := range endpoints {
+		response = append(response, webhookData(endpoint))
+	}
+
+	c.JSON(http.StatusOK, WebhookResponse{Data: response})
+}
+
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, WebhookResponse{Error: "User not authenticated"})
+		return
+	}
+
+	endpointID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, WebhookResponse{Error: "Invalid webhook ID"})
+		return
+	}
+
+	if err := h.webhookService.DeleteEndpoint(c.Request.Context(), userID.(uint), uint(endpointID)); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *WebhookHandler) PingWebhook(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, WebhookResponse{Error: "User not authenticated"})
+		return
+	}
+
+	endpointID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, WebhookResponse{Error: "Invalid webhook ID"})
+		return
+	}
+
+	delivery, err := h.webhookService.Ping(c.Request.Context(), userID.(uint), uint(endpointID))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, WebhookResponse{Data: deliveryData(delivery)})
+}
+
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, WebhookResponse{Error: "User not authenticated"})
+		return
+	}
+
+	endpointID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, WebhookResponse{Error: "Invalid webhook ID"})
+		return
+	}
+
+	req, ok := pageRequest(c)
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.webhookService.PageDeliveries(c.Request.Context(), userID.(uint), uint(endpointID), req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	var response []map[string]interface{}
+	for _, delivery := range deliveries.Items {
+		response = append(response, deliveryData(delivery))
+	}
+
+	c.JSON(http.StatusOK, listResponse(req, deliveries, response))
+}
+
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, WebhookResponse{Error: "User not authenticated"})
+		return
+	}
+
+	endpointID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, WebhookResponse{Error: "Invalid webhook ID"})
+		return
+	}
+
+	deliveryID, err := strconv.ParseUint(c.Param("delivery_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, WebhookResponse{Error: "Invalid delivery ID"})
+		return
+	}
+
+	delivery, err := h.webhookService.Redeliver(c.Request.Context(), userID.(uint), uint(endpointID), uint(deliveryID))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, WebhookResponse{Data: deliveryData(delivery)})
+}
+
+func (h *WebhookHandler) respondError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrWebhookNotFound) {
+		c.JSON(http.StatusNotFound, WebhookResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadRequest, WebhookResponse{Error: err.Error()})
+}
+
+func webhookData(endpoint *domain.WebhookEndpoint) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          endpoint.ID,
+		"url":         endpoint.URL,
+		"wallet_id":   endpoint.WalletID,
+		"event_types": endpoint.EventTypes,
+		"active":      endpoint.Active,
+		"created_at":  endpoint.CreatedAt,
+	}
+}
+
+func deliveryData(delivery *domain.WebhookDelivery) map[string]interface{} {
+	return map[string]interface{}{
+		"id":               delivery.ID,
+		"event_id":         delivery.EventID,
+		"event_type":       delivery.EventType,
+		"status":           delivery.Status,
+		"attempts":         delivery.Attempts,
+		"next_attempt_at":  delivery.NextAttemptAt,
+		"last_status_code": delivery.LastStatusCode,
+		"last_error":       delivery.LastError,
+		"delivered_at":     delivery.DeliveredAt,
+		"created_at":       delivery.CreatedAt,
+	}
+}
@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	idempotencyTTL            = 24 * time.Hour
+	idempotencyStateRunning   = "processing"
+	idempotencyStateCompleted = "completed"
+)
+
+type idempotencyRecord struct {
+	State       string `json:"state"`
+	Fingerprint string `json:"fingerprint"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// responseRecorder keeps a copy of what the handler writes
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// IdempotencyMiddleware makes requests carrying an Idempotency-Key header
+// safe to retry. The first response for a key is stored for 24 hours and
+// replayed for later requests with the same key and body. A request with the
+// same key but a different body is rejected, as is one arriving while the
+// first is still being processed. Requests without the header pass through.
+// Keys are scoped to the authenticated user and route.
+func IdempotencyMiddleware(redisClient *redis.Client) gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		idempotencyKey := c.GetHeader(IdempotencyKeyHeader)
+		if idempotencyKey == "" {
+			c.Next()
+			return
+		}
+		if len(idempotencyKey) < 8 || len(idempotencyKey) > 255 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be between 8 and 255 characters"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		bodyHash := sha256.Sum256(body)
+		fingerprint := hex.EncodeToString(bodyHash[:])
+		storeKey := fmt.Sprintf("idempotency:%v:%s:%s:%s", c.MustGet("user_id"), c.Request.Method, c.FullPath(), idempotencyKey)
+
+		ctx := c.Request.Context()
+		running, _ := json.Marshal(idempotencyRecord{State: idempotencyStateRunning, Fingerprint: fingerprint})
+		acquired, err := redisClient.SetNX(ctx, storeKey, running, idempotencyTTL).Result()
+		if err != nil {
+			// Fail closed, these routes move money
+			logging.FromContext(c.Request.Context()).WithError(err).Error("Failed to reserve idempotency key")
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Unable to process idempotency key"})
+			c.Abort()
+			return
+		}
+
+		if !acquired {
+			replayIdempotent(c, redisClient, storeKey, fingerprint)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// Server errors are not stored so that the client can retry
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			redisClient.Del(ctx, storeKey)
+			return
+		}
+
+		completed, _ := json.Marshal(idempotencyRecord{
+			State:       idempotencyStateCompleted,
+			Fingerprint: fingerprint,
+			Status:      c.Writer.Status(),
+			ContentType: c.Writer.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		})
+		if err := redisClient.Set(ctx, storeKey, completed, idempotencyTTL).Err(); err != nil {
+			logging.FromContext(c.Request.Context()).WithError(err).Error("Failed to store idempotent response")
+		}
+	})
+}
+
+func replayIdempotent(c *gin.Context, redisClient *redis.Client, storeKey, fingerprint string) {
+	data, err := redisClient.Get(c.Request.Context(), storeKey).Bytes()
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Request with this idempotency key is in progress"})
+		c.Abort()
+		return
+	}
+
+	var record idempotencyRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Unable to process idempotency key"})
+		c.Abort()
+		return
+	}
+
+	if record.Fingerprint != fingerprint {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency key was already used with a different request"})
+		c.Abort()
+		return
+	}
+
+	if record.State != idempotencyStateCompleted {
+		c.JSON(http.StatusConflict, gin.H{"error": "Request with this idempotency key is in progress"})
+		c.Abort()
+		return
+	}
+
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Data(record.Status, record.ContentType, record.Body)
+	c.Abort()
+}
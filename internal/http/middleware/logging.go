@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// RequestIDMiddleware accepts the client's X-Request-ID, or generates one
+// when it is missing or unusable, and echoes it in the response. The
+// request's logger is tagged with the ID and route.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		requestID := logging.RequestID(c.GetHeader("X-Request-ID"))
+		c.Set("request_id", requestID)
+		c.Header("X-Request-ID", requestID)
+
+		c.Request = c.Request.WithContext(logging.WithFields(c.Request.Context(), logrus.Fields{
+			"request_id": requestID,
+			"route":      c.FullPath(),
+		}))
+		c.Next()
+	})
+}
+
+// AccessLogMiddleware writes one structured entry per request, after it is
+// served. Query parameters named by redactor are masked.
+func AccessLogMiddleware(redactor *logging.Redactor, skipPaths ...string) gin.HandlerFunc {
+	skip := make(map[string]bool, len(skipPaths))
+	for _, path := range skipPaths {
+		skip[path] = true
+	}
+
+	return gin.HandlerFunc(func(c *gin.Context) {
+		if skip[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		c.Next()
+
+		// Size is -1 when nothing was written
+		size := c.Writer.Size()
+		if size < 0 {
+			size = 0
+		}
+		fields := logrus.Fields{
+			"method":     c.Request.Method,
+			"path":       c.Request.URL.Path,
+			"status":     c.Writer.Status(),
+			"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+			"bytes":      size,
+			"client_ip":  c.ClientIP(),
+			"user_agent": c.Request.UserAgent(),
+		}
+		if query := c.Request.URL.Query(); len(query) > 0 {
+			params := make(map[string]interface{}, len(query))
+			for key, values := range query {
+				switch {
+				case redactor.Sensitive(key):
+					params[key] = logging.Redacted
+				case len(values) == 1:
+					params[key] = values[0]
+				default:
+					params[key] = values
+				}
+			}
+			fields["query"] = params
+		}
+		if len(c.Errors) > 0 {
+			fields["errors"] = c.Errors.String()
+		}
+
+		// Handlers may have replaced the context, the logger is read last
+		entry := logging.FromContext(c.Request.Context()).WithFields(fields)
+		switch status := c.Writer.Status(); {
+		case status >= 500:
+			entry.Error("Request completed")
+		case status >= 400:
+			entry.Warn("Request completed")
+		default:
+			entry.Info("Request completed")
+		}
+	})
+}
+
+// RecoveryMiddleware turns panics into 500 responses and logs them with the
+// request's logger, in place of gin's text output.
+func RecoveryMiddleware() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered interface{}) {
+		logging.FromContext(c.Request.Context()).WithFields(logrus.Fields{
+			"panic": recovered,
+			"stack": string(debug.Stack()),
+		}).Error("Recovered from panic")
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	})
+}
@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/SahandMohammed/wallet-service/internal/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+// captureLogs records the standard logger's entries, after hooks such as
+// the redactor have run, until the test ends
+func captureLogs(t *testing.T, hooks ...logrus.Hook) *test.Hook {
+	t.Helper()
+	logger := logrus.StandardLogger()
+	saved := logger.ReplaceHooks(make(logrus.LevelHooks))
+	t.Cleanup(func() { logger.ReplaceHooks(saved) })
+
+	for _, hook := range hooks {
+		logger.AddHook(hook)
+	}
+	captured := &test.Hook{}
+	logger.AddHook(captured)
+	return captured
+}
+
+// entryWithMessage returns the only captured entry with message
+func entryWithMessage(t *testing.T, hook *test.Hook, message string) *logrus.Entry {
+	t.Helper()
+	var found *logrus.Entry
+	for _, entry := range hook.AllEntries() {
+		if entry.Message != message {
+			continue
+		}
+		if found != nil {
+			t.Fatalf("more than one %q entry", message)
+		}
+		found = entry
+	}
+	if found == nil {
+		t.Fatalf("no %q entry in %d entries", message, len(hook.AllEntries()))
+	}
+	return found
+}
+
+// newLoggedRouter serves /items/:id behind the request ID and access log
+// middleware. The handler logs a password field and answers with the
+// status in the status query parameter, 200 by default.
+func newLoggedRouter(redactor *logging.Redactor) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestIDMiddleware())
+	r.Use(AccessLogMiddleware(redactor, "/health"))
+	r.GET("/items/:id", func(c *gin.Context) {
+		logging.FromContext(c.Request.Context()).WithField("password", "hunter2").Info("Handled item")
+		status := http.StatusOK
+		switch c.Query("status") {
+		case "404":
+			status = http.StatusNotFound
+		case "500":
+			status = http.StatusInternalServerError
+		}
+		c.String(status, c.GetString("request_id"))
+	})
+	r.GET("/health", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	hook := captureLogs(t)
+	r := newLoggedRouter(logging.NewRedactor(nil))
+
+	tests := map[string]struct {
+		header string
+		keep   bool
+	}{
+		"accepted":  {"client-req.42:a_b", true},
+		"missing":   {"", false},
+		"malformed": {"has spaces\ninside", false},
+		"too long":  {strings.Repeat("a", 65), false},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			hook.Reset()
+			req := httptest.NewRequest(http.MethodGet, "/items/7", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Request-ID", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			echoed := rec.Header().Get("X-Request-ID")
+			if tt.keep && echoed != tt.header {
+				t.Errorf("expected %q echoed, got %q", tt.header, echoed)
+			}
+			if !tt.keep {
+				if _, err := uuid.Parse(echoed); err != nil {
+					t.Errorf("expected a generated UUID, got %q", echoed)
+				}
+			}
+			if rec.Body.String() != echoed {
+				t.Errorf("handlers see request ID %q, the response has %q", rec.Body.String(), echoed)
+			}
+
+			// Every entry of the request carries its ID and route
+			for _, message := range []string{"Handled item", "Request completed"} {
+				entry := entryWithMessage(t, hook, message)
+				if entry.Data["request_id"] != echoed || entry.Data["route"] != "/items/:id" {
+					t.Errorf("%q: expected request_id %q and route /items/:id, got %v", message, echoed, entry.Data)
+				}
+			}
+		})
+	}
+}
+
+func TestAccessLogMiddleware(t *testing.T) {
+	redactor := logging.NewRedactor([]string{"password", " Token "})
+	hook := captureLogs(t, redactor)
+	r := newLoggedRouter(redactor)
+
+	req := httptest.NewRequest(http.MethodGet, "/items/7?token=abc&TOKEN=def&page=2&tag=a&tag=b", nil)
+	req.Header.Set("User-Agent", "logging-test")
+	req.RemoteAddr = "192.0.2.10:40000"
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	entry := entryWithMessage(t, hook, "Request completed")
+	if entry.Level != logrus.InfoLevel {
+		t.Errorf("expected info for a 200, got %s", entry.Level)
+	}
+	for key, want := range map[string]interface{}{
+		"method":     http.MethodGet,
+		"path":       "/items/7",
+		"status":     http.StatusOK,
+		"client_ip":  "192.0.2.10",
+		"user_agent": "logging-test",
+	} {
+		if entry.Data[key] != want {
+			t.Errorf("expected %s=%v, got %v", key, want, entry.Data[key])
+		}
+	}
+	if _, ok := entry.Data["latency_ms"].(float64); !ok {
+		t.Errorf("expected latency_ms, got %v", entry.Data["latency_ms"])
+	}
+
+	query, ok := entry.Data["query"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected query parameters, got %v", entry.Data["query"])
+	}
+	if query["token"] != logging.Redacted || query["TOKEN"] != logging.Redacted {
+		t.Errorf("expected token parameters redacted, got %v", query)
+	}
+	if query["page"] != "2" {
+		t.Errorf("expected page=2, got %v", query["page"])
+	}
+	if tags, ok := query["tag"].([]string); !ok || len(tags) != 2 {
+		t.Errorf("expected both tag values, got %v", query["tag"])
+	}
+
+	// The redactor hook masks fields logged by handlers
+	if handled := entryWithMessage(t, hook, "Handled item"); handled.Data["password"] != logging.Redacted {
+		t.Errorf("expected the password field redacted, got %v", handled.Data["password"])
+	}
+
+	levels := map[string]logrus.Level{"404": logrus.WarnLevel, "500": logrus.ErrorLevel}
+	for status, level := range levels {
+		hook.Reset()
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items/7?status="+status, nil))
+		if entry := entryWithMessage(t, hook, "Request completed"); entry.Level != level {
+			t.Errorf("status %s: expected %s, got %s", status, level, entry.Level)
+		}
+	}
+
+	hook.Reset()
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	if entries := hook.AllEntries(); len(entries) != 0 {
+		t.Errorf("expected skipped paths not to be logged, got %d entries", len(entries))
+	}
+}
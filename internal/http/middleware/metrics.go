@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsMiddleware records request counts and latency per route template.
+// Requests that match no route share the "unmatched" label.
+func MetricsMiddleware() gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveHTTPRequest(c.Request.Method, route, strconv.Itoa(c.Writer.Status()), time.Since(start).Seconds())
+	})
+}
@@ -4,12 +4,28 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/SahandMohammed/wallet-service/internal/audit"
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/logging"
 	"github.com/SahandMohammed/wallet-service/internal/service"
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 )
 
-func AuthMiddleware(authService service.AuthService) gin.HandlerFunc {
+const (
+	AuthMethodJWT    = "jwt"
+	AuthMethodAPIKey = "api_key"
+)
+
+// AuthMiddleware accepts either a JWT or an API key, sent as
+// "Authorization: Bearer <credential>" or, for API keys, "X-API-Key".
+func AuthMiddleware(authService service.AuthService, apiKeyService service.APIKeyService) gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
+		if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+			authenticateAPIKey(c, apiKeyService, apiKey)
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
@@ -26,7 +42,12 @@ func AuthMiddleware(authService service.AuthService) gin.HandlerFunc {
 		}
 
 		token := parts[1]
-		claims, err := authService.ValidateToken(token)
+		if strings.HasPrefix(token, service.APIKeyPrefix) {
+			authenticateAPIKey(c, apiKeyService, token)
+			return
+		}
+
+		claims, err := authService.ValidateToken(c.Request.Context(), token)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			c.Abort()
@@ -34,8 +55,76 @@ func AuthMiddleware(authService service.AuthService) gin.HandlerFunc {
 		}
 
 		// Set user info in context
+		ctx := audit.WithActor(c.Request.Context(), claims.UserID, claims.Username)
+		c.Request = c.Request.WithContext(logging.WithFields(ctx, logrus.Fields{"user_id": claims.UserID}))
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("session_id", claims.SessionID)
+		c.Set("auth_method", AuthMethodJWT)
+		c.Next()
+	})
+}
+
+func authenticateAPIKey(c *gin.Context, apiKeyService service.APIKeyService, rawKey string) {
+	key, err := apiKeyService.Authenticate(c.Request.Context(), rawKey)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+		c.Abort()
+		return
+	}
+
+	ctx := audit.WithActor(c.Request.Context(), key.UserID, key.User.Username)
+	c.Request = c.Request.WithContext(logging.WithFields(ctx, logrus.Fields{"user_id": key.UserID, "api_key_id": key.ID}))
+	c.Set("user_id", key.UserID)
+	c.Set("username", key.User.Username)
+	c.Set("auth_method", AuthMethodAPIKey)
+	c.Set("api_key", key)
+	c.Next()
+}
+
+// RequireScope rejects API key requests whose key lacks the given scope.
+// JWT-authenticated users are not restricted by scopes.
+func RequireScope(scope domain.APIKeyScope) gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		if value, exists := c.Get("api_key"); exists {
+			if key := value.(*domain.APIKey); !key.HasScope(scope) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "API key is missing scope " + string(scope)})
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	})
+}
+
+// RequireAdmin rejects users that are not admins. API keys act with the
+// rights of their owner.
+func RequireAdmin(adminService service.AdminService) gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		isAdmin, err := adminService.IsAdmin(c.Request.Context(), c.MustGet("user_id").(uint))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check admin access"})
+			c.Abort()
+			return
+		}
+		if !isAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	})
+}
+
+// RequireJWT rejects API key requests, for routes such as credential
+// management that must only be reachable from an interactive login.
+func RequireJWT() gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		if c.GetString("auth_method") != AuthMethodJWT {
+			c.JSON(http.StatusForbidden, gin.H{"error": "This endpoint cannot be used with an API key"})
+			c.Abort()
+			return
+		}
 		c.Next()
 	})
 }
@@ -44,8 +133,9 @@ func CORSMiddleware() gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-API-Key, X-Signature, X-Signature-Timestamp, X-Signature-Nonce, Idempotency-Key, X-Request-ID")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy, Retry-After, Idempotent-Replayed, X-Request-ID")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -56,8 +146,16 @@ func CORSMiddleware() gin.HandlerFunc {
 	})
 }
 
-func LoggingMiddleware() gin.HandlerFunc {
-	return gin.LoggerWithConfig(gin.LoggerConfig{
-		SkipPaths: []string{"/health"},
+// AuditContextMiddleware attaches the client address and request ID to the
+// request context for audit log entries. AuthMiddleware adds the actor.
+func AuditContextMiddleware() gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		md := audit.Metadata{
+			IPAddress: c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			RequestID: c.GetString("request_id"),
+		}
+		c.Request = c.Request.WithContext(audit.WithMetadata(c.Request.Context(), md))
+		c.Next()
 	})
 }
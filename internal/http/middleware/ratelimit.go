@@ -0,0 +1,220 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// RateLimitPolicy describes how many requests a single caller may make to a
+// group of routes within a sliding window.
+type RateLimitPolicy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+}
+
+var (
+	// AuthRateLimitPolicy guards /auth/* against credential stuffing.
+	AuthRateLimitPolicy = RateLimitPolicy{Name: "auth", Limit: 10, Window: time.Minute}
+	// MoneyRateLimitPolicy guards routes that move money.
+	MoneyRateLimitPolicy = RateLimitPolicy{Name: "money", Limit: 30, Window: time.Minute}
+	// ReadRateLimitPolicy covers reads and other non-monetary requests.
+	ReadRateLimitPolicy = RateLimitPolicy{Name: "read", Limit: 120, Window: time.Minute}
+)
+
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration
+}
+
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, policy RateLimitPolicy) (*RateLimitResult, error)
+}
+
+// slidingWindowScript trims the window, records the request if there is room
+// and reports {allowed, remaining, reset_ms} atomically.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, 0, now - window)
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, ARGV[4])
+	redis.call('PEXPIRE', key, window)
+	count = count + 1
+	allowed = 1
+end
+
+local reset = window
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+
+return {allowed, limit - count, reset}
+`)
+
+type redisRateLimiter struct {
+	client *redis.Client
+}
+
+func NewRedisRateLimiter(client *redis.Client) RateLimiter {
+	return &redisRateLimiter{client: client}
+}
+
+func (l *redisRateLimiter) Allow(ctx context.Context, key string, policy RateLimitPolicy) (*RateLimitResult, error) {
+	now := time.Now().UnixMilli()
+	redisKey := fmt.Sprintf("ratelimit:%s:%s", policy.Name, key)
+
+	values, err := slidingWindowScript.Run(ctx, l.client, []string{redisKey},
+		now, policy.Window.Milliseconds(), policy.Limit, uuid.New().String()).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != 3 {
+		return nil, fmt.Errorf("unexpected rate limit script result: %v", values)
+	}
+
+	return &RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      policy.Limit,
+		Remaining:  int(values[1]),
+		ResetAfter: time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
+
+type memoryRateLimiter struct {
+	mu       sync.Mutex
+	requests map[string][]time.Time
+	calls    int
+}
+
+// NewMemoryRateLimiter returns a process-local sliding window limiter. It is
+// used as a fallback when Redis is unavailable, so limits are enforced per
+// instance rather than globally while it is in effect.
+func NewMemoryRateLimiter() RateLimiter {
+	return &memoryRateLimiter{requests: make(map[string][]time.Time)}
+}
+
+func (l *memoryRateLimiter) Allow(ctx context.Context, key string, policy RateLimitPolicy) (*RateLimitResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	windowStart := now.Add(-policy.Window)
+	mapKey := policy.Name + ":" + key
+
+	// Periodically drop keys whose windows have fully expired
+	l.calls++
+	if l.calls%1000 == 0 {
+		l.sweep(now)
+	}
+
+	kept := l.requests[mapKey][:0]
+	for _, t := range l.requests[mapKey] {
+		if t.After(windowStart) {
+			kept = append(kept, t)
+		}
+	}
+
+	allowed := len(kept) < policy.Limit
+	if allowed {
+		kept = append(kept, now)
+	}
+	l.requests[mapKey] = kept
+
+	reset := policy.Window
+	if len(kept) > 0 {
+		reset = kept[0].Add(policy.Window).Sub(now)
+	}
+
+	return &RateLimitResult{
+		Allowed:    allowed,
+		Limit:      policy.Limit,
+		Remaining:  policy.Limit - len(kept),
+		ResetAfter: reset,
+	}, nil
+}
+
+func (l *memoryRateLimiter) sweep(now time.Time) {
+	for key, times := range l.requests {
+		// The longest policy window bounds how long any entry stays relevant
+		if len(times) == 0 || now.Sub(times[len(times)-1]) > time.Hour {
+			delete(l.requests, key)
+		}
+	}
+}
+
+type fallbackRateLimiter struct {
+	primary  RateLimiter
+	fallback RateLimiter
+}
+
+// NewRateLimiter returns a Redis-backed limiter that falls back to an
+// in-memory limiter whenever Redis returns an error.
+func NewRateLimiter(redisClient *redis.Client) RateLimiter {
+	return &fallbackRateLimiter{
+		primary:  NewRedisRateLimiter(redisClient),
+		fallback: NewMemoryRateLimiter(),
+	}
+}
+
+func (l *fallbackRateLimiter) Allow(ctx context.Context, key string, policy RateLimitPolicy) (*RateLimitResult, error) {
+	result, err := l.primary.Allow(ctx, key, policy)
+	if err == nil {
+		return result, nil
+	}
+
+	logging.FromContext(ctx).WithError(err).WithField("policy", policy.Name).Warn("Redis rate limiter unavailable, using in-memory limiter")
+	return l.fallback.Allow(ctx, key, policy)
+}
+
+// RateLimitMiddleware limits requests per user, or per client IP when the
+// route is not behind AuthMiddleware.
+func RateLimitMiddleware(limiter RateLimiter, policy RateLimitPolicy) gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		key := "ip:" + c.ClientIP()
+		if userID, exists := c.Get("user_id"); exists {
+			key = fmt.Sprintf("user:%v", userID)
+		}
+
+		result, err := limiter.Allow(c.Request.Context(), key, policy)
+		if err != nil {
+			// Never reject traffic because the limiter itself failed
+			logging.FromContext(c.Request.Context()).WithError(err).WithField("policy", policy.Name).Error("Rate limiter failed")
+			c.Next()
+			return
+		}
+
+		resetSeconds := int(math.Ceil(result.ResetAfter.Seconds()))
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(max(result.Remaining, 0)))
+		c.Header("RateLimit-Reset", strconv.Itoa(resetSeconds))
+		c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, int(policy.Window.Seconds())))
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(max(resetSeconds, 1)))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	})
+}
@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+var testRateLimitPolicy = RateLimitPolicy{Name: "test", Limit: 3, Window: time.Minute}
+
+// newRateLimitedRouter serves one route behind RateLimitMiddleware. Requests
+// with an X-Test-User header are treated as authenticated by that user.
+func newRateLimitedRouter(limiter RateLimiter, policy RateLimitPolicy) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if user := c.GetHeader("X-Test-User"); user != "" {
+			id, _ := strconv.ParseUint(user, 10, 32)
+			c.Set("user_id", uint(id))
+		}
+	})
+	r.GET("/limited", RateLimitMiddleware(limiter, policy), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func requestFrom(r http.Handler, ip, user string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/limited", nil)
+	req.RemoteAddr = ip + ":40000"
+	if user != "" {
+		req.Header.Set("X-Test-User", user)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return mr, client
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	mr, client := newTestRedis(t)
+	r := newRateLimitedRouter(NewRateLimiter(client), testRateLimitPolicy)
+
+	for i := 0; i < testRateLimitPolicy.Limit; i++ {
+		rec := requestFrom(r, "192.0.2.1", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i+1, rec.Code)
+		}
+		if got := rec.Header().Get("RateLimit-Limit"); got != "3" {
+			t.Errorf("expected RateLimit-Limit 3, got %q", got)
+		}
+		if got := rec.Header().Get("RateLimit-Remaining"); got != strconv.Itoa(testRateLimitPolicy.Limit-i-1) {
+			t.Errorf("request %d: expected RateLimit-Remaining %d, got %q", i+1, testRateLimitPolicy.Limit-i-1, got)
+		}
+		if got := rec.Header().Get("RateLimit-Policy"); got != "3;w=60" {
+			t.Errorf("expected RateLimit-Policy 3;w=60, got %q", got)
+		}
+		if reset, err := strconv.Atoi(rec.Header().Get("RateLimit-Reset")); err != nil || reset < 1 || reset > 60 {
+			t.Errorf("expected RateLimit-Reset within the window, got %q", rec.Header().Get("RateLimit-Reset"))
+		}
+		if rec.Header().Get("Retry-After") != "" {
+			t.Error("expected no Retry-After on allowed requests")
+		}
+	}
+
+	rec := requestFrom(r, "192.0.2.1", "")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("RateLimit-Remaining"); got != "0" {
+		t.Errorf("expected RateLimit-Remaining 0, got %q", got)
+	}
+	if retry, err := strconv.Atoi(rec.Header().Get("Retry-After")); err != nil || retry < 1 || retry > 60 {
+		t.Errorf("expected Retry-After within the window, got %q", rec.Header().Get("Retry-After"))
+	}
+
+	// Other addresses have their own budget
+	if rec := requestFrom(r, "192.0.2.2", ""); rec.Code != http.StatusOK {
+		t.Errorf("expected another address to be allowed, got %d", rec.Code)
+	}
+
+	// Authenticated callers are counted per user, whatever their address
+	for i, ip := range []string{"192.0.2.3", "192.0.2.4", "192.0.2.5"} {
+		if rec := requestFrom(r, ip, "7"); rec.Code != http.StatusOK {
+			t.Fatalf("user request %d: expected 200, got %d", i+1, rec.Code)
+		}
+	}
+	if rec := requestFrom(r, "192.0.2.6", "7"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected the user to be limited across addresses, got %d", rec.Code)
+	}
+
+	for _, key := range []string{"ratelimit:test:ip:192.0.2.1", "ratelimit:test:user:7"} {
+		if !mr.Exists(key) {
+			t.Errorf("expected %s to be counted in Redis, keys are %v", key, mr.Keys())
+		}
+	}
+}
+
+func TestRateLimitWindowSlides(t *testing.T) {
+	_, client := newTestRedis(t)
+	policy := RateLimitPolicy{Name: "short", Limit: 1, Window: 200 * time.Millisecond}
+	r := newRateLimitedRouter(NewRateLimiter(client), policy)
+
+	if rec := requestFrom(r, "192.0.2.1", ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if rec := requestFrom(r, "192.0.2.1", ""); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rec.Code)
+	}
+	time.Sleep(250 * time.Millisecond)
+	if rec := requestFrom(r, "192.0.2.1", ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected the window to have moved on, got %d", rec.Code)
+	}
+}
+
+func TestRateLimitFallback(t *testing.T) {
+	mr, client := newTestRedis(t)
+	r := newRateLimitedRouter(NewRateLimiter(client), testRateLimitPolicy)
+
+	if rec := requestFrom(r, "192.0.2.1", ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	// With Redis failing the in-memory limiter takes over, counting from
+	// zero but still enforcing the policy
+	mr.SetError("LOADING Redis is loading the dataset in memory")
+	for i := 0; i < testRateLimitPolicy.Limit; i++ {
+		rec := requestFrom(r, "192.0.2.1", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("fallback request %d: expected 200, got %d", i+1, rec.Code)
+		}
+		if rec.Header().Get("RateLimit-Limit") != "3" {
+			t.Errorf("expected rate limit headers from the fallback, got %v", rec.Header())
+		}
+	}
+	rec := requestFrom(r, "192.0.2.1", "")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected the fallback to limit, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After from the fallback")
+	}
+
+	// Once Redis is back it is used again, with the count it had
+	mr.SetError("")
+	if rec := requestFrom(r, "192.0.2.1", ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected Redis to take over again, got %d", rec.Code)
+	}
+	if rec := requestFrom(r, "192.0.2.1", ""); rec.Header().Get("RateLimit-Remaining") != "0" {
+		t.Errorf("expected Redis to keep its count, got remaining %q", rec.Header().Get("RateLimit-Remaining"))
+	}
+}
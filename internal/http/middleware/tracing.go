@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/SahandMohammed/wallet-service/internal/tracing"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// untracedPaths are polled by probes and scrapers
+var untracedPaths = map[string]bool{
+	"/health":  true,
+	"/ready":   true,
+	"/live":    true,
+	"/metrics": true,
+}
+
+// TracingMiddleware starts a server span per request, named after the route
+// template, continuing the trace from W3C traceparent headers.
+func TracingMiddleware() gin.HandlerFunc {
+	return otelgin.Middleware(tracing.ServiceName, otelgin.WithFilter(func(r *http.Request) bool {
+		return !untracedPaths[r.URL.Path]
+	}))
+}
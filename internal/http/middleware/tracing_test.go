@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SahandMohammed/wallet-service/internal/logging"
+	"github.com/SahandMohammed/wallet-service/internal/tracing"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans installs a global tracer provider that keeps ended spans in
+// memory, and the W3C propagator the server uses, until the test ends
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	savedProvider, savedPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(savedProvider)
+		otel.SetTextMapPropagator(savedPropagator)
+		provider.Shutdown(context.Background())
+	})
+	return recorder
+}
+
+func newTracedRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(TracingMiddleware())
+	r.Use(RequestIDMiddleware())
+	r.GET("/wallets/:id", func(c *gin.Context) {
+		logging.FromContext(c.Request.Context()).Info("Handled wallet")
+		c.Status(http.StatusOK)
+	})
+	r.GET("/health", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	recorder := recordSpans(t)
+	hook := captureLogs(t, tracing.LogHook{})
+	r := newTracedRouter()
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/wallets/42", nil))
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected one span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "/wallets/:id" || span.SpanKind() != trace.SpanKindServer {
+		t.Errorf("expected a server span named after the route, got %s %q", span.SpanKind(), span.Name())
+	}
+	var route string
+	for _, attr := range span.Attributes() {
+		if attr.Key == semconv.HTTPRouteKey {
+			route = attr.Value.AsString()
+		}
+	}
+	if route != "/wallets/:id" {
+		t.Errorf("expected http.route /wallets/:id, got %q", route)
+	}
+
+	// Entries written through the request's logger carry its span
+	entry := entryWithMessage(t, hook, "Handled wallet")
+	if entry.Data["trace_id"] != span.SpanContext().TraceID().String() || entry.Data["span_id"] != span.SpanContext().SpanID().String() {
+		t.Errorf("expected trace_id %s and span_id %s, got %v", span.SpanContext().TraceID(), span.SpanContext().SpanID(), entry.Data)
+	}
+}
+
+func TestTracingContinuesTraceparent(t *testing.T) {
+	recorder := recordSpans(t)
+	r := newTracedRouter()
+
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanID  = "00f067aa0ba902b7"
+	)
+	req := httptest.NewRequest(http.MethodGet, "/wallets/42", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+spanID+"-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Probes are not traced
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected one span, without /health, got %d", len(spans))
+	}
+	if got := spans[0].SpanContext().TraceID().String(); got != traceID {
+		t.Errorf("expected the caller's trace %s, got %s", traceID, got)
+	}
+	if got := spans[0].Parent().SpanID().String(); got != spanID {
+		t.Errorf("expected the caller's span %s as parent, got %s", spanID, got)
+	}
+}
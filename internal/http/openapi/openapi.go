@@ -0,0 +1,158 @@
+package openapi
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed openapi.yaml
+var specYAML []byte
+
+// Spec is the OpenAPI 3 document describing the HTTP API
+type Spec struct {
+	doc  *openapi3.T
+	json []byte
+}
+
+// Load parses and validates the embedded document
+func Load() (*Spec, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(specYAML)
+	if err != nil {
+		return nil, err
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Spec{doc: doc, json: data}, nil
+}
+
+// MustLoad is like Load but panics on error. The document is embedded in the
+// binary, so an error is a programming mistake caught by the tests.
+func MustLoad() *Spec {
+	spec, err := Load()
+	if err != nil {
+		panic("openapi: invalid embedded document: " + err.Error())
+	}
+	return spec
+}
+
+func (s *Spec) Document() *openapi3.T {
+	return s.doc
+}
+
+// Operation returns the operation for a Gin route template such as
+// /wallets/:id, or nil when the document does not describe it.
+func (s *Spec) Operation(method, ginPath string) *openapi3.Operation {
+	item := s.doc.Paths.Value(PathFromGin(ginPath))
+	if item == nil {
+		return nil
+	}
+	return item.GetOperation(method)
+}
+
+// ServeJSON serves the document at /openapi.json
+func (s *Spec) ServeJSON(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", s.json)
+}
+
+// ServeDocs renders the document with Swagger UI at /docs
+func (s *Spec) ServeDocs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docsPage))
+}
+
+// ValidationMiddleware rejects requests whose JSON body does not match the
+// request body schema of the matched route.
+func (s *Spec) ValidationMiddleware() gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		operation := s.Operation(c.Request.Method, c.FullPath())
+		if operation == nil || operation.RequestBody == nil || operation.RequestBody.Value == nil {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		err = openapi3filter.ValidateRequestBody(c.Request.Context(), &openapi3filter.RequestValidationInput{
+			Request: c.Request,
+			Options: &openapi3filter.Options{MultiError: false},
+		}, operation.RequestBody.Value)
+
+		// Handlers read the body again
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed: " + validationMessage(err)})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	})
+}
+
+// PathFromGin converts a Gin route template to an OpenAPI path,
+// /wallets/:id becomes /wallets/{id}.
+func PathFromGin(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func validationMessage(err error) string {
+	var requestErr *openapi3filter.RequestError
+	if errors.As(err, &requestErr) && requestErr.Err != nil {
+		var schemaErr *openapi3.SchemaError
+		if errors.As(requestErr.Err, &schemaErr) {
+			if field := strings.Join(schemaErr.JSONPointer(), "."); field != "" {
+				return field + ": " + schemaErr.Reason
+			}
+			return schemaErr.Reason
+		}
+		return requestErr.Err.Error()
+	}
+	return err.Error()
+}
+
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Wallet Service API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
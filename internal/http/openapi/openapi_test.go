@@ -0,0 +1,69 @@
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLoad(t *testing.T) {
+	if _, err := Load(); err != nil {
+		t.Fatalf("embedded document is invalid: %v", err)
+	}
+}
+
+func TestPathFromGin(t *testing.T) {
+	cases := map[string]string{
+		"/wallets":     "/wallets",
+		"/wallets/:id": "/wallets/{id}",
+		"/webhooks/:id/deliveries/:delivery_id/redeliver": "/webhooks/{id}/deliveries/{delivery_id}/redeliver",
+	}
+	for in, want := range cases {
+		if got := PathFromGin(in); got != want {
+			t.Errorf("PathFromGin(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestValidationMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(MustLoad().ValidationMiddleware())
+	r.POST("/wallets/deposit", func(c *gin.Context) {
+		// The body must still be readable by the handler
+		var body map[string]interface{}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	cases := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"valid", `{"wallet_id": 1, "amount": 10.5}`, http.StatusOK},
+		{"missing amount", `{"wallet_id": 1}`, http.StatusBadRequest},
+		{"negative amount", `{"wallet_id": 1, "amount": -5}`, http.StatusBadRequest},
+		{"wrong type", `{"wallet_id": "one", "amount": 5}`, http.StatusBadRequest},
+		{"not json", `amount=5`, http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/wallets/deposit", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tc.want {
+			}
+		})
+	}
+}
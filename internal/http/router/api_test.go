@@ -0,0 +1,948 @@
+package router
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/cache"
+	"github.com/SahandMohammed/wallet-service/internal/config"
+	"github.com/SahandMohammed/wallet-service/internal/credential"
+	"github.com/SahandMohammed/wallet-service/internal/db"
+	"github.com/SahandMohammed/wallet-service/internal/dialect"
+	"github.com/SahandMohammed/wallet-service/internal/events"
+	"github.com/SahandMohammed/wallet-service/internal/http/middleware"
+	"github.com/SahandMohammed/wallet-service/internal/migration"
+	"github.com/SahandMohammed/wallet-service/internal/notification"
+	"github.com/SahandMohammed/wallet-service/internal/realtime"
+	"github.com/SahandMohammed/wallet-service/internal/repository"
+	"github.com/SahandMohammed/wallet-service/internal/service"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const testPassword = "password123"
+
+// testAPI is the real router on a migrated SQLite database and an in-process
+// Redis, as the server builds it
+type testAPI struct {
+	t       *testing.T
+	handler http.Handler
+	db      *gorm.DB
+	redis   *redis.Client
+	clients int
+	// shutdown cancels the router context, as the server does on SIGTERM
+	shutdown context.CancelFunc
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.Defaults()
+	cfg.DBDriver = dialect.SQLite
+	cfg.SQLitePath = filepath.Join(t.TempDir(), "wallet.db")
+	cfg.PasswordHasher = "bcrypt"
+	cfg.BcryptCost = 4
+
+	database, err := db.NewConnection(cfg)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := database.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	m, err := migration.New(database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(context.Background(), 0); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	redisClient := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+
+	policy, err := credential.NewPolicy(credential.PolicyConfig{
+		UsernamePattern:   cfg.UsernamePattern,
+		PasswordMinLength: cfg.PasswordMinLength,
+		PasswordMaxLength: cfg.PasswordMaxLength,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hasher, err := credential.NewHasher(credential.HasherConfig{Algorithm: cfg.PasswordHasher, BcryptCost: cfg.BcryptCost})
+	if err != nil {
+		t.Fatal(err)
+	}
+	services := service.NewServices(database, cache.NewRedisCache(redisClient), notification.NewLogNotifier(), policy, hasher, cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	return &testAPI{
+		t:        t,
+		handler:  SetupRouter(ctx, database, redisClient, services, cfg),
+		db:       database,
+		redis:    redisClient,
+		shutdown: cancel,
+	}
+}
+
+// client is a user with a wallet. Every client calls from its own address,
+// so that the per-IP rate limit on /auth is not shared between them.
+type client struct {
+	api      *testAPI
+	ip       string
+	username string
+	token    string
+	userID   uint
+	walletID uint
+	apiKeyID uint
+}
+
+// response is the envelope every handler answers with. Pagination is only
+// set by listings.
+type response struct {
+	status     int
+	Data       json.RawMessage `json:"data"`
+	Error      string          `json:"error"`
+	Pagination *struct {
+		Limit      int    `json:"limit"`
+		NextCursor string `json:"next_cursor"`
+		PrevCursor string `json:"prev_cursor"`
+		Total      *int64 `json:"total"`
+	} `json:"pagination"`
+}
+
+func (r *response) decode(t *testing.T, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(r.Data, v); err != nil {
+		t.Fatalf("decode %s: %v", r.Data, err)
+	}
+}
+
+func (a *testAPI) anonymous() *client {
+	a.clients++
+	return &client{api: a, ip: fmt.Sprintf("192.0.2.%d", a.clients)}
+}
+
+// signUp registers and logs in a user and creates a wallet for it
+func (a *testAPI) signUp(username string) *client {
+	a.t.Helper()
+	c := a.anonymous()
+	c.username = username
+
+	var user struct {
+		ID uint `json:"id"`
+	}
+	c.expect(http.StatusCreated, http.MethodPost, "/auth/register", credentials(username, testPassword)).decode(a.t, &user)
+	c.userID = user.ID
+	c.login(testPassword)
+
+	var wallet struct {
+		ID uint `json:"id"`
+	}
+	c.expect(http.StatusCreated, http.MethodPost, "/wallets", nil).decode(a.t, &wallet)
+	c.walletID = wallet.ID
+	return c
+}
+
+func credentials(username, password string) map[string]string {
+	return map[string]string{"username": username, "password": password}
+}
+
+func (c *client) login(password string) {
+	c.api.t.Helper()
+	var login struct {
+		Token string `json:"token"`
+	}
+	c.expect(http.StatusOK, http.MethodPost, "/auth/login", credentials(c.username, password)).decode(c.api.t, &login)
+	c.token = login.Token
+}
+
+func (c *client) do(method, path string, body interface{}, headers ...string) *response {
+	c.api.t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			c.api.t.Fatal(err)
+		}
+		reader = bytes.NewReader(encoded)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.RemoteAddr = c.ip + ":40000"
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	rec := httptest.NewRecorder()
+	c.api.handler.ServeHTTP(rec, req)
+
+	res := &response{status: rec.Code}
+	if rec.Body.Len() > 0 {
+		if err := json.Unmarshal(rec.Body.Bytes(), res); err != nil {
+			c.api.t.Fatalf("%s %s: invalid JSON %q", method, path, rec.Body.String())
+		}
+	}
+	return res
+}
+
+// expect fails the test unless the request answers with status
+func (c *client) expect(status int, method, path string, body interface{}, headers ...string) *response {
+	c.api.t.Helper()
+	res := c.do(method, path, body, headers...)
+	if res.status != status {
+		c.api.t.Fatalf("%s %s: expected %d, got %d: %s %s", method, path, status, res.status, res.Error, res.Data)
+	}
+	return res
+}
+
+type transaction struct {
+	TransactionID uint    `json:"transaction_id"`
+	WalletID      uint    `json:"wallet_id"`
+	Type          string  `json:"type"`
+	Amount        float64 `json:"amount"`
+	BalanceAfter  float64 `json:"balance_after"`
+	Description   string  `json:"description"`
+}
+
+func (c *client) deposit(amount float64) transaction {
+	c.api.t.Helper()
+	var tx transaction
+	c.expect(http.StatusOK, http.MethodPost, "/wallets/deposit", map[string]interface{}{
+		"wallet_id": c.walletID, "amount": amount, "description": "deposit",
+	}).decode(c.api.t, &tx)
+	return tx
+}
+
+func (c *client) transfer(to *client, amount float64) transaction {
+	c.api.t.Helper()
+	var tx transaction
+	c.expect(http.StatusOK, http.MethodPost, "/wallets/transfer", map[string]interface{}{
+		"from_wallet_id": c.walletID, "to_wallet_id": to.walletID, "amount": amount, "description": "transfer",
+	}).decode(c.api.t, &tx)
+	return tx
+}
+
+func (c *client) balance() float64 {
+	c.api.t.Helper()
+	var wallet struct {
+		Balance float64 `json:"balance"`
+	}
+	c.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/wallets/%d", c.walletID), nil).decode(c.api.t, &wallet)
+	return wallet.Balance
+}
+
+// apiKey creates an API key for the user and returns a client calling with
+// it from the same address, along with the key's signing secret
+func (c *client) apiKey(scopes []string, walletIDs ...uint) (*client, string) {
+	c.api.t.Helper()
+	var key struct {
+		ID            uint   `json:"id"`
+		Key           string `json:"key"`
+		SigningSecret string `json:"signing_secret"`
+	}
+	body := map[string]interface{}{"name": "integration", "scopes": scopes}
+	if len(walletIDs) > 0 {
+		body["wallet_ids"] = walletIDs
+	}
+	c.expect(http.StatusCreated, http.MethodPost, "/api-keys", body).decode(c.api.t, &key)
+
+	keyClient := *c
+	keyClient.token = key.Key
+	keyClient.apiKeyID = key.ID
+	return &keyClient, key.SigningSecret
+}
+
+func TestAuthAPI(t *testing.T) {
+	api := newTestAPI(t)
+	guest := api.anonymous()
+
+	guest.expect(http.StatusOK, http.MethodGet, "/health", nil)
+
+	alice := api.signUp("alice")
+	if alice.token == "" || alice.walletID == 0 {
+		t.Fatalf("expected a token and a wallet, got %+v", alice)
+	}
+
+	tests := map[string]struct {
+		path   string
+		body   interface{}
+		status int
+	}{
+		"duplicate username":   {"/auth/register", credentials("alice", testPassword), http.StatusBadRequest},
+		"username with digits": {"/auth/register", credentials("alice2", testPassword), http.StatusBadRequest},
+		"short password":       {"/auth/register", credentials("carol", "short"), http.StatusBadRequest},
+		"missing password":     {"/auth/register", map[string]string{"username": "carol"}, http.StatusBadRequest},
+		"wrong password":       {"/auth/login", credentials("alice", "password124"), http.StatusUnauthorized},
+		"unknown user":         {"/auth/login", credentials("nobody", testPassword), http.StatusUnauthorized},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if res := api.anonymous().do(http.MethodPost, tt.path, tt.body); res.status != tt.status {
+				t.Errorf("expected %d, got %d: %s", tt.status, res.status, res.Error)
+			}
+		})
+	}
+
+	guest.expect(http.StatusUnauthorized, http.MethodGet, "/wallets", nil)
+	guest.token = "not-a-jwt"
+	guest.expect(http.StatusUnauthorized, http.MethodGet, "/wallets", nil)
+
+	var sessions []struct {
+		ID string `json:"id"`
+	}
+	alice.expect(http.StatusOK, http.MethodGet, "/auth/sessions", nil).decode(t, &sessions)
+	if len(sessions) != 1 {
+		t.Errorf("expected one session, got %d", len(sessions))
+	}
+
+	// Changing the password revokes the tokens issued before
+	oldToken := alice.token
+	alice.expect(http.StatusOK, http.MethodPost, "/auth/password/change", map[string]string{
+		"current_password": testPassword, "new_password": "password456",
+	})
+	alice.token = oldToken
+	alice.expect(http.StatusUnauthorized, http.MethodGet, "/wallets", nil)
+	alice.login("password456")
+	alice.expect(http.StatusOK, http.MethodGet, "/wallets", nil)
+}
+
+func TestOwnershipAPI(t *testing.T) {
+	api := newTestAPI(t)
+	alice, bob := api.signUp("alice"), api.signUp("bob")
+	alice.deposit(10)
+
+	tests := map[string]struct {
+		method string
+		path   string
+		body   interface{}
+		status int
+	}{
+		"read another wallet": {http.MethodGet, fmt.Sprintf("/wallets/%d", alice.walletID), nil, http.StatusForbidden},
+		"read another history": {
+			http.MethodGet, fmt.Sprintf("/wallets/%d/transactions", alice.walletID), nil, http.StatusForbidden,
+		},
+		"deposit into another wallet": {
+			http.MethodPost, "/wallets/deposit",
+			map[string]interface{}{"wallet_id": alice.walletID, "amount": 5}, http.StatusForbidden,
+		},
+		"transfer from another wallet": {
+			http.MethodPost, "/wallets/transfer",
+			map[string]interface{}{"from_wallet_id": alice.walletID, "to_wallet_id": bob.walletID, "amount": 5}, http.StatusForbidden,
+		},
+		"missing wallet":    {http.MethodGet, "/wallets/999", nil, http.StatusNotFound},
+		"invalid wallet id": {http.MethodGet, "/wallets/abc", nil, http.StatusBadRequest},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if res := bob.do(tt.method, tt.path, tt.body); res.status != tt.status {
+				t.Errorf("expected %d, got %d: %s", tt.status, res.status, res.Error)
+			}
+		})
+	}
+
+	var wallets []struct {
+		ID uint `json:"id"`
+	}
+	bob.expect(http.StatusOK, http.MethodGet, "/wallets", nil).decode(t, &wallets)
+	if len(wallets) != 1 || wallets[0].ID != bob.walletID {
+		t.Errorf("expected only bob's wallet, got %+v", wallets)
+	}
+	if got := alice.balance(); got != 10 {
+		t.Errorf("forbidden requests changed alice's balance to %v", got)
+	}
+}
+
+func TestMoneyAPI(t *testing.T) {
+	api := newTestAPI(t)
+	alice, bob := api.signUp("alice"), api.signUp("bob")
+
+	if tx := alice.deposit(100); tx.Type != "deposit" || tx.BalanceAfter != 100 {
+		t.Errorf("unexpected deposit %+v", tx)
+	}
+	if tx := alice.transfer(bob, 30.5); tx.Amount != -30.5 || tx.BalanceAfter != 69.5 {
+		t.Errorf("unexpected transfer %+v", tx)
+	}
+	if alice.balance() != 69.5 || bob.balance() != 30.5 {
+		t.Errorf("unexpected balances %v and %v", alice.balance(), bob.balance())
+	}
+
+	tests := map[string]struct {
+		path string
+		body map[string]interface{}
+	}{
+		"zero deposit":         {"/wallets/deposit", map[string]interface{}{"wallet_id": alice.walletID, "amount": 0}},
+		"negative deposit":     {"/wallets/deposit", map[string]interface{}{"wallet_id": alice.walletID, "amount": -5}},
+		"insufficient balance": {"/wallets/transfer", map[string]interface{}{"from_wallet_id": alice.walletID, "to_wallet_id": bob.walletID, "amount": 1000}},
+		"same wallet":          {"/wallets/transfer", map[string]interface{}{"from_wallet_id": alice.walletID, "to_wallet_id": alice.walletID, "amount": 1}},
+		"missing destination":  {"/wallets/transfer", map[string]interface{}{"from_wallet_id": alice.walletID, "to_wallet_id": 999, "amount": 1}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if res := alice.do(http.MethodPost, tt.path, tt.body); res.status != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d: %s", res.status, res.Error)
+			}
+		})
+	}
+	if alice.balance() != 69.5 || bob.balance() != 30.5 {
+		t.Errorf("rejected requests changed the balances to %v and %v", alice.balance(), bob.balance())
+	}
+
+	// A repeated Idempotency-Key replays the first response
+	body := map[string]interface{}{"wallet_id": bob.walletID, "amount": 1}
+	var first, replayed transaction
+	bob.expect(http.StatusOK, http.MethodPost, "/wallets/deposit", body, "Idempotency-Key", "deposit-0001").decode(t, &first)
+	bob.expect(http.StatusOK, http.MethodPost, "/wallets/deposit", body, "Idempotency-Key", "deposit-0001").decode(t, &replayed)
+	if first.TransactionID != replayed.TransactionID || bob.balance() != 31.5 {
+		t.Errorf("expected one deposit, got transactions %d and %d and balance %v", first.TransactionID, replayed.TransactionID, bob.balance())
+	}
+
+	var history []transaction
+	path := fmt.Sprintf("/wallets/%d/transactions", bob.walletID)
+	res := bob.expect(http.StatusOK, http.MethodGet, path+"?limit=1&include_total=true", nil)
+	res.decode(t, &history)
+	if len(history) != 1 || history[0].TransactionID != first.TransactionID {
+		t.Errorf("expected the newest transaction first, got %+v", history)
+	}
+	if p := res.Pagination; p == nil || p.Limit != 1 || p.NextCursor == "" || p.PrevCursor != "" || p.Total == nil || *p.Total != 2 {
+		t.Fatalf("unexpected pagination on the first page %+v", p)
+	}
+
+	// A transaction arriving between pages does not shift the next page
+	bob.deposit(2)
+	res = bob.expect(http.StatusOK, http.MethodGet, path+"?limit=1&cursor="+res.Pagination.NextCursor, nil)
+	res.decode(t, &history)
+	if len(history) != 1 || history[0].Type != "transfer" || history[0].Amount != 30.5 {
+		t.Errorf("expected the incoming transfer on the second page, got %+v", history)
+	}
+	if p := res.Pagination; p.NextCursor != "" || p.PrevCursor == "" || p.Total != nil {
+		t.Errorf("unexpected pagination on the last page %+v", p)
+	}
+
+	// Going back from the last page lands on the first one, and the new
+	// transaction is behind it
+	res = bob.expect(http.StatusOK, http.MethodGet, path+"?limit=1&cursor="+res.Pagination.PrevCursor, nil)
+	res.decode(t, &history)
+	if len(history) != 1 || history[0].TransactionID != first.TransactionID || res.Pagination.PrevCursor == "" {
+		t.Errorf("expected the first page with a newer one before it, got %+v %+v", history, res.Pagination)
+	}
+
+	bob.expect(http.StatusBadRequest, http.MethodGet, path+"?cursor=bogus", nil)
+}
+
+func TestAdminAPI(t *testing.T) {
+	api := newTestAPI(t)
+	alice, bob := api.signUp("alice"), api.signUp("bob")
+	alice.deposit(50)
+	alice.transfer(bob, 20)
+	bob.deposit(5)
+
+	alice.expect(http.StatusForbidden, http.MethodGet, "/admin/users", nil)
+
+	// Admin rights are granted out of band, as walletctl does
+	if err := repository.NewUserRepository(api.db).SetAdmin(context.Background(), alice.userID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	var users []struct {
+		Username string `json:"username"`
+		IsAdmin  bool   `json:"is_admin"`
+		Wallets  []struct {
+			Balance float64 `json:"balance"`
+		} `json:"wallets"`
+	}
+	alice.expect(http.StatusOK, http.MethodGet, "/admin/users", nil).decode(t, &users)
+	if len(users) != 2 || users[0].Username != "bob" || users[0].Wallets[0].Balance != 25 || !users[1].IsAdmin {
+		t.Errorf("expected the newest user first, got %+v", users)
+	}
+
+	today := time.Now().UTC().Format("2006-01-02")
+	tomorrow := time.Now().UTC().Add(24 * time.Hour).Format("2006-01-02")
+	tests := map[string]struct {
+		query string
+		want  int
+	}{
+		"all":              {"", 4},
+		"deposits":         {"?type=deposit", 2},
+		"transfers":        {"?type=transfer", 2},
+		"one user":         {fmt.Sprintf("?user_id=%d", bob.userID), 2},
+		"user and type":    {fmt.Sprintf("?user_id=%d&type=deposit", bob.userID), 1},
+		"today":            {"?start_date=" + today + "&end_date=" + today, 4},
+		"from tomorrow":    {"?start_date=" + tomorrow, 0},
+		"first page":       {"?limit=3", 3},
+		"ignored bad type": {"?type=refund", 4},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var transactions []transaction
+			res := alice.do(http.MethodGet, "/admin/transactions"+tt.query, nil)
+			if res.status != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", res.status, res.Error)
+			}
+			res.decode(t, &transactions)
+			if len(transactions) != tt.want {
+				t.Errorf("expected %d transactions, got %d", tt.want, len(transactions))
+			}
+		})
+	}
+
+	// Walking the pages visits every transaction once
+	seen := map[uint]bool{}
+	query := "?limit=3&include_total=true"
+	for pages := 0; query != ""; pages++ {
+		if pages == 3 {
+			t.Fatal("paging did not end")
+		}
+		var transactions []transaction
+		res := alice.expect(http.StatusOK, http.MethodGet, "/admin/transactions"+query, nil)
+		res.decode(t, &transactions)
+		for _, tx := range transactions {
+			if seen[tx.TransactionID] {
+				t.Errorf("transaction %d listed twice", tx.TransactionID)
+			}
+			seen[tx.TransactionID] = true
+		}
+		if res.Pagination.Total == nil || *res.Pagination.Total != 4 {
+			t.Errorf("expected a total of 4, got %v", res.Pagination.Total)
+		}
+		query = ""
+		if res.Pagination.NextCursor != "" {
+			query = "?limit=3&include_total=true&cursor=" + res.Pagination.NextCursor
+		}
+	}
+	if len(seen) != 4 {
+		t.Errorf("expected 4 transactions across the pages, got %d", len(seen))
+	}
+
+	var entries []struct {
+		Action   string `json:"action"`
+		TargetID string `json:"target_id"`
+	}
+	alice.expect(http.StatusOK, http.MethodGet, "/admin/audit-logs?action="+service.AuditActionTransfer, nil).decode(t, &entries)
+	if len(entries) != 1 || entries[0].TargetID != fmt.Sprint(alice.walletID) {
+		t.Errorf("expected one transfer audit entry, got %+v", entries)
+	}
+
+	// RequireAdmin checks the user on every request, so revoking takes
+	// effect without a new login
+	if err := repository.NewUserRepository(api.db).SetAdmin(context.Background(), alice.userID, false); err != nil {
+		t.Fatal(err)
+	}
+	alice.expect(http.StatusForbidden, http.MethodGet, "/admin/users", nil)
+	api.anonymous().expect(http.StatusUnauthorized, http.MethodGet, "/admin/users", nil)
+}
+
+func TestAPIKeyAPI(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.signUp("alice")
+
+	var second struct {
+		ID uint `json:"id"`
+	}
+	alice.expect(http.StatusCreated, http.MethodPost, "/wallets", nil).decode(t, &second)
+
+	reader, _ := alice.apiKey([]string{"wallets:read"}, alice.walletID)
+
+	tests := map[string]struct {
+		method string
+		path   string
+		body   interface{}
+		status int
+	}{
+		"read an allowed wallet":   {http.MethodGet, fmt.Sprintf("/wallets/%d", alice.walletID), nil, http.StatusOK},
+		"read an allowed history":  {http.MethodGet, fmt.Sprintf("/wallets/%d/transactions", alice.walletID), nil, http.StatusOK},
+		"read another own wallet":  {http.MethodGet, fmt.Sprintf("/wallets/%d", second.ID), nil, http.StatusForbidden},
+		"read another own history": {http.MethodGet, fmt.Sprintf("/wallets/%d/transactions", second.ID), nil, http.StatusForbidden},
+		"create without scope":     {http.MethodPost, "/wallets", nil, http.StatusForbidden},
+		"deposit without scope": {
+			http.MethodPost, "/wallets/deposit", map[string]interface{}{"wallet_id": alice.walletID, "amount": 5}, http.StatusForbidden,
+		},
+		"admin without scope":      {http.MethodGet, "/admin/users", nil, http.StatusForbidden},
+		"manage keys with a key":   {http.MethodGet, "/api-keys", nil, http.StatusForbidden},
+		"manage webhooks with key": {http.MethodGet, "/webhooks", nil, http.StatusForbidden},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if res := reader.do(tt.method, tt.path, tt.body); res.status != tt.status {
+				t.Errorf("expected %d, got %d: %s", tt.status, res.status, res.Error)
+			}
+		})
+	}
+
+	// Listings leave out the wallets the key is not allowed on
+	var wallets []struct {
+		ID uint `json:"id"`
+	}
+	reader.expect(http.StatusOK, http.MethodGet, "/wallets", nil).decode(t, &wallets)
+	if len(wallets) != 1 || wallets[0].ID != alice.walletID {
+		t.Errorf("expected only the allowed wallet, got %+v", wallets)
+	}
+
+	// A key without wallet restrictions reaches every wallet of its user,
+	// sent either as a bearer token or in X-API-Key
+	unrestricted, _ := alice.apiKey([]string{"wallets:read"})
+	unrestricted.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/wallets/%d", second.ID), nil)
+	rawKey := unrestricted.token
+	unrestricted.token = ""
+	unrestricted.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/wallets/%d", second.ID), nil, "X-API-Key", rawKey)
+	unrestricted.expect(http.StatusUnauthorized, http.MethodGet, "/wallets", nil, "X-API-Key", rawKey+"x")
+
+	alice.expect(http.StatusNoContent, http.MethodDelete, fmt.Sprintf("/api-keys/%d", reader.apiKeyID), nil)
+	reader.expect(http.StatusUnauthorized, http.MethodGet, fmt.Sprintf("/wallets/%d", alice.walletID), nil)
+}
+
+// signature returns the signing headers for a request with body, which is
+// encoded the way client.do sends it
+func signature(t *testing.T, secret, method, path string, body interface{}, at time.Time, nonce string) []string {
+	t.Helper()
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(middleware.CanonicalRequest(method, path, timestamp, nonce, encoded)))
+	return []string{
+		middleware.SignatureHeader, hex.EncodeToString(mac.Sum(nil)),
+		middleware.SignatureTimestampHeader, timestamp,
+		middleware.SignatureNonceHeader, nonce,
+	}
+}
+
+func TestRequestSigningAPI(t *testing.T) {
+	api := newTestAPI(t)
+	alice, bob := api.signUp("alice"), api.signUp("bob")
+	var second struct {
+		ID uint `json:"id"`
+	}
+	alice.expect(http.StatusCreated, http.MethodPost, "/wallets", nil).decode(t, &second)
+	payer, secret := alice.apiKey([]string{"wallets:read", "wallets:deposit", "wallets:transfer"}, alice.walletID)
+
+	deposit := map[string]interface{}{"wallet_id": alice.walletID, "amount": 10}
+	now := time.Now()
+
+	payer.expect(http.StatusUnauthorized, http.MethodPost, "/wallets/deposit", deposit)
+	payer.expect(http.StatusOK, http.MethodPost, "/wallets/deposit", deposit,
+		signature(t, secret, http.MethodPost, "/wallets/deposit", deposit, now, "nonce-0000000001")...)
+
+	tests := map[string]struct {
+		path    string
+		body    interface{}
+		headers []string
+		status  int
+	}{
+		"replayed nonce": {
+			"/wallets/deposit", deposit,
+			signature(t, secret, http.MethodPost, "/wallets/deposit", deposit, now, "nonce-0000000001"), http.StatusUnauthorized,
+		},
+		"timestamp too old": {
+			"/wallets/deposit", deposit,
+			signature(t, secret, http.MethodPost, "/wallets/deposit", deposit, now.Add(-6*time.Minute), "nonce-0000000002"), http.StatusUnauthorized,
+		},
+		"timestamp too far ahead": {
+			"/wallets/deposit", deposit,
+			signature(t, secret, http.MethodPost, "/wallets/deposit", deposit, now.Add(6*time.Minute), "nonce-0000000003"), http.StatusUnauthorized,
+		},
+		"tampered body": {
+			"/wallets/deposit", map[string]interface{}{"wallet_id": alice.walletID, "amount": 1000},
+			signature(t, secret, http.MethodPost, "/wallets/deposit", deposit, now, "nonce-0000000004"), http.StatusUnauthorized,
+		},
+		"tampered path": {
+			"/wallets/transfer", map[string]interface{}{"from_wallet_id": alice.walletID, "to_wallet_id": bob.walletID, "amount": 10},
+			signature(t, secret, http.MethodPost, "/wallets/deposit", map[string]interface{}{
+				"from_wallet_id": alice.walletID, "to_wallet_id": bob.walletID, "amount": 10,
+			}, now, "nonce-0000000005"), http.StatusUnauthorized,
+		},
+		"wrong secret": {
+			"/wallets/deposit", deposit,
+			signature(t, "not-the-secret", http.MethodPost, "/wallets/deposit", deposit, now, "nonce-0000000006"), http.StatusUnauthorized,
+		},
+		"short nonce": {
+			"/wallets/deposit", deposit,
+			signature(t, secret, http.MethodPost, "/wallets/deposit", deposit, now, "short"), http.StatusUnauthorized,
+		},
+		// Signed correctly, but the key is limited to alice's first wallet
+		"wallet not allowed": {
+			"/wallets/deposit", map[string]interface{}{"wallet_id": second.ID, "amount": 10},
+			signature(t, secret, http.MethodPost, "/wallets/deposit", map[string]interface{}{"wallet_id": second.ID, "amount": 10}, now, "nonce-0000000007"),
+			http.StatusForbidden,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if res := payer.do(http.MethodPost, tt.path, tt.body, tt.headers...); res.status != tt.status {
+				t.Errorf("expected %d, got %d: %s", tt.status, res.status, res.Error)
+			}
+		})
+	}
+
+	// Timestamps inside the skew window are accepted
+	transfer := map[string]interface{}{"from_wallet_id": alice.walletID, "to_wallet_id": bob.walletID, "amount": 4}
+	payer.expect(http.StatusOK, http.MethodPost, "/wallets/transfer", transfer,
+		signature(t, secret, http.MethodPost, "/wallets/transfer", transfer, now.Add(-4*time.Minute), "nonce-0000000008")...)
+
+	if got := alice.balance(); got != 6 {
+		t.Errorf("expected a balance of 6, got %v", got)
+	}
+	if got := bob.balance(); got != 4 {
+		t.Errorf("expected a balance of 4, got %v", got)
+	}
+
+	// Interactive logins do not sign
+	alice.deposit(1)
+}
+
+// sseEvent is one frame of an event stream
+type sseEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+// openStream connects to a wallet's event stream on server. Events are
+// read from the returned channel, which is closed when the stream ends.
+func (c *client) openStream(server *httptest.Server, walletID uint, lastEventID string) <-chan sseEvent {
+	c.api.t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	c.api.t.Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/wallets/%d/stream", server.URL, walletID), nil)
+	if err != nil {
+		c.api.t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	res, err := server.Client().Do(req)
+	if err != nil {
+		c.api.t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != "text/event-stream" {
+		res.Body.Close()
+		c.api.t.Fatalf("expected an event stream, got %d %s", res.StatusCode, res.Header.Get("Content-Type"))
+	}
+
+	frames := make(chan sseEvent, 16)
+	go func() {
+		defer close(frames)
+		defer res.Body.Close()
+		scanner := bufio.NewScanner(res.Body)
+		var frame sseEvent
+		for scanner.Scan() {
+			field, value, _ := strings.Cut(scanner.Text(), ": ")
+			switch field {
+			case "id":
+				frame.id = value
+			case "event":
+				frame.event = value
+			case "data":
+				frame.data = value
+			case "":
+				if frame.event != "" {
+					frames <- frame
+				}
+				frame = sseEvent{}
+			}
+		}
+	}()
+	return frames
+}
+
+func nextEvent(t *testing.T, frames <-chan sseEvent) sseEvent {
+	t.Helper()
+	select {
+	case frame, ok := <-frames:
+		if !ok {
+			t.Fatal("stream ended")
+		}
+		return frame
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+	return sseEvent{}
+}
+
+func TestStreamAPI(t *testing.T) {
+	api := newTestAPI(t)
+	alice, bob := api.signUp("alice"), api.signUp("bob")
+	server := httptest.NewServer(api.handler)
+	t.Cleanup(server.Close)
+
+	bob.expect(http.StatusForbidden, http.MethodGet, fmt.Sprintf("/wallets/%d/stream", alice.walletID), nil)
+	alice.expect(http.StatusBadRequest, http.MethodGet, fmt.Sprintf("/wallets/%d/stream", alice.walletID), nil, "Last-Event-ID", "latest")
+
+	broker := realtime.NewBroker(api.redis, 100)
+	publish := func(balance int64) {
+		t.Helper()
+		payload, _ := json.Marshal(events.DepositCompleted{WalletID: alice.walletID, Amount: 100, BalanceAfter: balance})
+		if err := broker.Publish(context.Background(), events.Envelope{
+			EventID: fmt.Sprintf("deposit-%d", balance), Type: events.TypeDepositCompleted, OccurredAt: time.Now(), Payload: payload,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	type update struct {
+		EventID  string  `json:"event_id"`
+		WalletID uint    `json:"wallet_id"`
+		Balance  float64 `json:"balance"`
+	}
+	decode := func(frame sseEvent) update {
+		t.Helper()
+		var u update
+		if err := json.Unmarshal([]byte(frame.data), &u); err != nil {
+			t.Fatalf("decode %q: %v", frame.data, err)
+		}
+		return u
+	}
+
+	// A fresh stream starts with the balance, then every connected stream
+	// gets each update
+	first, second := alice.openStream(server, alice.walletID, ""), alice.openStream(server, alice.walletID, "")
+	for _, frames := range []<-chan sseEvent{first, second} {
+		if frame := nextEvent(t, frames); frame.event != realtime.UpdateBalance || frame.id != "" || decode(frame).WalletID != alice.walletID {
+			t.Fatalf("expected the current balance first, got %+v", frame)
+		}
+	}
+
+	publish(100)
+	var seen sseEvent
+	for _, frames := range []<-chan sseEvent{first, second} {
+		seen = nextEvent(t, frames)
+		if seen.event != realtime.UpdateTransaction || seen.id == "" || decode(seen).Balance != 1 {
+			t.Fatalf("expected the deposit, got %+v", seen)
+		}
+	}
+
+	// A client that reconnects with Last-Event-ID gets what it missed and
+	// no initial balance
+	publish(200)
+	publish(300)
+	resumed := alice.openStream(server, alice.walletID, seen.id)
+	for _, want := range []float64{2, 3} {
+		frame := nextEvent(t, resumed)
+		if frame.event != realtime.UpdateTransaction || decode(frame).Balance != want {
+			t.Fatalf("expected the replayed deposit to %v, got %+v", want, frame)
+		}
+	}
+	publish(400)
+	if frame := nextEvent(t, resumed); decode(frame).EventID != "deposit-400" {
+		t.Fatalf("expected the live deposit after the replay, got %+v", frame)
+	}
+}
+
+func TestShutdownAPI(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.signUp("alice")
+	server := httptest.NewServer(api.handler)
+	t.Cleanup(server.Close)
+
+	type readiness struct {
+		Ready        bool `json:"ready"`
+		ShuttingDown bool `json:"shutting_down"`
+	}
+	ready := func() (int, readiness) {
+		t.Helper()
+		recorder := httptest.NewRecorder()
+		api.handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ready", nil))
+		var body readiness
+		if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode %s: %v", recorder.Body, err)
+		}
+		return recorder.Code, body
+	}
+
+	if code, body := ready(); code != http.StatusOK || !body.Ready || body.ShuttingDown {
+		t.Fatalf("expected ready before shutdown, got %d %+v", code, body)
+	}
+	frames := alice.openStream(server, alice.walletID, "")
+	if frame := nextEvent(t, frames); frame.event != realtime.UpdateBalance {
+		t.Fatalf("expected the current balance first, got %+v", frame)
+	}
+
+	api.shutdown()
+
+	if code, body := ready(); code != http.StatusServiceUnavailable || body.Ready || !body.ShuttingDown {
+		t.Errorf("expected not ready while shutting down, got %d %+v", code, body)
+	}
+
+	// Open streams end, so that draining the server does not wait for them
+	select {
+	case frame, ok := <-frames:
+		if ok {
+			t.Fatalf("expected the stream to close, got %+v", frame)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream still open after shutdown")
+	}
+
+	// Other requests are still served until the server stops
+	if balance := alice.balance(); balance != 0 {
+		t.Errorf("expected a zero balance, got %v", balance)
+	}
+}
+
+func TestMetricsAPI(t *testing.T) {
+	api := newTestAPI(t)
+	alice, bob := api.signUp("alice"), api.signUp("bob")
+
+	wallet := fmt.Sprintf("/wallets/%d", alice.walletID)
+	alice.expect(http.StatusOK, http.MethodGet, wallet, nil)
+	bob.expect(http.StatusForbidden, http.MethodGet, wallet, nil)
+	recorder := httptest.NewRecorder()
+	api.handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/no/such/route", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown route, got %d", recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	api.handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected /metrics to answer 200, got %d", recorder.Code)
+	}
+	scraped := recorder.Body.String()
+
+	// Series are labelled by route template, so wallet IDs add no series
+	for _, want := range []string{
+		`wallet_http_requests_total{method="GET",route="/wallets/:id",status="200"}`,
+		`wallet_http_requests_total{method="GET",route="/wallets/:id",status="403"}`,
+		`wallet_http_requests_total{method="GET",route="unmatched",status="404"}`,
+		`wallet_http_request_duration_seconds_count{method="GET",route="/wallets/:id"}`,
+	} {
+		if !strings.Contains(scraped, want) {
+			t.Errorf("expected %s in the scrape", want)
+		}
+	}
+	for _, raw := range []string{`route="` + wallet + `"`, `route="/no/such/route"`} {
+		if strings.Contains(scraped, raw) {
+			t.Errorf("raw path label %s in the scrape", raw)
+		}
+	}
+}
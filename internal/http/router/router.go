@@ -1,72 +1,141 @@
 package router
 
 import (
+	"context"
+
 	"github.com/SahandMohammed/wallet-service/internal/config"
+	"github.com/SahandMohammed/wallet-service/internal/domain"
 	"github.com/SahandMohammed/wallet-service/internal/http/handler"
 	"github.com/SahandMohammed/wallet-service/internal/http/middleware"
-	"github.com/SahandMohammed/wallet-service/internal/repository"
+	"github.com/SahandMohammed/wallet-service/internal/http/openapi"
+	"github.com/SahandMohammed/wallet-service/internal/logging"
+	"github.com/SahandMohammed/wallet-service/internal/metrics"
+	"github.com/SahandMohammed/wallet-service/internal/realtime"
 	"github.com/SahandMohammed/wallet-service/internal/service"
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
-func SetupRouter(db *gorm.DB, redisClient *redis.Client, cfg *config.Config) *gin.Engine {
+// SetupRouter registers the HTTP API on the shared services. Once ctx is
+// done, at the start of shutdown, readiness fails and event streams are
+// closed.
+func SetupRouter(
+	ctx context.Context,
+	db *gorm.DB,
+	redisClient *redis.Client,
+	services *service.Services,
+	cfg *config.Config,
+) *gin.Engine {
 	r := gin.New()
 
-	// Middleware
-	r.Use(gin.Recovery())
-	r.Use(middleware.LoggingMiddleware())
+	spec := openapi.MustLoad()
+
+	// Middleware, metrics, tracing and logging first so that recovered
+	// panics are counted and logged as 500s
+	r.Use(middleware.MetricsMiddleware())
+	r.Use(middleware.TracingMiddleware())
+	r.Use(middleware.RequestIDMiddleware())
+	r.Use(middleware.AccessLogMiddleware(logging.NewRedactor(cfg.LogRedactFields), "/health", "/metrics"))
+	r.Use(middleware.RecoveryMiddleware())
 	r.Use(middleware.CORSMiddleware())
+	r.Use(middleware.AuditContextMiddleware())
+	r.Use(spec.ValidationMiddleware())
 
-	// Initialize repositories
-	userRepo := repository.NewUserRepository(db)
-	walletRepo := repository.NewWalletRepository(db)
-	transactionRepo := repository.NewTransactionRepository(db)
+	// Initialize handlers
+	healthHandler := handler.NewHealthHandler(db, redisClient, ctx.Done())
+	authHandler := handler.NewAuthHandler(services.Auth)
+	walletHandler := handler.NewWalletHandler(services.Wallet)
+	adminHandler := handler.NewAdminHandler(services.Admin)
+	apiKeyHandler := handler.NewAPIKeyHandler(services.APIKeys)
+	webhookHandler := handler.NewWebhookHandler(services.Webhooks)
+	streamHandler := handler.NewStreamHandler(
+		services.Wallet,
+		realtime.NewBroker(redisClient, int64(cfg.StreamHistorySize)),
+		cfg.StreamHeartbeat,
+		ctx.Done(),
+	)
 
-	// Initialize services
-	authService := service.NewAuthService(userRepo, cfg, redisClient)
-	walletService := service.NewWalletService(walletRepo, transactionRepo, userRepo, redisClient, db)
-	adminService := service.NewAdminService(userRepo, transactionRepo)
+	authMiddleware := middleware.AuthMiddleware(services.Auth, services.APIKeys)
 
-	// Initialize handlers
-	healthHandler := handler.NewHealthHandler(db, redisClient)
-	authHandler := handler.NewAuthHandler(authService)
-	walletHandler := handler.NewWalletHandler(walletService)
-	adminHandler := handler.NewAdminHandler(adminService)
+	signed := middleware.SignatureMiddleware(services.APIKeys, redisClient, cfg.RequestSigningMaxSkew)
+	idempotent := middleware.IdempotencyMiddleware(redisClient)
+
+	// Rate limiters
+	rateLimiter := middleware.NewRateLimiter(redisClient)
+	authLimit := middleware.RateLimitMiddleware(rateLimiter, middleware.AuthRateLimitPolicy)
+	moneyLimit := middleware.RateLimitMiddleware(rateLimiter, middleware.MoneyRateLimitPolicy)
+	readLimit := middleware.RateLimitMiddleware(rateLimiter, middleware.ReadRateLimitPolicy)
 
 	// Health check endpoints
 	r.GET("/health", healthHandler.Health)
 	r.GET("/ready", healthHandler.Ready)
 	r.GET("/live", healthHandler.Live)
 
+	// Prometheus metrics
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	// API documentation
+	r.GET("/openapi.json", spec.ServeJSON)
+	r.GET("/docs", spec.ServeDocs)
+
 	// Auth routes
 	auth := r.Group("/auth")
+	auth.Use(authLimit)
 	{
 		auth.POST("/register", authHandler.Register)
 		auth.POST("/login", authHandler.Login)
+		auth.POST("/password/reset", authHandler.RequestPasswordReset)
+		auth.POST("/password/reset/confirm", authHandler.ResetPassword)
+		auth.POST("/password/change", authMiddleware, middleware.RequireJWT(), authHandler.ChangePassword)
+		auth.GET("/sessions", authMiddleware, middleware.RequireJWT(), authHandler.ListSessions)
+		auth.DELETE("/sessions/:id", authMiddleware, middleware.RequireJWT(), authHandler.RevokeSession)
 	}
 
 	// Protected routes
 	protected := r.Group("/")
-	protected.Use(middleware.AuthMiddleware(authService))
+	protected.Use(authMiddleware)
 	{
 		// Wallet routes
 		wallets := protected.Group("/wallets")
 		{
-			wallets.POST("", walletHandler.CreateWallet)
-			wallets.GET("", walletHandler.GetUserWallets)
-			wallets.GET("/:id", walletHandler.GetWallet)
-			wallets.POST("/deposit", walletHandler.Deposit)
-			wallets.POST("/transfer", walletHandler.Transfer)
-			wallets.GET("/:id/transactions", walletHandler.GetTransactions)
+			wallets.POST("", readLimit, middleware.RequireScope(domain.ScopeWalletsCreate), walletHandler.CreateWallet)
+			wallets.GET("", readLimit, middleware.RequireScope(domain.ScopeWalletsRead), walletHandler.GetUserWallets)
+			wallets.GET("/:id", readLimit, middleware.RequireScope(domain.ScopeWalletsRead), walletHandler.GetWallet)
+			wallets.POST("/deposit", moneyLimit, middleware.RequireScope(domain.ScopeWalletsDeposit), signed, idempotent, walletHandler.Deposit)
+			wallets.POST("/transfer", moneyLimit, middleware.RequireScope(domain.ScopeWalletsTransfer), signed, idempotent, walletHandler.Transfer)
+			wallets.GET("/:id/transactions", readLimit, middleware.RequireScope(domain.ScopeWalletsRead), walletHandler.GetTransactions)
+			wallets.GET("/:id/stream", readLimit, middleware.RequireScope(domain.ScopeWalletsRead), streamHandler.StreamWallet)
+		}
+
+		// API key management, only available to interactive logins
+		apiKeys := protected.Group("/api-keys")
+		apiKeys.Use(readLimit, middleware.RequireJWT())
+		{
+			apiKeys.POST("", apiKeyHandler.CreateAPIKey)
+			apiKeys.GET("", apiKeyHandler.ListAPIKeys)
+			apiKeys.DELETE("/:id", apiKeyHandler.RevokeAPIKey)
+		}
+
+		// Webhook endpoints, only available to interactive logins
+		webhooks := protected.Group("/webhooks")
+		webhooks.Use(readLimit, middleware.RequireJWT())
+		{
+			webhooks.POST("", webhookHandler.CreateWebhook)
+			webhooks.GET("", webhookHandler.ListWebhooks)
+			webhooks.DELETE("/:id", webhookHandler.DeleteWebhook)
+			webhooks.POST("/:id/ping", webhookHandler.PingWebhook)
+			webhooks.GET("/:id/deliveries", webhookHandler.ListDeliveries)
+			webhooks.POST("/:id/deliveries/:delivery_id/redeliver", webhookHandler.Redeliver)
 		}
 
 		// Admin routes
 		admin := protected.Group("/admin")
+		admin.Use(readLimit, middleware.RequireScope(domain.ScopeAdminRead), middleware.RequireAdmin(services.Admin))
 		{
 			admin.GET("/users", adminHandler.ListUsers)
 			admin.GET("/transactions", adminHandler.ListTransactions)
+			admin.GET("/audit-logs", adminHandler.ListAuditLogs)
 		}
 	}
 
@@ -0,0 +1,61 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"testing"
+
+	"github.com/SahandMohammed/wallet-service/internal/config"
+	"github.com/SahandMohammed/wallet-service/internal/http/openapi"
+	"github.com/SahandMohammed/wallet-service/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// TestOpenAPIMatchesRoutes fails when a route is registered without being
+// documented, or documented without being registered.
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Nothing is called on the dependencies while routes are registered
+	redisClient := redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+	defer redisClient.Close()
+	r := SetupRouter(context.Background(), nil, redisClient, &service.Services{}, &config.Config{})
+
+	registered := map[string]bool{}
+	for _, route := range r.Routes() {
+		registered[route.Method+" "+openapi.PathFromGin(route.Path)] = true
+	}
+
+	documented := map[string]bool{}
+	for path, item := range openapi.MustLoad().Document().Paths.Map() {
+		for method := range item.Operations() {
+			documented[method+" "+path] = true
+		}
+	}
+
+	for _, route := range sortedKeys(registered) {
+		if !documented[route] {
+			t.Errorf("route %s is not described in openapi.yaml", route)
+		}
+	}
+	for _, route := range sortedKeys(documented) {
+		if !registered[route] {
+			t.Errorf("openapi.yaml describes %s but no such route is registered", route)
+		}
+	}
+
+	if len(registered) == 0 || !registered[http.MethodGet+" /health"] {
+		t.Fatal("expected the router to register routes")
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
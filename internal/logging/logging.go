@@ -0,0 +1,43 @@
+// Package logging carries a request-scoped logger in the context, so that
+// every log line of a request shares its request ID, route and user.
+package logging
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type contextKey struct{}
+
+// requestIDPattern accepts client supplied IDs that are safe to log and
+// fit the audit log's request_id column.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
+// RequestID returns id when it is a usable request ID, or a new random one
+func RequestID(id string) string {
+	if requestIDPattern.MatchString(id) {
+		return id
+	}
+	return uuid.New().String()
+}
+
+// WithFields returns a copy of ctx whose logger also has fields
+func WithFields(ctx context.Context, fields logrus.Fields) context.Context {
+	return context.WithValue(ctx, contextKey{}, entry(ctx).WithFields(fields))
+}
+
+// FromContext returns the logger of ctx, or the standard logger when ctx has
+// none. The entry carries ctx so that hooks can read the active span.
+func FromContext(ctx context.Context) *logrus.Entry {
+	return entry(ctx).WithContext(ctx)
+}
+
+func entry(ctx context.Context) *logrus.Entry {
+	if e, ok := ctx.Value(contextKey{}).(*logrus.Entry); ok {
+		return e
+	}
+	return logrus.NewEntry(logrus.StandardLogger())
+}
@@ -0,0 +1,44 @@
+package logging
+
+import (
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Redacted replaces the value of a sensitive field
+const Redacted = "[REDACTED]"
+
+// Redactor masks log fields by name, case-insensitively. It is a logrus
+// hook, so it applies to every entry of the logger it is added to.
+type Redactor struct {
+	fields map[string]bool
+}
+
+func NewRedactor(fields []string) *Redactor {
+	r := &Redactor{fields: make(map[string]bool, len(fields))}
+	for _, field := range fields {
+		if field = strings.ToLower(strings.TrimSpace(field)); field != "" {
+			r.fields[field] = true
+		}
+	}
+	return r
+}
+
+// Sensitive reports whether the value of key must not be logged
+func (r *Redactor) Sensitive(key string) bool {
+	return r.fields[strings.ToLower(key)]
+}
+
+func (r *Redactor) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (r *Redactor) Fire(entry *logrus.Entry) error {
+	for key := range entry.Data {
+		if r.Sensitive(key) {
+			entry.Data[key] = Redacted
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+// Package metrics defines the Prometheus metrics exported on /metrics.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
+)
+
+const namespace = "wallet"
+
+// Operations and caches used as label values
+const (
+	OperationDeposit  = "deposit"
+	OperationTransfer = "transfer"
+
+	CacheWallet       = "wallet"
+	CacheTransactions = "transactions"
+)
+
+// Registry holds every metric served by Handler, together with the Go
+// runtime and process collectors.
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	httpRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route template.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	deposits = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "deposits_total",
+		Help:      "Completed deposits.",
+	})
+
+	transfers = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transfers_total",
+		Help:      "Completed transfers.",
+	})
+
+	failures = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "operation_failures_total",
+		Help:      "Failed deposits and transfers by reason.",
+	}, []string{"operation", "reason"})
+
+	volume = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "amount_volume_total",
+		Help:      "Amount moved by completed deposits and transfers, in major currency units.",
+	}, []string{"operation", "currency"})
+
+	cacheRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Cache lookups by cache and result, hit or miss.",
+	}, []string{"cache", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// ObserveHTTPRequest records a served request. Route is the route template,
+// such as /wallets/:id, so that IDs do not create new series.
+func ObserveHTTPRequest(method, route, status string, seconds float64) {
+	httpRequests.WithLabelValues(method, route, status).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(seconds)
+}
+
+// RecordDeposit counts a completed deposit of amount major units
+func RecordDeposit(currency string, amount float64) {
+	deposits.Inc()
+	volume.WithLabelValues(OperationDeposit, currency).Add(amount)
+}
+
+// RecordTransfer counts a completed transfer of amount major units
+func RecordTransfer(currency string, amount float64) {
+	transfers.Inc()
+	volume.WithLabelValues(OperationTransfer, currency).Add(amount)
+}
+
+// RecordFailure counts a failed operation. Reason must come from a small,
+// fixed set of values.
+func RecordFailure(operation, reason string) {
+	failures.WithLabelValues(operation, reason).Inc()
+}
+
+// CacheHit counts a lookup answered from the cache
+func CacheHit(cache string) {
+	cacheRequests.WithLabelValues(cache, "hit").Inc()
+}
+
+// CacheMiss counts a lookup that had to go to the database
+func CacheMiss(cache string) {
+	cacheRequests.WithLabelValues(cache, "miss").Inc()
+}
+
+// RegisterDB exports the connection pool statistics of db as go_sql_*
+// metrics labelled with name.
+func RegisterDB(db *sql.DB, name string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterRedis exports the connection pool statistics of client
+func RegisterRedis(client *redis.Client) error {
+	return Registry.Register(newRedisCollector(client))
+}
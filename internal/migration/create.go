@@ -0,0 +1,45 @@
+package migration
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// SourceDir is where migrations live in the source tree, relative to the
+// repository root
+const SourceDir = "internal/migration/sql"
+
+var unsafeName = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Create writes an empty up and down file for the next version in dir and
+// returns their paths.
+func Create(dir, name string) (string, string, error) {
+	name = strings.Trim(unsafeName.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", "", fmt.Errorf("migration name is required")
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", "", err
+	}
+	migrations, err := Load(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+	var version uint64 = 1
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%06d_%s", version, name))
+	upPath, downPath := base+".up.sql", base+".down.sql"
+	for _, p := range []string{upPath, downPath} {
+		if err := os.WriteFile(p, nil, 0o644); err != nil {
+			return "", "", err
+		}
+	}
+	return upPath, downPath, nil
+}
@@ -1,14 +1,502 @@
+// Package migration applies the versioned SQL migrations in sql/<dialect>.
+//
+// Each migration is a pair of files named <version>_<name>.up.sql and
+// <version>_<name>.down.sql. Applied versions are recorded in the
+// schema_migrations table together with a checksum of the up file, and a
+// database lock makes concurrent runs wait for each other.
 package migration
 
 import (
-	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/dialect"
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
-func AutoMigrate(db *gorm.DB) error {
-	return db.AutoMigrate(
-		&domain.User{},
-		&domain.Wallet{},
-		&domain.Transaction{},
+//go:embed sql
+var embedded embed.FS
+
+const (
+	lockName    = "wallet_service_schema_migrations"
+	lockTimeout = 5 * time.Minute
+)
+
+// Startup modes
+const (
+	ModeUp    = "up"
+	ModeCheck = "check"
+	ModeOff   = "off"
+)
+
+var (
+	ErrSchemaBehind = errors.New("database schema is behind")
+	ErrModified     = errors.New("applied migration has been modified")
+	ErrUnknown      = errors.New("applied migration is unknown to this build")
+)
+
+type Migration struct {
+	Version  uint64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// Status describes a migration known to the build or recorded in the database
+type Status struct {
+	Version   uint64
+	Name      string
+	AppliedAt *time.Time
+	// Modified is set when the up file changed after it was applied
+	Modified bool
+	// Unknown is set when the database has a version this build lacks
+	Unknown bool
+}
+
+type appliedMigration struct {
+	Version   uint64    `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"not null;size:255"`
+	Checksum  string    `gorm:"not null;size:64"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (appliedMigration) TableName() string {
+	return "schema_migrations"
+}
+
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New returns a migrator for the embedded migrations of the database dialect
+func New(db *gorm.DB) (*Migrator, error) {
+	dialect := db.Dialector.Name()
+	dir, err := fs.Sub(embedded, path.Join("sql", dialect))
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(migrations) == 0 {
+		return nil, fmt.Errorf("no migrations for %s", dialect)
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Run applies the startup mode: "up" applies pending migrations, "check"
+// fails when any are pending and "off" does nothing.
+func Run(ctx context.Context, db *gorm.DB, mode string) error {
+	if mode == ModeOff {
+		return nil
+	}
+
+	m, err := New(db)
+	if err != nil {
+		return err
+	}
+
+	switch mode {
+	case ModeUp:
+		applied, err := m.Up(ctx, 0)
+		for _, migration := range applied {
+			logrus.WithField("version", migration.Version).WithField("name", migration.Name).Info("Applied migration")
+		}
+		return err
+	case ModeCheck:
+		return m.Check(ctx)
+	default:
+		return fmt.Errorf("unknown migration mode %q", mode)
+	}
+}
+
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Load reads migration pairs from dir, ordered by version
+func Load(dir fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(dir, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration version in %q", entry.Name())
+		}
+
+		content, err := fs.ReadFile(dir, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by %q and %q", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+			sum := sha256.Sum256(content)
+			migration.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Checksum == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Up applies pending migrations in order, at most steps of them when steps
+// is positive. It returns the migrations that were applied.
+func (m *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func() error {
+		db := m.db.WithContext(ctx)
+		if !db.Migrator().HasTable(&appliedMigration{}) {
+			if err := db.Migrator().CreateTable(&appliedMigration{}); err != nil {
+				return fmt.Errorf("failed to create schema_migrations: %w", err)
+			}
+		}
+
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(applied); err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if steps > 0 && len(done) == steps {
+				break
+			}
+			record := appliedMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				Checksum:  migration.Checksum,
+				AppliedAt: time.Now(),
+			}
+			err := m.apply(ctx, migration.Up, func(db *gorm.DB) error {
+				if err := db.Create(&record).Error; err != nil {
+					return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+				}
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down rolls back the last steps applied migrations, newest first
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func() error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(applied); err != nil {
+			return err
+		}
+
+		// Rolling back around a newer build's migrations would leave gaps
+		known := make(map[uint64]bool, len(m.migrations))
+		for _, migration := range m.migrations {
+			known[migration.Version] = true
+		}
+		for version := range applied {
+			if !known[version] {
+				return fmt.Errorf("%w: %d", ErrUnknown, version)
+			}
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if strings.TrimSpace(migration.Down) == "" {
+				return fmt.Errorf("migration %d_%s cannot be rolled back", migration.Version, migration.Name)
+			}
+			err := m.apply(ctx, migration.Down, func(db *gorm.DB) error {
+				if err := db.Delete(&appliedMigration{}, migration.Version).Error; err != nil {
+					return fmt.Errorf("failed to record rollback of %d: %w", migration.Version, err)
+				}
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("rollback of %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status lists known and applied migrations by version
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if record, ok := applied[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.AppliedAt = &appliedAt
+			status.Modified = record.Checksum != migration.Checksum
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, record := range applied {
+		appliedAt := record.AppliedAt
+		statuses = append(statuses, Status{Version: record.Version, Name: record.Name, AppliedAt: &appliedAt, Unknown: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// Check returns ErrSchemaBehind when migrations are pending, or an error when
+// applied migrations were modified. Versions unknown to this build are
+// tolerated so that an older build keeps running during a rollout.
+func (m *Migrator) Check(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, status := range statuses {
+		switch {
+		case status.Modified:
+			return fmt.Errorf("%w: %d_%s", ErrModified, status.Version, status.Name)
+		case status.Unknown:
+			logrus.WithField("version", status.Version).Warn("Database has a migration unknown to this build")
+		case status.AppliedAt == nil:
+			pending = append(pending, fmt.Sprintf("%d_%s", status.Version, status.Name))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: pending %s", ErrSchemaBehind, strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// verify refuses to migrate over modified migrations. Unknown versions are
+// left alone since a newer build applied them.
+func (m *Migrator) verify(applied map[uint64]appliedMigration) error {
+	for _, migration := range m.migrations {
+		if record, ok := applied[migration.Version]; ok && record.Checksum != migration.Checksum {
+			return fmt.Errorf("%w: %d_%s", ErrModified, migration.Version, migration.Name)
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[uint64]appliedMigration, error) {
+	db := m.db.WithContext(ctx)
+	if !db.Migrator().HasTable(&appliedMigration{}) {
+		return map[uint64]appliedMigration{}, nil
+	}
+
+	var records []appliedMigration
+	if err := db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[uint64]appliedMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// apply runs the statements of a migration file one by one, since drivers
+// do not generally accept several statements per call, then record updates
+// schema_migrations. On Postgres and SQLite both happen in one transaction,
+// so a failed migration leaves neither the schema nor schema_migrations
+// changed. DDL is not transactional in MySQL, where a failed migration may
+// be partially applied.
+func (m *Migrator) apply(ctx context.Context, script string, record func(db *gorm.DB) error) error {
+	run := func(db *gorm.DB) error {
+		for _, statement := range splitStatements(script) {
+			if err := db.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return record(db)
+	}
+
+	db := m.db.WithContext(ctx)
+	if m.db.Dialector.Name() == dialect.MySQL {
+		return run(db)
+	}
+	return db.Transaction(run)
+}
+
+// withLock holds a database lock while fn runs, on a connection of its own
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	unlock, err := acquireLock(ctx, conn, m.db.Dialector.Name())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return fn()
+}
+
+func acquireLock(ctx context.Context, conn *sql.Conn, name string) (func(), error) {
+	switch name {
+	case dialect.MySQL:
+		var acquired sql.NullInt64
+		err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, int(lockTimeout.Seconds())).Scan(&acquired)
+		if err != nil {
+			return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		if acquired.Int64 != 1 {
+			return nil, fmt.Errorf("timed out waiting for migration lock")
+		}
+		return func() {
+			if _, err := conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", lockName); err != nil {
+				logrus.WithError(err).Warn("Failed to release migration lock")
+			}
+		}, nil
+	case dialect.Postgres:
+		// Advisory locks take a number; hashtext turns the name into one
+		lockCtx, cancel := context.WithTimeout(ctx, lockTimeout)
+		defer cancel()
+		if _, err := conn.ExecContext(lockCtx, "SELECT pg_advisory_lock(hashtext($1))", lockName); err != nil {
+			if errors.Is(lockCtx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("timed out waiting for migration lock")
+			}
+			return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		return func() {
+			if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock(hashtext($1))", lockName); err != nil {
+				logrus.WithError(err).Warn("Failed to release migration lock")
+			}
+		}, nil
+	case dialect.SQLite:
+		// SQLite has no named locks. Its database is a file on one host,
+		// and a version applied twice by concurrent runs fails on the
+		// schema_migrations primary key.
+		return func() {}, nil
+	default:
+		return nil, fmt.Errorf("migration lock is not supported for %s", name)
+	}
+}
+
+// splitStatements splits a script on semicolons outside of quotes and
+// comments. Comments are dropped.
+func splitStatements(script string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+		quote      byte
 	)
+
+	flush := func() {
+		if statement := strings.TrimSpace(current.String()); statement != "" {
+			statements = append(statements, statement)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case quote != 0:
+			current.WriteByte(c)
+			if c == '\\' && quote != '`' && i+1 < len(script) {
+				i++
+				current.WriteByte(script[i])
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			current.WriteByte(c)
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			if end := strings.IndexByte(script[i:], '\n'); end >= 0 {
+				i += end
+				current.WriteByte('\n')
+			} else {
+				i = len(script)
+			}
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			if end := strings.Index(script[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(script)
+			}
+			current.WriteByte(' ')
+		case c == ';':
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+	return statements
 }
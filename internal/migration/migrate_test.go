@@ -0,0 +1,231 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/SahandMohammed/wallet-service/internal/db"
+	"github.com/SahandMohammed/wallet-service/internal/dialect"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	var reference []Migration
+	for _, name := range dialect.All {
+		sub, err := fs.Sub(embedded, path.Join("sql", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		migrations, err := Load(sub)
+		if err != nil {
+			t.Fatalf("%s: Load: %v", name, err)
+		}
+		if len(migrations) == 0 {
+			t.Fatalf("%s: expected embedded migrations", name)
+		}
+		for i, migration := range migrations {
+			if migration.Version != uint64(i+1) {
+				t.Errorf("%s: expected version %d, got %d_%s", name, i+1, migration.Version, migration.Name)
+			}
+			if strings.TrimSpace(migration.Down) == "" {
+				t.Errorf("%s: migration %d_%s has no down file", name, migration.Version, migration.Name)
+			}
+		}
+
+		// Every dialect has the same versions, so that schema_migrations
+		// reads the same whatever the database
+		if reference == nil {
+			reference = migrations
+			continue
+		}
+		if len(migrations) != len(reference) {
+			t.Errorf("%s has %d migrations, %s has %d", name, len(migrations), dialect.All[0], len(reference))
+			continue
+		}
+		for i, migration := range migrations {
+			if migration.Name != reference[i].Name {
+				t.Errorf("%s: migration %d is %s, expected %s", name, migration.Version, migration.Name, reference[i].Name)
+			}
+		}
+	}
+}
+
+func openSQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+	gormDB, err := gorm.Open(sqlite.Open(db.SQLiteDSN(filepath.Join(t.TempDir(), "wallet.db"))), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gormDB
+}
+
+func TestSQLite(t *testing.T) {
+	gormDB := openSQLite(t)
+	m, err := New(gormDB)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := m.Check(ctx); !errors.Is(err, ErrSchemaBehind) {
+		t.Fatalf("expected ErrSchemaBehind before migrating, got %v", err)
+	}
+	applied, err := m.Up(ctx, 0)
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if len(applied) != len(m.migrations) {
+		t.Errorf("expected %d migrations applied, got %d", len(m.migrations), len(applied))
+	}
+	if err := m.Check(ctx); err != nil {
+		t.Errorf("Check after Up: %v", err)
+	}
+	if !gormDB.Migrator().HasColumn("wallets", "frozen_at") {
+		t.Error("expected wallets.frozen_at")
+	}
+
+	rolledBack, err := m.Down(ctx, len(m.migrations))
+	if err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	if len(rolledBack) != len(m.migrations) || gormDB.Migrator().HasTable("wallets") {
+		t.Errorf("expected every migration rolled back, got %d", len(rolledBack))
+	}
+	if _, err := m.Up(ctx, 0); err != nil {
+		t.Errorf("Up after Down: %v", err)
+	}
+}
+
+func TestFailedMigrationIsRolledBack(t *testing.T) {
+	gormDB := openSQLite(t)
+	migrations, err := Load(fstest.MapFS{
+		"000001_create_a.up.sql":   {Data: []byte("CREATE TABLE a (id INTEGER);")},
+		"000001_create_a.down.sql": {Data: []byte("DROP TABLE a;\nDROP TABLE missing;")},
+		"000002_create_b.up.sql":   {Data: []byte("CREATE TABLE b (id INTEGER);\nINSERT INTO missing VALUES (1);")},
+		"000002_create_b.down.sql": {Data: []byte("DROP TABLE b;")},
+	})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	m := &Migrator{db: gormDB, migrations: migrations}
+	ctx := context.Background()
+
+	applied, err := m.Up(ctx, 0)
+	if err == nil || !strings.Contains(err.Error(), "migration 2_create_b failed") {
+		t.Fatalf("expected migration 2 to fail, got %v", err)
+	}
+	if len(applied) != 1 || applied[0].Version != 1 {
+		t.Errorf("expected only migration 1 applied, got %+v", applied)
+	}
+	if gormDB.Migrator().HasTable("b") {
+		t.Error("the first statement of the failed migration was not rolled back")
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if statuses[0].AppliedAt == nil || statuses[1].AppliedAt != nil {
+		t.Errorf("expected only version 1 recorded, got %+v", statuses)
+	}
+
+	// A failed rollback keeps both the table and its record
+	if _, err := m.Down(ctx, 1); err == nil {
+		t.Fatal("expected the rollback of migration 1 to fail")
+	}
+	if !gormDB.Migrator().HasTable("a") {
+		t.Error("the first statement of the failed rollback was not rolled back")
+	}
+	if err := m.Check(ctx); !errors.Is(err, ErrSchemaBehind) || !strings.Contains(err.Error(), "pending 2_create_b") {
+		t.Errorf("expected only migration 2 pending, got %v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	files := fstest.MapFS{
+		"000002_add_index.up.sql":   {Data: []byte("CREATE INDEX a ON t (a);")},
+		"000002_add_index.down.sql": {Data: []byte("DROP INDEX a ON t;")},
+		"000001_create_t.up.sql":    {Data: []byte("CREATE TABLE t (a INT);")},
+		"000001_create_t.down.sql":  {Data: []byte("DROP TABLE t;")},
+	}
+	migrations, err := Load(files)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(migrations) != 2 || migrations[0].Name != "create_t" || migrations[1].Name != "add_index" {
+		t.Fatalf("unexpected migrations %+v", migrations)
+	}
+	if migrations[0].Checksum == "" || migrations[0].Checksum == migrations[1].Checksum {
+		t.Errorf("expected distinct checksums, got %q and %q", migrations[0].Checksum, migrations[1].Checksum)
+	}
+
+	invalid := map[string]fstest.MapFS{
+		"bad name":        {"create_t.up.sql": {}},
+		"missing up":      {"000001_create_t.down.sql": {}},
+		"version reused":  {"000001_a.up.sql": {}, "000001_b.up.sql": {}},
+		"zero version":    {"000000_a.up.sql": {}},
+		"wrong extension": {"000001_a.up.txt": {}},
+	}
+	for name, files := range invalid {
+		if _, err := Load(files); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- leading comment; with a semicolon
+CREATE TABLE t (a VARCHAR(10) DEFAULT 'x;y');
+/* block; comment */ INSERT INTO t VALUES ('it''s'), ("a\";b");
+
+UPDATE t SET ` + "`a`" + ` = 'z' -- trailing
+`
+	want := []string{
+		"CREATE TABLE t (a VARCHAR(10) DEFAULT 'x;y')",
+		`INSERT INTO t VALUES ('it''s'), ("a\";b")`,
+		"UPDATE t SET `a` = 'z'",
+	}
+	if got := splitStatements(script); !reflect.DeepEqual(got, want) {
+		t.Errorf("splitStatements:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+
+	up, down, err := Create(dir, "Add Wallet Status")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if path.Base(up) != "000001_add_wallet_status.up.sql" || path.Base(down) != "000001_add_wallet_status.down.sql" {
+		t.Errorf("unexpected files %s, %s", up, down)
+	}
+
+	up, _, err = Create(dir, "backfill")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if path.Base(up) != "000002_backfill.up.sql" {
+		t.Errorf("expected the next version, got %s", up)
+	}
+
+	migrations, err := Load(os.DirFS(dir))
+	if err != nil || len(migrations) != 2 {
+		t.Errorf("expected the created files to load, got %v, %v", migrations, err)
+	}
+
+	if _, _, err := Create(dir, "--"); err == nil {
+		t.Error("expected an error for an empty name")
+	}
+}
@@ -0,0 +1,170 @@
+-- Tables as previously created by GORM AutoMigrate. IF NOT EXISTS lets
+-- databases created that way adopt versioned migrations.
+
+CREATE TABLE IF NOT EXISTS users (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    username VARCHAR(50) NOT NULL,
+    password LONGTEXT NOT NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    deleted_at DATETIME(3) NULL,
+    password_changed_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_users_username (username),
+    INDEX idx_users_deleted_at (deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS wallets (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    user_id BIGINT UNSIGNED NOT NULL,
+    balance BIGINT NOT NULL DEFAULT 0,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    deleted_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    INDEX idx_wallets_user_id (user_id),
+    INDEX idx_wallets_deleted_at (deleted_at),
+    CONSTRAINT fk_users_wallets FOREIGN KEY (user_id) REFERENCES users (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS transactions (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    wallet_id BIGINT UNSIGNED NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    amount BIGINT NOT NULL,
+    balance_before BIGINT NOT NULL,
+    balance_after BIGINT NOT NULL,
+    from_wallet_id BIGINT UNSIGNED NULL,
+    to_wallet_id BIGINT UNSIGNED NULL,
+    transaction_uuid VARCHAR(36) NOT NULL,
+    description VARCHAR(255) NULL,
+    created_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    INDEX idx_transactions_wallet_id (wallet_id),
+    INDEX idx_transactions_from_wallet_id (from_wallet_id),
+    INDEX idx_transactions_to_wallet_id (to_wallet_id),
+    UNIQUE INDEX idx_transactions_transaction_uuid (transaction_uuid),
+    CONSTRAINT fk_wallets_transactions FOREIGN KEY (wallet_id) REFERENCES wallets (id),
+    CONSTRAINT fk_transactions_from_wallet FOREIGN KEY (from_wallet_id) REFERENCES wallets (id),
+    CONSTRAINT fk_transactions_to_wallet FOREIGN KEY (to_wallet_id) REFERENCES wallets (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    user_id BIGINT UNSIGNED NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    expires_at DATETIME(3) NOT NULL,
+    used_at DATETIME(3) NULL,
+    created_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    INDEX idx_password_reset_tokens_user_id (user_id),
+    UNIQUE INDEX idx_password_reset_tokens_token_hash (token_hash)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    user_id BIGINT UNSIGNED NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL,
+    scopes TEXT NULL,
+    wallet_ids TEXT NULL,
+    expires_at DATETIME(3) NULL,
+    revoked_at DATETIME(3) NULL,
+    last_used_at DATETIME(3) NULL,
+    created_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    INDEX idx_api_keys_user_id (user_id),
+    UNIQUE INDEX idx_api_keys_prefix (prefix),
+    CONSTRAINT fk_api_keys_user FOREIGN KEY (user_id) REFERENCES users (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id VARCHAR(36) NOT NULL,
+    user_id BIGINT UNSIGNED NOT NULL,
+    user_agent VARCHAR(255) NULL,
+    ip_address VARCHAR(45) NULL,
+    device_hash VARCHAR(64) NULL,
+    created_at DATETIME(3) NULL,
+    last_seen_at DATETIME(3) NULL,
+    expires_at DATETIME(3) NOT NULL,
+    revoked_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    INDEX idx_sessions_user_id (user_id),
+    INDEX idx_sessions_device_hash (device_hash)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    actor_id BIGINT UNSIGNED NULL,
+    actor_name VARCHAR(50) NULL,
+    action VARCHAR(64) NOT NULL,
+    target_type VARCHAR(32) NULL,
+    target_id VARCHAR(64) NULL,
+    `before` TEXT NULL,
+    `after` TEXT NULL,
+    ip_address VARCHAR(45) NULL,
+    user_agent VARCHAR(255) NULL,
+    request_id VARCHAR(64) NULL,
+    created_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    INDEX idx_audit_logs_actor_id (actor_id),
+    INDEX idx_audit_logs_action (action),
+    INDEX idx_audit_target (target_type, target_id),
+    INDEX idx_audit_logs_request_id (request_id),
+    INDEX idx_audit_logs_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    event_id VARCHAR(36) NOT NULL,
+    type VARCHAR(64) NOT NULL,
+    aggregate_type VARCHAR(32) NOT NULL,
+    aggregate_id BIGINT UNSIGNED NOT NULL,
+    payload TEXT NOT NULL,
+    created_at DATETIME(3) NULL,
+    published_at DATETIME(3) NULL,
+    attempts BIGINT NOT NULL DEFAULT 0,
+    last_error VARCHAR(255) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_outbox_events_event_id (event_id),
+    INDEX idx_outbox_events_aggregate_id (aggregate_id),
+    INDEX idx_outbox_events_published_at (published_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    user_id BIGINT UNSIGNED NOT NULL,
+    wallet_id BIGINT UNSIGNED NULL,
+    url VARCHAR(2048) NOT NULL,
+    secret VARCHAR(64) NOT NULL,
+    event_types TEXT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    deleted_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    INDEX idx_webhook_endpoints_user_id (user_id),
+    INDEX idx_webhook_endpoints_wallet_id (wallet_id),
+    INDEX idx_webhook_endpoints_deleted_at (deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    endpoint_id BIGINT UNSIGNED NOT NULL,
+    event_id VARCHAR(36) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    attempts BIGINT NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME(3) NULL,
+    last_status_code BIGINT NULL,
+    last_error VARCHAR(255) NULL,
+    delivered_at DATETIME(3) NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_webhook_delivery_event (endpoint_id, event_id),
+    INDEX idx_webhook_delivery_due (status, next_attempt_at),
+    CONSTRAINT fk_webhook_deliveries_endpoint FOREIGN KEY (endpoint_id) REFERENCES webhook_endpoints (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
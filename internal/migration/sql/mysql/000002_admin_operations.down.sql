@@ -0,0 +1,5 @@
+ALTER TABLE wallets
+    DROP COLUMN frozen_reason,
+    DROP COLUMN frozen_at;
+
+ALTER TABLE users DROP COLUMN is_admin;
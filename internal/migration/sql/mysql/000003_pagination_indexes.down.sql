@@ -0,0 +1,3 @@
+DROP INDEX idx_users_created ON users;
+DROP INDEX idx_transactions_created ON transactions;
+DROP INDEX idx_transactions_wallet_created ON transactions;
@@ -0,0 +1,4 @@
+-- Cursor pagination walks created_at and id, newest first
+CREATE INDEX idx_transactions_wallet_created ON transactions (wallet_id, created_at, id);
+CREATE INDEX idx_transactions_created ON transactions (created_at, id);
+CREATE INDEX idx_users_created ON users (created_at, id);
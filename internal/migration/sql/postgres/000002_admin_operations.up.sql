@@ -0,0 +1,5 @@
+ALTER TABLE users ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE wallets
+    ADD COLUMN frozen_at TIMESTAMPTZ NULL,
+    ADD COLUMN frozen_reason VARCHAR(255) NULL;
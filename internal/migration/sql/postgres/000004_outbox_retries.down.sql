@@ -0,0 +1,3 @@
+ALTER TABLE outbox_events
+    DROP COLUMN parked_at,
+    DROP COLUMN next_attempt_at;
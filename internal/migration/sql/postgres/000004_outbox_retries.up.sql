@@ -0,0 +1,4 @@
+-- Failed events are retried after a backoff and parked after too many attempts
+ALTER TABLE outbox_events
+    ADD COLUMN next_attempt_at TIMESTAMPTZ NULL,
+    ADD COLUMN parked_at TIMESTAMPTZ NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE wallets DROP COLUMN frozen_reason;
+ALTER TABLE wallets DROP COLUMN frozen_at;
+
+ALTER TABLE users DROP COLUMN is_admin;
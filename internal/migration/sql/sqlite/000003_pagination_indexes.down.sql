@@ -0,0 +1,3 @@
+DROP INDEX idx_users_created;
+DROP INDEX idx_transactions_created;
+DROP INDEX idx_transactions_wallet_created;
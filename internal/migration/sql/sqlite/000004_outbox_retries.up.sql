@@ -0,0 +1,3 @@
+-- Failed events are retried after a backoff and parked after too many attempts
+ALTER TABLE outbox_events ADD COLUMN next_attempt_at DATETIME NULL;
+ALTER TABLE outbox_events ADD COLUMN parked_at DATETIME NULL;
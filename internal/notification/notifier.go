@@ -0,0 +1,103 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type Kind string
+
+const (
+	KindPasswordReset  Kind = "password_reset"
+	KindNewDeviceLogin Kind = "new_device_login"
+)
+
+// Message is a user-facing notification. Data carries machine-readable values
+// such as tokens so that tests can pick them up from a FileNotifier.
+type Message struct {
+	Kind      Kind              `json:"kind"`
+	UserID    uint              `json:"user_id"`
+	Username  string            `json:"username"`
+	Subject   string            `json:"subject"`
+	Body      string            `json:"body"`
+	Data      map[string]string `json:"data,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+type logNotifier struct{}
+
+// NewLogNotifier returns a notifier that writes messages to the application
+// log. It is meant for development only since secrets end up in the log.
+func NewLogNotifier() Notifier {
+	return &logNotifier{}
+}
+
+func (n *logNotifier) Send(ctx context.Context, msg Message) error {
+	logrus.WithFields(logrus.Fields{
+		"kind":     msg.Kind,
+		"user_id":  msg.UserID,
+		"username": msg.Username,
+		"subject":  msg.Subject,
+		"data":     msg.Data,
+	}).Info(msg.Body)
+	return nil
+}
+
+type fileNotifier struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileNotifier returns a notifier that appends each message as a JSON line
+// to the given file.
+func NewFileNotifier(path string) Notifier {
+	return &fileNotifier{path: path}
+}
+
+func (n *fileNotifier) Send(ctx context.Context, msg Message) error {
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now().UTC()
+	}
+
+	line, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open notification file: %w", err)
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// New builds the notifier selected by configuration.
+func New(notifierType, filePath string) (Notifier, error) {
+	switch notifierType {
+	case "", "log":
+		return NewLogNotifier(), nil
+	case "file":
+		if filePath == "" {
+			return nil, fmt.Errorf("NOTIFIER_FILE is required for the file notifier")
+		}
+		return NewFileNotifier(filePath), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier type %q", notifierType)
+	}
+}
@@ -0,0 +1,298 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/events"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	UpdateBalance     = "balance"
+	UpdateTransaction = "transaction"
+)
+
+// Update is a change to a single wallet as pushed to clients. ID is the
+// position in the wallet's history and is used as the SSE event id, so that
+// clients can resume with Last-Event-ID. Amounts are in dollars, like the
+// rest of the API.
+type Update struct {
+	ID              string    `json:"-"`
+	Kind            string    `json:"-"`
+	EventID         string    `json:"event_id"`
+	EventType       string    `json:"event_type"`
+	WalletID        uint      `json:"wallet_id"`
+	Balance         float64   `json:"balance"`
+	Amount          float64   `json:"amount,omitempty"`
+	TransactionUUID string    `json:"transaction_uuid,omitempty"`
+	Description     string    `json:"description,omitempty"`
+	OccurredAt      time.Time `json:"occurred_at"`
+}
+
+type message struct {
+	ID     string `json:"id"`
+	Kind   string `json:"kind"`
+	Update Update `json:"update"`
+}
+
+// Broker fans wallet updates out to every server instance. Updates are kept
+// in a capped per-wallet Redis stream for resuming, and announced on a
+// per-wallet pub/sub channel for live delivery.
+type Broker struct {
+	client      *redis.Client
+	historySize int64
+}
+
+func NewBroker(client *redis.Client, historySize int64) *Broker {
+	return &Broker{
+		client:      client,
+		historySize: historySize,
+	}
+}
+
+// Publish implements events.Publisher so the broker can be fed by the outbox
+// relay. Relayed events may repeat; clients deduplicate on event_id.
+func (b *Broker) Publish(ctx context.Context, envelope events.Envelope) error {
+	updates, err := updatesFor(envelope)
+	if err != nil {
+		return err
+	}
+
+	for _, update := range updates {
+		data, err := json.Marshal(update)
+		if err != nil {
+			return err
+		}
+
+		id, err := b.client.XAdd(ctx, &redis.XAddArgs{
+			Stream: historyKey(update.WalletID),
+			MaxLen: b.historySize,
+			Approx: true,
+			Values: map[string]interface{}{
+				"kind": update.Kind,
+				"data": string(data),
+			},
+		}).Result()
+		if err != nil {
+			return err
+		}
+
+		update.ID = id
+		payload, err := json.Marshal(message{ID: id, Kind: update.Kind, Update: update})
+		if err != nil {
+			return err
+		}
+		if err := b.client.Publish(ctx, channelKey(update.WalletID), payload).Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Subscribe streams updates for a wallet until ctx is cancelled. When
+// lastEventID is set, updates after it are replayed from history first. The
+// channel is closed when the subscription ends.
+func (b *Broker) Subscribe(ctx context.Context, walletID uint, lastEventID string) (<-chan Update, error) {
+	// Subscribe before reading history so nothing falls in between
+	pubsub := b.client.Subscribe(ctx, channelKey(walletID))
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	var history []Update
+	if lastEventID != "" {
+		entries, err := b.client.XRange(ctx, historyKey(walletID), "("+lastEventID, "+").Result()
+		if err != nil {
+			pubsub.Close()
+			return nil, err
+		}
+		for _, entry := range entries {
+			update, err := updateFromEntry(entry)
+			if err != nil {
+				logrus.WithError(err).WithField("entry_id", entry.ID).Warn("Skipping malformed wallet stream entry")
+				continue
+			}
+			history = append(history, update)
+		}
+	}
+
+	updates := make(chan Update, 16)
+	go func() {
+		defer close(updates)
+		defer pubsub.Close()
+
+		last := lastEventID
+		send := func(update Update) bool {
+			if last != "" && !idAfter(update.ID, last) {
+				return true
+			}
+			select {
+			case updates <- update:
+				last = update.ID
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for _, update := range history {
+			if !send(update) {
+				return
+			}
+		}
+
+		live := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-live:
+				if !ok {
+					return
+				}
+				var m message
+				if err := json.Unmarshal([]byte(msg.Payload), &m); err != nil {
+					continue
+				}
+				m.Update.ID = m.ID
+				m.Update.Kind = m.Kind
+				if !send(m.Update) {
+					return
+				}
+			}
+		}
+	}()
+
+	return updates, nil
+}
+
+func updatesFor(envelope events.Envelope) ([]Update, error) {
+	base := Update{
+		EventID:    envelope.EventID,
+		EventType:  envelope.Type,
+		OccurredAt: envelope.OccurredAt,
+	}
+
+	switch envelope.Type {
+	case events.TypeWalletCreated:
+		var payload events.WalletCreated
+		if err := json.Unmarshal(envelope.Payload, &payload); err != nil {
+			return nil, err
+		}
+		update := base
+		update.Kind = UpdateBalance
+		update.WalletID = payload.WalletID
+		return []Update{update}, nil
+
+	case events.TypeDepositCompleted:
+		var payload events.DepositCompleted
+		if err := json.Unmarshal(envelope.Payload, &payload); err != nil {
+			return nil, err
+		}
+		update := base
+		update.Kind = UpdateTransaction
+		update.WalletID = payload.WalletID
+		update.Balance = domain.MinorUnitsToDollars(payload.BalanceAfter)
+		update.Amount = domain.MinorUnitsToDollars(payload.Amount)
+		update.TransactionUUID = payload.TransactionUUID
+		update.Description = payload.Description
+		return []Update{update}, nil
+
+	case events.TypeBalanceAdjusted:
+		var payload events.BalanceAdjusted
+		if err := json.Unmarshal(envelope.Payload, &payload); err != nil {
+			return nil, err
+		}
+		update := base
+		update.Kind = UpdateTransaction
+		update.WalletID = payload.WalletID
+		update.Balance = domain.MinorUnitsToDollars(payload.BalanceAfter)
+		update.Amount = domain.MinorUnitsToDollars(payload.Amount)
+		update.TransactionUUID = payload.TransactionUUID
+		update.Description = payload.Reason
+		return []Update{update}, nil
+
+	case events.TypeTransferCompleted:
+		var payload events.TransferCompleted
+		if err := json.Unmarshal(envelope.Payload, &payload); err != nil {
+			return nil, err
+		}
+		from := base
+		from.Kind = UpdateTransaction
+		from.WalletID = payload.FromWalletID
+		from.Balance = domain.MinorUnitsToDollars(payload.FromBalanceAfter)
+		from.Amount = -domain.MinorUnitsToDollars(payload.Amount)
+		from.TransactionUUID = payload.FromTransactionUUID
+		from.Description = payload.Description
+
+		to := base
+		to.Kind = UpdateTransaction
+		to.WalletID = payload.ToWalletID
+		to.Balance = domain.MinorUnitsToDollars(payload.ToBalanceAfter)
+		to.Amount = domain.MinorUnitsToDollars(payload.Amount)
+		to.TransactionUUID = payload.ToTransactionUUID
+		to.Description = payload.Description
+		return []Update{from, to}, nil
+
+	default:
+		return nil, nil
+	}
+}
+
+func updateFromEntry(entry redis.XMessage) (Update, error) {
+	var update Update
+	data, _ := entry.Values["data"].(string)
+	if err := json.Unmarshal([]byte(data), &update); err != nil {
+		return update, err
+	}
+	update.ID = entry.ID
+	update.Kind, _ = entry.Values["kind"].(string)
+	return update, nil
+}
+
+// ValidEventID reports whether id looks like an update id
+func ValidEventID(id string) bool {
+	msPart, seqPart, ok := strings.Cut(id, "-")
+	if !ok {
+		return false
+	}
+	if _, err := strconv.ParseUint(msPart, 10, 64); err != nil {
+		return false
+	}
+	_, err := strconv.ParseUint(seqPart, 10, 64)
+	return err == nil
+}
+
+// idAfter compares Redis stream ids ("<ms>-<seq>")
+func idAfter(id, other string) bool {
+	idMs, idSeq := splitID(id)
+	otherMs, otherSeq := splitID(other)
+	if idMs != otherMs {
+		return idMs > otherMs
+	}
+	return idSeq > otherSeq
+}
+
+func splitID(id string) (uint64, uint64) {
+	msPart, seqPart, _ := strings.Cut(id, "-")
+	ms, _ := strconv.ParseUint(msPart, 10, 64)
+	seq, _ := strconv.ParseUint(seqPart, 10, 64)
+	return ms, seq
+}
+
+func historyKey(walletID uint) string {
+	return fmt.Sprintf("wallet:updates:%d", walletID)
+}
+
+func channelKey(walletID uint) string {
+	return fmt.Sprintf("wallet:updates:%d:live", walletID)
+}
@@ -0,0 +1,165 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/events"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestBrokers returns brokers on separate connections to one Redis, as
+// separate server instances would have
+func newTestBrokers(t *testing.T, n int) []*Broker {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	brokers := make([]*Broker, n)
+	for i := range brokers {
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { client.Close() })
+		brokers[i] = NewBroker(client, 100)
+	}
+	return brokers
+}
+
+func envelope(t *testing.T, eventType string, payload interface{}) events.Envelope {
+	t.Helper()
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events.Envelope{
+		EventID:    uuid.NewString(),
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		Payload:    data,
+	}
+}
+
+func deposit(t *testing.T, walletID uint, amount, balanceAfter int64) events.Envelope {
+	t.Helper()
+	return envelope(t, events.TypeDepositCompleted, events.DepositCompleted{
+		WalletID:        walletID,
+		Amount:          amount,
+		BalanceAfter:    balanceAfter,
+		TransactionUUID: uuid.NewString(),
+	})
+}
+
+func subscribe(t *testing.T, b *Broker, walletID uint, lastEventID string) <-chan Update {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	updates, err := b.Subscribe(ctx, walletID, lastEventID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return updates
+}
+
+func receive(t *testing.T, updates <-chan Update) Update {
+	t.Helper()
+	select {
+	case update, ok := <-updates:
+		if !ok {
+			t.Fatal("subscription ended")
+		}
+		return update
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for an update")
+	}
+	return Update{}
+}
+
+func expectNothing(t *testing.T, updates <-chan Update) {
+	t.Helper()
+	select {
+	case update := <-updates:
+		t.Fatalf("unexpected update %+v", update)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestBrokerFansOut(t *testing.T) {
+	brokers := newTestBrokers(t, 2)
+	ctx := context.Background()
+
+	fromOther := subscribe(t, brokers[1], 1, "")
+	toSame := subscribe(t, brokers[0], 2, "")
+	unrelated := subscribe(t, brokers[1], 3, "")
+
+	transfer := envelope(t, events.TypeTransferCompleted, events.TransferCompleted{
+		FromWalletID:     1,
+		ToWalletID:       2,
+		Amount:           250,
+		FromBalanceAfter: 750,
+		ToBalanceAfter:   1250,
+	})
+	if err := brokers[0].Publish(ctx, transfer); err != nil {
+		t.Fatal(err)
+	}
+
+	from := receive(t, fromOther)
+	if from.WalletID != 1 || from.Amount != -2.5 || from.Balance != 7.5 || from.Kind != UpdateTransaction || from.EventID != transfer.EventID {
+		t.Errorf("unexpected update for the source wallet: %+v", from)
+	}
+	to := receive(t, toSame)
+	if to.WalletID != 2 || to.Amount != 2.5 || to.Balance != 12.5 {
+		t.Errorf("unexpected update for the destination wallet: %+v", to)
+	}
+	if from.ID == "" || !ValidEventID(from.ID) {
+		t.Errorf("expected a stream id, got %q", from.ID)
+	}
+	expectNothing(t, unrelated)
+}
+
+func TestBrokerReplaysAfterLastEventID(t *testing.T) {
+	brokers := newTestBrokers(t, 2)
+	ctx := context.Background()
+
+	first := subscribe(t, brokers[0], 1, "")
+	for i := int64(1); i <= 3; i++ {
+		if err := brokers[0].Publish(ctx, deposit(t, 1, 100, i*100)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	seen := []Update{receive(t, first), receive(t, first), receive(t, first)}
+
+	// Resuming on another instance replays what came after the last seen
+	// update, then continues live without repeating anything
+	resumed := subscribe(t, brokers[1], 1, seen[0].ID)
+	for _, want := range seen[1:] {
+		if got := receive(t, resumed); got.ID != want.ID || got.Balance != want.Balance {
+			t.Fatalf("expected replay of %s, got %+v", want.ID, got)
+		}
+	}
+	if err := brokers[0].Publish(ctx, deposit(t, 1, 100, 400)); err != nil {
+		t.Fatal(err)
+	}
+	if got := receive(t, resumed); got.Balance != 4 || !idAfter(got.ID, seen[2].ID) {
+		t.Fatalf("expected the live update, got %+v", got)
+	}
+	expectNothing(t, resumed)
+
+	// Without Last-Event-ID only live updates arrive
+	fresh := subscribe(t, brokers[1], 1, "")
+	expectNothing(t, fresh)
+}
+
+func TestValidEventID(t *testing.T) {
+	for id, want := range map[string]bool{
+		"1700000000000-0": true,
+		"1700000000000-3": true,
+		"1700000000000":   false,
+		"abc-0":           false,
+		"":                false,
+	} {
+		if got := ValidEventID(id); got != want {
+			t.Errorf("ValidEventID(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
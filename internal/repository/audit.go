@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"gorm.io/gorm"
+)
+
+// AuditLogRepository deliberately has no update method, entries are only
+// ever appended and removed by the retention policy.
+type AuditLogRepository interface {
+	Create(ctx context.Context, entry *domain.AuditLog) error
+	List(ctx context.Context, filters AuditLogFilters) ([]*domain.AuditLog, error)
+	Page(ctx context.Context, filters AuditLogFilters, req PageRequest) (*Page[*domain.AuditLog], error)
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type AuditLogFilters struct {
+	ActorID    *uint
+	Action     string
+	TargetType string
+	TargetID   string
+	RequestID  string
+	StartDate  *time.Time
+	EndDate    *time.Time
+}
+
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditLogRepository(db *gorm.DB) AuditLogRepository {
+	return &auditLogRepository{db: db}
+}
+
+func (r *auditLogRepository) Create(ctx context.Context, entry *domain.AuditLog) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *auditLogRepository) List(ctx context.Context, filters AuditLogFilters) ([]*domain.AuditLog, error) {
+	var entries []*domain.AuditLog
+	err := r.filtered(ctx, filters).Order("created_at DESC, id DESC").Find(&entries).Error
+	return entries, err
+}
+
+// Page returns the page of matching entries selected by req
+func (r *auditLogRepository) Page(ctx context.Context, filters AuditLogFilters, req PageRequest) (*Page[*domain.AuditLog], error) {
+	return paginate(r.filtered(ctx, filters), "audit_logs", req, auditLogCursor)
+}
+
+func auditLogCursor(entry *domain.AuditLog) Cursor {
+	return Cursor{CreatedAt: entry.CreatedAt, ID: entry.ID}
+}
+
+func (r *auditLogRepository) filtered(ctx context.Context, filters AuditLogFilters) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&domain.AuditLog{})
+
+	if filters.ActorID != nil {
+		query = query.Where("actor_id = ?", *filters.ActorID)
+	}
+
+	if filters.Action != "" {
+		query = query.Where("action = ?", filters.Action)
+	}
+
+	if filters.TargetType != "" {
+		query = query.Where("target_type = ?", filters.TargetType)
+	}
+
+	if filters.TargetID != "" {
+		query = query.Where("target_id = ?", filters.TargetID)
+	}
+
+	if filters.RequestID != "" {
+		query = query.Where("request_id = ?", filters.RequestID)
+	}
+
+	if filters.StartDate != nil {
+		query = query.Where("created_at >= ?", *filters.StartDate)
+	}
+
+	if filters.EndDate != nil {
+		query = query.Where("created_at <= ?", *filters.EndDate)
+	}
+
+	return query
+}
+
+func (r *auditLogRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("created_at < ?", before).Delete(&domain.AuditLog{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/repository"
+)
+
+type auditLogRepository struct {
+	view view
+}
+
+func (r *auditLogRepository) Create(ctx context.Context, entry *domain.AuditLog) error {
+	defer r.view.lock()()
+	s := r.view.store
+
+	entry.ID = s.nextID()
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = s.now()
+	}
+	stored := *entry
+	put(r.view, s.auditLogs, stored.ID, &stored)
+	return nil
+}
+
+func (r *auditLogRepository) List(ctx context.Context, filters repository.AuditLogFilters) ([]*domain.AuditLog, error) {
+	defer r.view.lock()()
+
+	return r.newestFirst(filters), nil
+}
+
+// Page returns the page of matching entries selected by req
+func (r *auditLogRepository) Page(ctx context.Context, filters repository.AuditLogFilters, req repository.PageRequest) (*repository.Page[*domain.AuditLog], error) {
+	defer r.view.lock()()
+
+	return repository.PageOf(r.newestFirst(filters), req, func(entry *domain.AuditLog) repository.Cursor {
+		return repository.Cursor{CreatedAt: entry.CreatedAt, ID: entry.ID}
+	}), nil
+}
+
+func (r *auditLogRepository) newestFirst(filters repository.AuditLogFilters) []*domain.AuditLog {
+	var entries []*domain.AuditLog
+	for _, entry := range r.view.store.auditLogs {
+		switch {
+		case filters.ActorID != nil && (entry.ActorID == nil || *entry.ActorID != *filters.ActorID),
+			filters.Action != "" && entry.Action != filters.Action,
+			filters.TargetType != "" && entry.TargetType != filters.TargetType,
+			filters.TargetID != "" && entry.TargetID != filters.TargetID,
+			filters.RequestID != "" && entry.RequestID != filters.RequestID,
+			filters.StartDate != nil && entry.CreatedAt.Before(*filters.StartDate),
+			filters.EndDate != nil && entry.CreatedAt.After(*filters.EndDate):
+			continue
+		}
+		copied := *entry
+		entries = append(entries, &copied)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID > b.ID
+	})
+	return entries
+}
+
+func (r *auditLogRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	defer r.view.lock()()
+	s := r.view.store
+
+	var deleted int64
+	for id, entry := range s.auditLogs {
+		if entry.CreatedAt.Before(before) {
+			remove(r.view, s.auditLogs, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
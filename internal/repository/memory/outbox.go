@@ -0,0 +1,24 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+)
+
+type outboxRepository struct {
+	view view
+}
+
+func (r *outboxRepository) Create(ctx context.Context, event *domain.OutboxEvent) error {
+	defer r.view.lock()()
+	s := r.view.store
+
+	event.ID = s.nextID()
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = s.now()
+	}
+	stored := *event
+	put(r.view, s.outbox, stored.ID, &stored)
+	return nil
+}
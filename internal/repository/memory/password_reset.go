@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"gorm.io/gorm"
+)
+
+type passwordResetRepository struct {
+	view view
+}
+
+func (r *passwordResetRepository) Create(ctx context.Context, token *domain.PasswordResetToken) error {
+	defer r.view.lock()()
+	s := r.view.store
+
+	if _, ok := s.users[token.UserID]; !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+	for _, existing := range s.resetTokens {
+		if existing.TokenHash == token.TokenHash {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+
+	token.ID = s.nextID()
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = s.now()
+	}
+	stored := *token
+	put(r.view, s.resetTokens, stored.ID, &stored)
+	return nil
+}
+
+func (r *passwordResetRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.PasswordResetToken, error) {
+	defer r.view.lock()()
+
+	for _, token := range r.view.store.resetTokens {
+		if token.TokenHash == tokenHash {
+			copied := *token
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *passwordResetRepository) MarkUsed(ctx context.Context, id uint, usedAt time.Time) (bool, error) {
+	defer r.view.lock()()
+	s := r.view.store
+
+	token, ok := s.resetTokens[id]
+	if !ok || token.UsedAt != nil {
+		return false, nil
+	}
+	r.markUsed(token, usedAt)
+	return true, nil
+}
+
+func (r *passwordResetRepository) InvalidateForUser(ctx context.Context, userID uint, usedAt time.Time) error {
+	defer r.view.lock()()
+
+	for _, token := range r.view.store.resetTokens {
+		if token.UserID == userID && token.UsedAt == nil {
+			r.markUsed(token, usedAt)
+		}
+	}
+	return nil
+}
+
+func (r *passwordResetRepository) markUsed(token *domain.PasswordResetToken, usedAt time.Time) {
+	updated := *token
+	updated.UsedAt = &usedAt
+	put(r.view, r.view.store.resetTokens, updated.ID, &updated)
+}
@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"gorm.io/gorm"
+)
+
+type sessionRepository struct {
+	view view
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *domain.Session) error {
+	defer r.view.lock()()
+	s := r.view.store
+
+	if _, ok := s.users[session.UserID]; !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+	if _, ok := s.sessions[session.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+
+	now := s.now()
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = now
+	}
+	if session.LastSeenAt.IsZero() {
+		session.LastSeenAt = now
+	}
+	stored := *session
+	put(r.view, s.sessions, stored.ID, &stored)
+	return nil
+}
+
+func (r *sessionRepository) GetByID(ctx context.Context, id string) (*domain.Session, error) {
+	defer r.view.lock()()
+
+	session, ok := r.view.store.sessions[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *session
+	return &copied, nil
+}
+
+func (r *sessionRepository) GetActiveByUserID(ctx context.Context, userID uint, now time.Time) ([]*domain.Session, error) {
+	defer r.view.lock()()
+
+	var sessions []*domain.Session
+	for _, session := range r.view.store.sessions {
+		if session.UserID == userID && session.Active(now) {
+			copied := *session
+			sessions = append(sessions, &copied)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt) })
+	return sessions, nil
+}
+
+func (r *sessionRepository) CountByUserID(ctx context.Context, userID uint) (int64, error) {
+	defer r.view.lock()()
+
+	var count int64
+	for _, session := range r.view.store.sessions {
+		if session.UserID == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *sessionRepository) HasDevice(ctx context.Context, userID uint, deviceHash string) (bool, error) {
+	defer r.view.lock()()
+
+	for _, session := range r.view.store.sessions {
+		if session.UserID == userID && session.DeviceHash == deviceHash {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *sessionRepository) Revoke(ctx context.Context, id string, userID uint, revokedAt time.Time) (bool, error) {
+	defer r.view.lock()()
+
+	session, ok := r.view.store.sessions[id]
+	if !ok || session.UserID != userID || session.RevokedAt != nil {
+		return false, nil
+	}
+	r.update(session, func(session *domain.Session) { session.RevokedAt = &revokedAt })
+	return true, nil
+}
+
+// RevokeAllForUser revokes every open session of the user and returns their IDs
+func (r *sessionRepository) RevokeAllForUser(ctx context.Context, userID uint, revokedAt time.Time) ([]string, error) {
+	defer r.view.lock()()
+
+	var ids []string
+	for _, session := range r.view.store.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			ids = append(ids, session.ID)
+			r.update(session, func(session *domain.Session) { session.RevokedAt = &revokedAt })
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (r *sessionRepository) TouchLastSeen(ctx context.Context, id string, seenAt time.Time) error {
+	defer r.view.lock()()
+
+	if session, ok := r.view.store.sessions[id]; ok {
+		r.update(session, func(session *domain.Session) { session.LastSeenAt = seenAt })
+	}
+	return nil
+}
+
+// update stores a changed copy of the session
+func (r *sessionRepository) update(session *domain.Session, change func(session *domain.Session)) {
+	updated := *session
+	change(&updated)
+	put(r.view, r.view.store.sessions, updated.ID, &updated)
+}
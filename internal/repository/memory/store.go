@@ -0,0 +1,205 @@
+// Package memory implements the repositories in process memory, for tests
+// of the services that should not need a database. Records are copied in and
+// out, so callers never share state with the store.
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/repository"
+)
+
+// Store holds the records of every repository it hands out. A unit of work
+// holds the store lock until it finishes, so units of work run one at a
+// time and reads outside them see committed data only.
+type Store struct {
+	mu                sync.Mutex
+	users             map[uint]*domain.User
+	resetTokens       map[uint]*domain.PasswordResetToken
+	sessions          map[string]*domain.Session
+	wallets           map[uint]*domain.Wallet
+	transactions      map[uint]*domain.Transaction
+	outbox            map[uint]*domain.OutboxEvent
+	auditLogs         map[uint]*domain.AuditLog
+	webhookEndpoints  map[uint]*domain.WebhookEndpoint
+	webhookDeliveries map[uint]*domain.WebhookDelivery
+	lastID            uint
+	now               func() time.Time
+}
+
+func NewStore() *Store {
+	return &Store{
+		users:             make(map[uint]*domain.User),
+		resetTokens:       make(map[uint]*domain.PasswordResetToken),
+		sessions:          make(map[string]*domain.Session),
+		wallets:           make(map[uint]*domain.Wallet),
+		transactions:      make(map[uint]*domain.Transaction),
+		outbox:            make(map[uint]*domain.OutboxEvent),
+		auditLogs:         make(map[uint]*domain.AuditLog),
+		webhookEndpoints:  make(map[uint]*domain.WebhookEndpoint),
+		webhookDeliveries: make(map[uint]*domain.WebhookDelivery),
+		now:               time.Now,
+	}
+}
+
+func (s *Store) Users() repository.UserRepository { return &userRepository{view{store: s}} }
+
+func (s *Store) PasswordResets() repository.PasswordResetRepository {
+	return &passwordResetRepository{view{store: s}}
+}
+
+func (s *Store) Sessions() repository.SessionRepository { return &sessionRepository{view{store: s}} }
+
+func (s *Store) Wallets() repository.WalletRepository { return &walletRepository{view{store: s}} }
+
+func (s *Store) Transactions() repository.TransactionRepository {
+	return &transactionRepository{view{store: s}}
+}
+
+func (s *Store) Outbox() repository.OutboxRepository { return &outboxRepository{view{store: s}} }
+
+func (s *Store) AuditLogs() repository.AuditLogRepository {
+	return &auditLogRepository{view{store: s}}
+}
+
+func (s *Store) Webhooks() repository.WebhookRepository { return &webhookRepository{view{store: s}} }
+
+func (s *Store) UnitOfWork() repository.UnitOfWork { return unitOfWork{store: s} }
+
+// OutboxEvents returns the events written so far in id order
+func (s *Store) OutboxEvents() []*domain.OutboxEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := make([]*domain.OutboxEvent, 0, len(s.outbox))
+	for _, event := range s.outbox {
+		copied := *event
+		events = append(events, &copied)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	return events
+}
+
+// nextID hands out ids from one sequence shared by all tables. Like database
+// sequences, it does not roll back.
+func (s *Store) nextID() uint {
+	s.lastID++
+	return s.lastID
+}
+
+type unitOfWork struct {
+	store *Store
+}
+
+// Do runs fn with the store locked and undoes its writes when it fails.
+// Repositories outside tx must not be used from fn, they would wait for the
+// lock forever.
+func (u unitOfWork) Do(ctx context.Context, fn func(tx repository.Tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	u.store.mu.Lock()
+	defer u.store.mu.Unlock()
+
+	t := &tx{}
+	err := fn(txRepositories{view{store: u.store, tx: t}})
+	if err != nil {
+		for i := len(t.undo) - 1; i >= 0; i-- {
+			t.undo[i]()
+		}
+	}
+	return err
+}
+
+type tx struct {
+	undo []func()
+}
+
+type txRepositories struct {
+	view view
+}
+
+func (t txRepositories) Users() repository.UserRepository { return &userRepository{t.view} }
+
+func (t txRepositories) PasswordResets() repository.PasswordResetRepository {
+	return &passwordResetRepository{t.view}
+}
+
+func (t txRepositories) Wallets() repository.WalletRepository { return &walletRepository{t.view} }
+
+func (t txRepositories) Transactions() repository.TransactionRepository {
+	return &transactionRepository{t.view}
+}
+
+func (t txRepositories) Outbox() repository.OutboxRepository { return &outboxRepository{t.view} }
+
+func (t txRepositories) AuditLogs() repository.AuditLogRepository {
+	return &auditLogRepository{t.view}
+}
+
+// view is the store as seen by a repository, either on its own or inside a
+// unit of work that already holds the lock
+type view struct {
+	store *Store
+	tx    *tx
+}
+
+// lock takes the store lock unless the unit of work holds it, and returns
+// the function releasing it
+func (v view) lock() func() {
+	if v.tx != nil {
+		return func() {}
+	}
+	v.store.mu.Lock()
+	return v.store.mu.Unlock
+}
+
+// onRollback registers fn to undo a write if the unit of work fails
+func (v view) onRollback(fn func()) {
+	if v.tx != nil {
+		v.tx.undo = append(v.tx.undo, fn)
+	}
+}
+
+// put stores record under id, restoring the previous record on rollback
+func put[K comparable, T any](v view, records map[K]*T, id K, record *T) {
+	previous, existed := records[id]
+	records[id] = record
+	v.onRollback(func() {
+		if existed {
+			records[id] = previous
+		} else {
+			delete(records, id)
+		}
+	})
+}
+
+// remove deletes the record under id, restoring it on rollback
+func remove[K comparable, T any](v view, records map[K]*T, id K) {
+	previous, existed := records[id]
+	if !existed {
+		return
+	}
+	delete(records, id)
+	v.onRollback(func() { records[id] = previous })
+}
+
+// page applies a limit and offset the way the SQL repositories do, where
+// zero means no limit
+func page[T any](records []T, limit, offset int) []T {
+	if offset > 0 {
+		if offset >= len(records) {
+			return records[:0]
+		}
+		records = records[offset:]
+	}
+	if limit > 0 && limit < len(records) {
+		records = records[:limit]
+	}
+	return records
+}
@@ -0,0 +1,172 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/repository"
+	"gorm.io/gorm"
+)
+
+func TestUnitOfWork(t *testing.T) {
+	store := NewStore()
+	ctx := context.Background()
+
+	user := &domain.User{Username: "alice", Password: "x"}
+	if err := store.Users().Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Users().Create(ctx, &domain.User{Username: "alice"}); !errors.Is(err, gorm.ErrDuplicatedKey) {
+		t.Errorf("expected duplicate username to fail, got %v", err)
+	}
+	wallet := &domain.Wallet{UserID: user.ID, Balance: 100}
+	if err := store.Wallets().Create(ctx, wallet); err != nil {
+		t.Fatal(err)
+	}
+
+	failed := errors.New("failed")
+	err := store.UnitOfWork().Do(ctx, func(tx repository.Tx) error {
+		if err := tx.Wallets().UpdateBalance(ctx, wallet.ID, 50); err != nil {
+			return err
+		}
+		if err := tx.Transactions().Create(ctx, &domain.Transaction{WalletID: wallet.ID, Amount: -50, TransactionUUID: "a"}); err != nil {
+			return err
+		}
+		if err := tx.Outbox().Create(ctx, &domain.OutboxEvent{EventID: "a"}); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("expected the error of fn, got %v", err)
+	}
+
+	stored, err := store.Wallets().GetByID(ctx, wallet.ID)
+	if err != nil || stored.Balance != 100 || stored.User.Username != "alice" {
+		t.Errorf("expected the wallet unchanged with its user, got %+v, %v", stored, err)
+	}
+	if ledgers, _ := store.Wallets().Ledgers(ctx, false); len(ledgers) != 1 || ledgers[0].Transactions != 0 {
+		t.Errorf("expected the transaction rolled back, got %+v", ledgers)
+	}
+	if len(store.OutboxEvents()) != 0 {
+		t.Error("expected the outbox event rolled back")
+	}
+
+	err = store.UnitOfWork().Do(ctx, func(tx repository.Tx) error {
+		if err := tx.Wallets().UpdateBalance(ctx, wallet.ID, 50); err != nil {
+			return err
+		}
+		return tx.Transactions().Create(ctx, &domain.Transaction{WalletID: wallet.ID, Amount: -50, TransactionUUID: "a"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ledgers, _ := store.Wallets().Ledgers(ctx, true); len(ledgers) != 1 || ledgers[0].Difference() != 100 {
+		t.Errorf("expected a mismatched ledger after the commit, got %+v", ledgers)
+	}
+}
+
+func TestTransactionFilters(t *testing.T) {
+	store := NewStore()
+	ctx := context.Background()
+
+	var walletIDs []uint
+	for _, name := range []string{"alice", "bob"} {
+		user := &domain.User{Username: name}
+		if err := store.Users().Create(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+		wallet := &domain.Wallet{UserID: user.ID}
+		if err := store.Wallets().Create(ctx, wallet); err != nil {
+			t.Fatal(err)
+		}
+		walletIDs = append(walletIDs, wallet.ID)
+	}
+	for i, walletID := range []uint{walletIDs[0], walletIDs[0], walletIDs[1]} {
+		transaction := &domain.Transaction{WalletID: walletID, Type: domain.TransactionTypeDeposit, TransactionUUID: string(rune('a' + i))}
+		if err := store.Transactions().Create(ctx, transaction); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Transactions().Create(ctx, &domain.Transaction{WalletID: 999, TransactionUUID: "z"}); !errors.Is(err, gorm.ErrForeignKeyViolated) {
+		t.Errorf("expected unknown wallet to fail, got %v", err)
+	}
+
+	transactions, err := store.Transactions().List(ctx, repository.TransactionFilters{WalletID: &walletIDs[0]})
+	if err != nil || len(transactions) != 2 || transactions[0].ID < transactions[1].ID {
+		t.Fatalf("expected two transactions newest first, got %+v, %v", transactions, err)
+	}
+	if transactions[0].Wallet.User.Username != "alice" {
+		t.Errorf("expected wallet and user preloaded, got %+v", transactions[0].Wallet)
+	}
+
+	var batches []int
+	err = store.Transactions().ListInBatches(ctx, repository.TransactionFilters{}, 2, func(batch []*domain.Transaction) error {
+		batches = append(batches, len(batch))
+		return nil
+	})
+	if err != nil || len(batches) != 2 || batches[0] != 2 || batches[1] != 1 {
+		t.Errorf("expected batches of 2 and 1, got %v, %v", batches, err)
+	}
+}
+
+func TestPages(t *testing.T) {
+	store := NewStore()
+	ctx := context.Background()
+
+	user := &domain.User{Username: "alice"}
+	if err := store.Users().Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	wallet := &domain.Wallet{UserID: user.ID}
+	if err := store.Wallets().Create(ctx, wallet); err != nil {
+		t.Fatal(err)
+	}
+	// Two transactions share a timestamp and are told apart by id
+	start := time.Now()
+	for i, offset := range []time.Duration{0, time.Minute, time.Minute, 2 * time.Minute} {
+		if err := store.Transactions().Create(ctx, &domain.Transaction{
+			WalletID: wallet.ID, Amount: int64(i + 1), TransactionUUID: string(rune('a' + i)), CreatedAt: start.Add(offset),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var amounts []int64
+	req := repository.PageRequest{Limit: 3, IncludeTotal: true}
+	page, err := store.Transactions().Page(ctx, repository.TransactionFilters{}, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, transaction := range page.Items {
+		amounts = append(amounts, transaction.Amount)
+	}
+	if !reflect.DeepEqual(amounts, []int64{4, 3, 2}) || page.Next == nil || page.Prev != nil || *page.Total != 4 {
+		t.Fatalf("unexpected first page %v %+v", amounts, page)
+	}
+
+	page, err = store.Transactions().Page(ctx, repository.TransactionFilters{}, repository.PageRequest{Limit: 3, After: page.Next})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 1 || page.Items[0].Amount != 1 || page.Next != nil || page.Prev == nil {
+		t.Fatalf("unexpected last page %+v", page)
+	}
+
+	page, err = store.Transactions().Page(ctx, repository.TransactionFilters{}, repository.PageRequest{Limit: 2, Before: page.Prev})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 2 || page.Items[0].Amount != 3 || page.Items[1].Amount != 2 || page.Prev == nil {
+		t.Errorf("expected 3 and 2 with a newer page before them, got %+v", page)
+	}
+
+	users, err := store.Users().Page(ctx, repository.PageRequest{Limit: 10})
+	if err != nil || len(users.Items) != 1 || len(users.Items[0].Wallets) != 1 || users.Next != nil {
+		t.Errorf("expected the user with its wallet, got %+v, %v", users, err)
+	}
+}
@@ -0,0 +1,125 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/repository"
+	"gorm.io/gorm"
+)
+
+type transactionRepository struct {
+	view view
+}
+
+func (r *transactionRepository) Create(ctx context.Context, transaction *domain.Transaction) error {
+	defer r.view.lock()()
+	s := r.view.store
+
+	if _, ok := s.wallets[transaction.WalletID]; !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+	for _, existing := range s.transactions {
+		if existing.TransactionUUID == transaction.TransactionUUID {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+
+	transaction.ID = s.nextID()
+	if transaction.CreatedAt.IsZero() {
+		transaction.CreatedAt = s.now()
+	}
+	stored := *transaction
+	stored.Wallet = domain.Wallet{}
+	stored.FromWallet = nil
+	stored.ToWallet = nil
+	put(r.view, s.transactions, stored.ID, &stored)
+	return nil
+}
+
+func (r *transactionRepository) GetByWalletID(ctx context.Context, walletID uint, limit, offset int) ([]*domain.Transaction, error) {
+	walletFilter := walletID
+	return r.List(ctx, repository.TransactionFilters{WalletID: &walletFilter, Limit: limit, Offset: offset})
+}
+
+func (r *transactionRepository) GetByUserID(ctx context.Context, userID uint, limit, offset int) ([]*domain.Transaction, error) {
+	userFilter := userID
+	return r.List(ctx, repository.TransactionFilters{UserID: &userFilter, Limit: limit, Offset: offset})
+}
+
+// List orders by id after created_at, which the SQL repository leaves to
+// the database, so that results are stable
+func (r *transactionRepository) List(ctx context.Context, filters repository.TransactionFilters) ([]*domain.Transaction, error) {
+	defer r.view.lock()()
+
+	return page(r.newestFirst(filters), filters.Limit, filters.Offset), nil
+}
+
+// Page returns the page of matching transactions selected by req. Limit and
+// Offset are ignored.
+func (r *transactionRepository) Page(ctx context.Context, filters repository.TransactionFilters, req repository.PageRequest) (*repository.Page[*domain.Transaction], error) {
+	defer r.view.lock()()
+
+	return repository.PageOf(r.newestFirst(filters), req, func(transaction *domain.Transaction) repository.Cursor {
+		return repository.Cursor{CreatedAt: transaction.CreatedAt, ID: transaction.ID}
+	}), nil
+}
+
+func (r *transactionRepository) newestFirst(filters repository.TransactionFilters) []*domain.Transaction {
+	transactions := r.filtered(filters)
+	sort.Slice(transactions, func(i, j int) bool {
+		a, b := transactions[i], transactions[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID > b.ID
+	})
+	return transactions
+}
+
+// ListInBatches calls fn with every matching transaction in id order,
+// batchSize at a time. Limit and Offset are ignored.
+func (r *transactionRepository) ListInBatches(ctx context.Context, filters repository.TransactionFilters, batchSize int, fn func([]*domain.Transaction) error) error {
+	unlock := r.view.lock()
+	transactions := r.filtered(filters)
+	unlock()
+
+	sort.Slice(transactions, func(i, j int) bool { return transactions[i].ID < transactions[j].ID })
+	for start := 0; start < len(transactions); start += batchSize {
+		end := min(start+batchSize, len(transactions))
+		if err := fn(transactions[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filtered copies the matching transactions with their wallet and its user
+// preloaded
+func (r *transactionRepository) filtered(filters repository.TransactionFilters) []*domain.Transaction {
+	s := r.view.store
+
+	var transactions []*domain.Transaction
+	for _, transaction := range s.transactions {
+		wallet := s.wallets[transaction.WalletID]
+		switch {
+		case filters.UserID != nil && (wallet == nil || wallet.UserID != *filters.UserID),
+			filters.WalletID != nil && transaction.WalletID != *filters.WalletID,
+			filters.Type != nil && transaction.Type != *filters.Type,
+			filters.StartDate != nil && transaction.CreatedAt.Before(*filters.StartDate),
+			filters.EndDate != nil && transaction.CreatedAt.After(*filters.EndDate):
+			continue
+		}
+
+		copied := *transaction
+		if wallet != nil {
+			copied.Wallet = *wallet
+			if user, ok := s.users[wallet.UserID]; ok {
+				copied.Wallet.User = *user
+			}
+		}
+		transactions = append(transactions, &copied)
+	}
+	return transactions
+}
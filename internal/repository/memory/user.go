@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/repository"
+	"gorm.io/gorm"
+)
+
+type userRepository struct {
+	view view
+}
+
+func (r *userRepository) Create(ctx context.Context, user *domain.User) error {
+	defer r.view.lock()()
+	s := r.view.store
+
+	for _, existing := range s.users {
+		if existing.Username == user.Username {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+
+	now := s.now()
+	user.ID = s.nextID()
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = now
+	}
+	if user.UpdatedAt.IsZero() {
+		user.UpdatedAt = now
+	}
+	stored := *user
+	stored.Wallets = nil
+	put(r.view, s.users, stored.ID, &stored)
+	return nil
+}
+
+func (r *userRepository) GetByID(ctx context.Context, id uint) (*domain.User, error) {
+	defer r.view.lock()()
+
+	user, ok := r.view.store.users[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.withWallets(user), nil
+}
+
+func (r *userRepository) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
+	defer r.view.lock()()
+
+	for _, user := range r.view.store.users {
+		if user.Username == username {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *userRepository) UpdatePassword(ctx context.Context, userID uint, hashedPassword string, changedAt time.Time) error {
+	return r.update(userID, func(user *domain.User) {
+		user.Password = hashedPassword
+		user.PasswordChangedAt = &changedAt
+	})
+}
+
+func (r *userRepository) UpdatePasswordHash(ctx context.Context, userID uint, hashedPassword string) error {
+	return r.update(userID, func(user *domain.User) {
+		user.Password = hashedPassword
+	})
+}
+
+func (r *userRepository) List(ctx context.Context, limit, offset int) ([]*domain.User, error) {
+	defer r.view.lock()()
+
+	users := make([]*domain.User, 0, len(r.view.store.users))
+	for _, user := range r.view.store.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	users = page(users, limit, offset)
+	for i, user := range users {
+		users[i] = r.withWallets(user)
+	}
+	return users, nil
+}
+
+func (r *userRepository) Page(ctx context.Context, req repository.PageRequest) (*repository.Page[*domain.User], error) {
+	defer r.view.lock()()
+
+	users := make([]*domain.User, 0, len(r.view.store.users))
+	for _, user := range r.view.store.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		a, b := users[i], users[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID > b.ID
+	})
+
+	result := repository.PageOf(users, req, func(user *domain.User) repository.Cursor {
+		return repository.Cursor{CreatedAt: user.CreatedAt, ID: user.ID}
+	})
+	for i, user := range result.Items {
+		result.Items[i] = r.withWallets(user)
+	}
+	return result, nil
+}
+
+func (r *userRepository) SetAdmin(ctx context.Context, userID uint, isAdmin bool) error {
+	return r.update(userID, func(user *domain.User) {
+		user.IsAdmin = isAdmin
+	})
+}
+
+// update changes a copy of the user and stores it. Like an UPDATE matching
+// no rows, a missing user is not an error.
+func (r *userRepository) update(userID uint, change func(user *domain.User)) error {
+	defer r.view.lock()()
+	s := r.view.store
+
+	user, ok := s.users[userID]
+	if !ok {
+		return nil
+	}
+	updated := *user
+	change(&updated)
+	updated.UpdatedAt = s.now()
+	put(r.view, s.users, userID, &updated)
+	return nil
+}
+
+// withWallets copies the user with its wallets preloaded
+func (r *userRepository) withWallets(user *domain.User) *domain.User {
+	copied := *user
+	copied.Wallets = nil
+	for _, wallet := range sortedWallets(r.view.store.wallets) {
+		if wallet.UserID == user.ID {
+			copied.Wallets = append(copied.Wallets, *wallet)
+		}
+	}
+	return &copied
+}
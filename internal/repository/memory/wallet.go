@@ -0,0 +1,155 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/repository"
+	"gorm.io/gorm"
+)
+
+type walletRepository struct {
+	view view
+}
+
+func (r *walletRepository) Create(ctx context.Context, wallet *domain.Wallet) error {
+	defer r.view.lock()()
+	s := r.view.store
+
+	if _, ok := s.users[wallet.UserID]; !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+
+	now := s.now()
+	wallet.ID = s.nextID()
+	if wallet.CreatedAt.IsZero() {
+		wallet.CreatedAt = now
+	}
+	if wallet.UpdatedAt.IsZero() {
+		wallet.UpdatedAt = now
+	}
+	put(r.view, s.wallets, wallet.ID, storedWallet(wallet))
+	return nil
+}
+
+func (r *walletRepository) GetByID(ctx context.Context, id uint) (*domain.Wallet, error) {
+	defer r.view.lock()()
+	s := r.view.store
+
+	wallet, ok := s.wallets[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *wallet
+	if user, ok := s.users[wallet.UserID]; ok {
+		copied.User = *user
+	}
+	return &copied, nil
+}
+
+// GetForUpdate needs no row lock, the unit of work holds the store lock
+func (r *walletRepository) GetForUpdate(ctx context.Context, id uint) (*domain.Wallet, error) {
+	defer r.view.lock()()
+
+	wallet, ok := r.view.store.wallets[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *wallet
+	return &copied, nil
+}
+
+func (r *walletRepository) GetByUserID(ctx context.Context, userID uint) ([]*domain.Wallet, error) {
+	defer r.view.lock()()
+
+	var wallets []*domain.Wallet
+	for _, wallet := range sortedWallets(r.view.store.wallets) {
+		if wallet.UserID == userID {
+			copied := *wallet
+			wallets = append(wallets, &copied)
+		}
+	}
+	return wallets, nil
+}
+
+func (r *walletRepository) Update(ctx context.Context, wallet *domain.Wallet) error {
+	defer r.view.lock()()
+	s := r.view.store
+
+	wallet.UpdatedAt = s.now()
+	put(r.view, s.wallets, wallet.ID, storedWallet(wallet))
+	return nil
+}
+
+func (r *walletRepository) UpdateBalance(ctx context.Context, walletID uint, newBalance int64) error {
+	return r.update(walletID, func(wallet *domain.Wallet) {
+		wallet.Balance = newBalance
+	})
+}
+
+func (r *walletRepository) SetFrozen(ctx context.Context, walletID uint, frozenAt *time.Time, reason string) error {
+	return r.update(walletID, func(wallet *domain.Wallet) {
+		wallet.FrozenAt = frozenAt
+		wallet.FrozenReason = reason
+	})
+}
+
+func (r *walletRepository) Ledgers(ctx context.Context, mismatchedOnly bool) ([]*repository.WalletLedger, error) {
+	defer r.view.lock()()
+	s := r.view.store
+
+	var ledgers []*repository.WalletLedger
+	for _, wallet := range sortedWallets(s.wallets) {
+		ledger := &repository.WalletLedger{
+			WalletID: wallet.ID,
+			UserID:   wallet.UserID,
+			Balance:  wallet.Balance,
+		}
+		for _, transaction := range s.transactions {
+			if transaction.WalletID == wallet.ID {
+				ledger.LedgerTotal += transaction.Amount
+				ledger.Transactions++
+			}
+		}
+		if !mismatchedOnly || ledger.Difference() != 0 {
+			ledgers = append(ledgers, ledger)
+		}
+	}
+	return ledgers, nil
+}
+
+// update changes a copy of the wallet and stores it. Like an UPDATE matching
+// no rows, a missing wallet is not an error.
+func (r *walletRepository) update(walletID uint, change func(wallet *domain.Wallet)) error {
+	defer r.view.lock()()
+	s := r.view.store
+
+	wallet, ok := s.wallets[walletID]
+	if !ok {
+		return nil
+	}
+	updated := *wallet
+	change(&updated)
+	updated.UpdatedAt = s.now()
+	put(r.view, s.wallets, walletID, &updated)
+	return nil
+}
+
+// storedWallet copies a wallet without its associations
+func storedWallet(wallet *domain.Wallet) *domain.Wallet {
+	stored := *wallet
+	stored.User = domain.User{}
+	stored.Transactions = nil
+	return &stored
+}
+
+func sortedWallets(wallets map[uint]*domain.Wallet) []*domain.Wallet {
+	sorted := make([]*domain.Wallet, 0, len(wallets))
+	for _, wallet := range wallets {
+		sorted = append(sorted, wallet)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	return sorted
+}
@@ -0,0 +1,204 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/repository"
+	"gorm.io/gorm"
+)
+
+type webhookRepository struct {
+	view view
+}
+
+func (r *webhookRepository) CreateEndpoint(ctx context.Context, endpoint *domain.WebhookEndpoint) error {
+	defer r.view.lock()()
+	s := r.view.store
+
+	if _, ok := s.users[endpoint.UserID]; !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+
+	endpoint.ID = s.nextID()
+	now := s.now()
+	endpoint.CreatedAt, endpoint.UpdatedAt = now, now
+	stored := *endpoint
+	put(r.view, s.webhookEndpoints, stored.ID, &stored)
+	return nil
+}
+
+func (r *webhookRepository) GetEndpoint(ctx context.Context, id uint) (*domain.WebhookEndpoint, error) {
+	defer r.view.lock()()
+
+	endpoint, ok := r.view.store.webhookEndpoints[id]
+	if !ok || endpoint.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *endpoint
+	return &copied, nil
+}
+
+func (r *webhookRepository) GetEndpointsByUserID(ctx context.Context, userID uint) ([]*domain.WebhookEndpoint, error) {
+	defer r.view.lock()()
+
+	var endpoints []*domain.WebhookEndpoint
+	for _, endpoint := range r.view.store.webhookEndpoints {
+		if endpoint.UserID == userID && !endpoint.DeletedAt.Valid {
+			copied := *endpoint
+			endpoints = append(endpoints, &copied)
+		}
+	}
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].ID > endpoints[j].ID })
+	return endpoints, nil
+}
+
+func (r *webhookRepository) GetActiveEndpointsForUsers(ctx context.Context, userIDs []uint) ([]*domain.WebhookEndpoint, error) {
+	defer r.view.lock()()
+
+	users := make(map[uint]bool, len(userIDs))
+	for _, id := range userIDs {
+		users[id] = true
+	}
+
+	var endpoints []*domain.WebhookEndpoint
+	for _, endpoint := range r.view.store.webhookEndpoints {
+		if users[endpoint.UserID] && endpoint.Active && !endpoint.DeletedAt.Valid {
+			copied := *endpoint
+			endpoints = append(endpoints, &copied)
+		}
+	}
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].ID < endpoints[j].ID })
+	return endpoints, nil
+}
+
+// DeleteEndpoint soft deletes, like the SQL repository
+func (r *webhookRepository) DeleteEndpoint(ctx context.Context, id, userID uint) (bool, error) {
+	defer r.view.lock()()
+	s := r.view.store
+
+	endpoint, ok := s.webhookEndpoints[id]
+	if !ok || endpoint.UserID != userID || endpoint.DeletedAt.Valid {
+		return false, nil
+	}
+	updated := *endpoint
+	updated.DeletedAt = gorm.DeletedAt{Time: s.now(), Valid: true}
+	put(r.view, s.webhookEndpoints, id, &updated)
+	return true, nil
+}
+
+// CreateDelivery ignores duplicates of the same event for the same endpoint
+// and leaves the id unset, like the SQL repository
+func (r *webhookRepository) CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	defer r.view.lock()()
+	s := r.view.store
+
+	if _, ok := s.webhookEndpoints[delivery.EndpointID]; !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+	for _, existing := range s.webhookDeliveries {
+		if existing.EndpointID == delivery.EndpointID && existing.EventID == delivery.EventID {
+			return nil
+		}
+	}
+
+	delivery.ID = s.nextID()
+	now := s.now()
+	delivery.CreatedAt, delivery.UpdatedAt = now, now
+	stored := *delivery
+	stored.Endpoint = domain.WebhookEndpoint{}
+	put(r.view, s.webhookDeliveries, stored.ID, &stored)
+	return nil
+}
+
+// GetDelivery loads the endpoint unless it was deleted
+func (r *webhookRepository) GetDelivery(ctx context.Context, id uint) (*domain.WebhookDelivery, error) {
+	defer r.view.lock()()
+	s := r.view.store
+
+	delivery, ok := s.webhookDeliveries[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *delivery
+	if endpoint, ok := s.webhookEndpoints[delivery.EndpointID]; ok && !endpoint.DeletedAt.Valid {
+		copied.Endpoint = *endpoint
+	}
+	return &copied, nil
+}
+
+func (r *webhookRepository) PageDeliveries(ctx context.Context, endpointID uint, req repository.PageRequest) (*repository.Page[*domain.WebhookDelivery], error) {
+	defer r.view.lock()()
+
+	var deliveries []*domain.WebhookDelivery
+	for _, delivery := range r.view.store.webhookDeliveries {
+		if delivery.EndpointID == endpointID {
+			copied := *delivery
+			deliveries = append(deliveries, &copied)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		if !deliveries[i].CreatedAt.Equal(deliveries[j].CreatedAt) {
+			return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+		}
+		return deliveries[i].ID > deliveries[j].ID
+	})
+	return repository.PageOf(deliveries, req, func(delivery *domain.WebhookDelivery) repository.Cursor {
+		return repository.Cursor{CreatedAt: delivery.CreatedAt, ID: delivery.ID}
+	}), nil
+}
+
+// ClaimDueDeliveries pushes the next attempt of due deliveries out by lease
+// and loads their endpoints, including deleted ones
+func (r *webhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.WebhookDelivery, error) {
+	defer r.view.lock()()
+	s := r.view.store
+
+	var due []*domain.WebhookDelivery
+	for _, delivery := range s.webhookDeliveries {
+		if delivery.Status == domain.WebhookDeliveryPending && !delivery.NextAttemptAt.After(now) {
+			due = append(due, delivery)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].NextAttemptAt.Equal(due[j].NextAttemptAt) {
+			return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+		}
+		return due[i].ID < due[j].ID
+	})
+	due = page(due, limit, 0)
+
+	deliveries := make([]*domain.WebhookDelivery, 0, len(due))
+	for _, delivery := range due {
+		claimed := *delivery
+		claimed.NextAttemptAt = now.Add(lease)
+		put(r.view, s.webhookDeliveries, claimed.ID, &claimed)
+
+		copied := claimed
+		copied.Endpoint = *s.webhookEndpoints[claimed.EndpointID]
+		deliveries = append(deliveries, &copied)
+	}
+	return deliveries, nil
+}
+
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	defer r.view.lock()()
+	s := r.view.store
+
+	existing, ok := s.webhookDeliveries[delivery.ID]
+	if !ok {
+		return nil
+	}
+	updated := *existing
+	updated.Status = delivery.Status
+	updated.Attempts = delivery.Attempts
+	updated.NextAttemptAt = delivery.NextAttemptAt
+	updated.LastStatusCode = delivery.LastStatusCode
+	updated.LastError = delivery.LastError
+	updated.DeliveredAt = delivery.DeliveredAt
+	updated.UpdatedAt = s.now()
+	put(r.view, s.webhookDeliveries, updated.ID, &updated)
+	return nil
+}
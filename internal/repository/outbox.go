@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"gorm.io/gorm"
+)
+
+// OutboxRepository only appends events, the relay publishes and marks them
+type OutboxRepository interface {
+	Create(ctx context.Context, event *domain.OutboxEvent) error
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) Create(ctx context.Context, event *domain.OutboxEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
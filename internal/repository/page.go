@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidCursor is returned for page tokens that were not handed out by
+// EncodeCursor
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the position of a record in a listing ordered by created_at and
+// then id, newest first. Unlike an offset it stays on the same record when
+// newer ones are inserted.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uint
+}
+
+// PageRequest selects a page of a listing. After pages towards older
+// records and Before towards newer ones, at most one of them is set.
+type PageRequest struct {
+	Limit        int
+	After        *Cursor
+	Before       *Cursor
+	IncludeTotal bool
+}
+
+// Page is a page of a listing, newest first. Next is set when there are
+// older records and Prev when there are newer ones. Total counts every
+// record matching the filters and is only set when it was asked for.
+type Page[T any] struct {
+	Items []T
+	Next  *Cursor
+	Prev  *Cursor
+	Total *int64
+}
+
+const (
+	cursorAfter  = "a"
+	cursorBefore = "b"
+)
+
+// EncodeCursor turns a cursor into an opaque page token. before selects
+// the records newer than the cursor instead of the older ones.
+func EncodeCursor(cursor Cursor, before bool) string {
+	direction := cursorAfter
+	if before {
+		direction = cursorBefore
+	}
+	raw := fmt.Sprintf("%s:%d:%d", direction, cursor.CreatedAt.UnixNano(), cursor.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// SetCursor points the request at the page token, which must come from
+// EncodeCursor
+func (r *PageRequest) SetCursor(token string) error {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 3 {
+		return ErrInvalidCursor
+	}
+	nanos, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	id, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil || id == 0 {
+		return ErrInvalidCursor
+	}
+
+	cursor := &Cursor{CreatedAt: time.Unix(0, nanos), ID: uint(id)}
+	switch parts[0] {
+	case cursorAfter:
+		r.After, r.Before = cursor, nil
+	case cursorBefore:
+		r.After, r.Before = nil, cursor
+	default:
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+// paginate runs query, which must only filter, for the page selected by
+// req. Rows are compared on created_at and id of table, and one row more
+// than the limit is fetched to tell whether the page is the last one.
+// Associations to load are passed as preloads, so that counting the total
+// does not load them.
+func paginate[T any](query *gorm.DB, table string, req PageRequest, key func(T) Cursor, preloads ...string) (*Page[T], error) {
+	page := &Page[T]{}
+	if req.IncludeTotal {
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+
+	createdAt, id := table+".created_at", table+".id"
+	order := fmt.Sprintf("%s DESC, %s DESC", createdAt, id)
+	find := query.Session(&gorm.Session{})
+	switch {
+	case req.Before != nil:
+		find = find.Where(fmt.Sprintf("(%s > ? OR (%s = ? AND %s > ?))", createdAt, createdAt, id),
+			req.Before.CreatedAt, req.Before.CreatedAt, req.Before.ID)
+		order = fmt.Sprintf("%s ASC, %s ASC", createdAt, id)
+	case req.After != nil:
+		find = find.Where(fmt.Sprintf("(%s < ? OR (%s = ? AND %s < ?))", createdAt, createdAt, id),
+			req.After.CreatedAt, req.After.CreatedAt, req.After.ID)
+	}
+	for _, preload := range preloads {
+		find = find.Preload(preload)
+	}
+	if req.Limit > 0 {
+		find = find.Limit(req.Limit + 1)
+	}
+
+	var items []T
+	if err := find.Order(order).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	page.Items = items
+	page.link(req, key)
+	return page, nil
+}
+
+// PageOf cuts the page selected by req from every matching record, sorted
+// newest first. It is what paginate does in SQL, for repositories that keep
+// their records in memory.
+func PageOf[T any](items []T, req PageRequest, key func(T) Cursor) *Page[T] {
+	page := &Page[T]{}
+	if req.IncludeTotal {
+		total := int64(len(items))
+		page.Total = &total
+	}
+
+	var selected []T
+	switch {
+	case req.Before != nil:
+		for i := len(items) - 1; i >= 0; i-- {
+			if key(items[i]).newerThan(*req.Before) {
+				selected = append(selected, items[i])
+			}
+		}
+	case req.After != nil:
+		for _, item := range items {
+			if req.After.newerThan(key(item)) {
+				selected = append(selected, item)
+			}
+		}
+	default:
+		selected = items
+	}
+	if req.Limit > 0 && len(selected) > req.Limit+1 {
+		selected = selected[:req.Limit+1]
+	}
+
+	page.Items = append([]T(nil), selected...)
+	page.link(req, key)
+	return page
+}
+
+func (c Cursor) newerThan(other Cursor) bool {
+	if !c.CreatedAt.Equal(other.CreatedAt) {
+		return c.CreatedAt.After(other.CreatedAt)
+	}
+	return c.ID > other.ID
+}
+
+// link trims the extra row fetched past the limit, puts the items newest
+// first and sets the cursors to the neighbouring pages
+func (p *Page[T]) link(req PageRequest, key func(T) Cursor) {
+	more := req.Limit > 0 && len(p.Items) > req.Limit
+	if more {
+		p.Items = p.Items[:req.Limit]
+	}
+	if req.Before != nil {
+		for i, j := 0, len(p.Items)-1; i < j; i, j = i+1, j-1 {
+			p.Items[i], p.Items[j] = p.Items[j], p.Items[i]
+		}
+	}
+	if p.Items == nil {
+		p.Items = []T{}
+	}
+
+	var first, last *Cursor
+	if len(p.Items) > 0 {
+		firstKey, lastKey := key(p.Items[0]), key(p.Items[len(p.Items)-1])
+		first, last = &firstKey, &lastKey
+	}
+
+	switch {
+	case req.Before != nil:
+		// The record the cursor points at is older than this page
+		p.Next = last
+		if last == nil {
+			p.Next = req.Before
+		}
+		if more {
+			p.Prev = first
+		}
+	case req.After != nil:
+		if more {
+			p.Next = last
+		}
+		p.Prev = first
+		if first == nil {
+			p.Prev = req.After
+		}
+	default:
+		if more {
+			p.Next = last
+		}
+	}
+}
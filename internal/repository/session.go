@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"gorm.io/gorm"
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *domain.Session) error
+	GetByID(ctx context.Context, id string) (*domain.Session, error)
+	GetActiveByUserID(ctx context.Context, userID uint, now time.Time) ([]*domain.Session, error)
+	CountByUserID(ctx context.Context, userID uint) (int64, error)
+	HasDevice(ctx context.Context, userID uint, deviceHash string) (bool, error)
+	Revoke(ctx context.Context, id string, userID uint, revokedAt time.Time) (bool, error)
+	RevokeAllForUser(ctx context.Context, userID uint, revokedAt time.Time) ([]string, error)
+	TouchLastSeen(ctx context.Context, id string, seenAt time.Time) error
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *domain.Session) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+func (r *sessionRepository) GetByID(ctx context.Context, id string) (*domain.Session, error) {
+	var session domain.Session
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) GetActiveByUserID(ctx context.Context, userID uint, now time.Time) ([]*domain.Session, error) {
+	var sessions []*domain.Session
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *sessionRepository) CountByUserID(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.Session{}).
+		Where("user_id = ?", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *sessionRepository) HasDevice(ctx context.Context, userID uint, deviceHash string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.Session{}).
+		Where("user_id = ? AND device_hash = ?", userID, deviceHash).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *sessionRepository) Revoke(ctx context.Context, id string, userID uint, revokedAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", revokedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RevokeAllForUser revokes every open session of the user and returns their IDs
+func (r *sessionRepository) RevokeAllForUser(ctx context.Context, userID uint, revokedAt time.Time) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.Session{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return tx.Model(&domain.Session{}).
+			Where("id IN ?", ids).
+			Update("revoked_at", revokedAt).Error
+	})
+	return ids, err
+}
+
+func (r *sessionRepository) TouchLastSeen(ctx context.Context, id string, seenAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&domain.Session{}).
+		Where("id = ?", id).
+		Update("last_seen_at", seenAt).Error
+}
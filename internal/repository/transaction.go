@@ -13,10 +13,13 @@ type TransactionRepository interface {
 	GetByWalletID(ctx context.Context, walletID uint, limit, offset int) ([]*domain.Transaction, error)
 	GetByUserID(ctx context.Context, userID uint, limit, offset int) ([]*domain.Transaction, error)
 	List(ctx context.Context, filters TransactionFilters) ([]*domain.Transaction, error)
+	ListInBatches(ctx context.Context, filters TransactionFilters, batchSize int, fn func([]*domain.Transaction) error) error
+	Page(ctx context.Context, filters TransactionFilters, req PageRequest) (*Page[*domain.Transaction], error)
 }
 
 type TransactionFilters struct {
 	UserID    *uint
+	WalletID  *uint
 	Type      *domain.TransactionType
 	StartDate *time.Time
 	EndDate   *time.Time
@@ -60,38 +63,69 @@ func (r *transactionRepository) GetByUserID(ctx context.Context, userID uint, li
 }
 
 func (r *transactionRepository) List(ctx context.Context, filters TransactionFilters) ([]*domain.Transaction, error) {
-	query := r.db.WithContext(ctx).
+	query := r.filtered(ctx, filters).Order("transactions.created_at DESC")
+
+	if filters.Limit > 0 {
+		query = query.Limit(filters.Limit)
+	}
+
+	if filters.Offset > 0 {
+		query = query.Offset(filters.Offset)
+	}
+
+	var transactions []*domain.Transaction
+	err := query.Find(&transactions).Error
+	return transactions, err
+}
+
+// ListInBatches calls fn with every matching transaction in id order,
+// batchSize at a time. Limit and Offset are ignored.
+func (r *transactionRepository) ListInBatches(ctx context.Context, filters TransactionFilters, batchSize int, fn func([]*domain.Transaction) error) error {
+	var batch []*domain.Transaction
+	return r.filtered(ctx, filters).FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+		return fn(batch)
+	}).Error
+}
+
+// Page returns the page of matching transactions selected by req. Limit and
+// Offset are ignored.
+func (r *transactionRepository) Page(ctx context.Context, filters TransactionFilters, req PageRequest) (*Page[*domain.Transaction], error) {
+	return paginate(r.where(ctx, filters), "transactions", req, transactionCursor, "Wallet", "Wallet.User")
+}
+
+func transactionCursor(transaction *domain.Transaction) Cursor {
+	return Cursor{CreatedAt: transaction.CreatedAt, ID: transaction.ID}
+}
+
+func (r *transactionRepository) filtered(ctx context.Context, filters TransactionFilters) *gorm.DB {
+	return r.where(ctx, filters).
 		Preload("Wallet").
 		Preload("Wallet.User")
+}
+
+func (r *transactionRepository) where(ctx context.Context, filters TransactionFilters) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&domain.Transaction{})
 
 	if filters.UserID != nil {
 		query = query.Joins("JOIN wallets ON transactions.wallet_id = wallets.id").
 			Where("wallets.user_id = ?", *filters.UserID)
 	}
 
+	if filters.WalletID != nil {
+		query = query.Where("transactions.wallet_id = ?", *filters.WalletID)
+	}
+
 	if filters.Type != nil {
-		query = query.Where("type = ?", *filters.Type)
+		query = query.Where("transactions.type = ?", *filters.Type)
 	}
 
 	if filters.StartDate != nil {
-		query = query.Where("created_at >= ?", *filters.StartDate)
+		query = query.Where("transactions.created_at >= ?", *filters.StartDate)
 	}
 
 	if filters.EndDate != nil {
-		query = query.Where("created_at <= ?", *filters.EndDate)
-	}
-
-	query = query.Order("created_at DESC")
-
-	if filters.Limit > 0 {
-		query = query.Limit(filters.Limit)
+		query = query.Where("transactions.created_at <= ?", *filters.EndDate)
 	}
 
-	if filters.Offset > 0 {
-		query = query.Offset(filters.Offset)
-	}
-
-	var transactions []*domain.Transaction
-	err := query.Find(&transactions).Error
-	return transactions, err
+	return query
 }
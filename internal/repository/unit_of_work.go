@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/SahandMohammed/wallet-service/internal/dialect"
+	"github.com/SahandMohammed/wallet-service/internal/logging"
+	"gorm.io/gorm"
+)
+
+// Tx gives the repositories of a unit of work. Their writes commit or roll
+// back together.
+type Tx interface {
+	Users() UserRepository
+	PasswordResets() PasswordResetRepository
+	Wallets() WalletRepository
+	Transactions() TransactionRepository
+	Outbox() OutboxRepository
+	AuditLogs() AuditLogRepository
+}
+
+// UnitOfWork runs fn in a transaction, which commits when fn returns nil.
+// fn may be run more than once and must only write through tx.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(tx Tx) error) error
+}
+
+// maxTransactionAttempts bounds how often a unit of work is run again after
+// the database aborted it
+const maxTransactionAttempts = 3
+
+type unitOfWork struct {
+	db *gorm.DB
+}
+
+func NewUnitOfWork(db *gorm.DB) UnitOfWork {
+	return &unitOfWork{db: db}
+}
+
+// Do runs fn again when the database aborts the transaction over a deadlock
+// or lock timeout, as transfers locking the same two wallets in opposite
+// order can.
+func (u *unitOfWork) Do(ctx context.Context, fn func(tx Tx) error) error {
+	var err error
+	for attempt := 1; attempt <= maxTransactionAttempts; attempt++ {
+		err = u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return fn(gormTx{db: tx})
+		})
+		if !dialect.IsRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+		logging.FromContext(ctx).WithError(err).WithField("attempt", attempt).Warn("Retrying aborted transaction")
+	}
+	return err
+}
+
+type gormTx struct {
+	db *gorm.DB
+}
+
+func (t gormTx) Users() UserRepository                   { return NewUserRepository(t.db) }
+func (t gormTx) PasswordResets() PasswordResetRepository { return NewPasswordResetRepository(t.db) }
+func (t gormTx) Wallets() WalletRepository               { return NewWalletRepository(t.db) }
+func (t gormTx) Transactions() TransactionRepository     { return NewTransactionRepository(t.db) }
+func (t gormTx) Outbox() OutboxRepository                { return NewOutboxRepository(t.db) }
+func (t gormTx) AuditLogs() AuditLogRepository           { return NewAuditLogRepository(t.db) }
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/SahandMohammed/wallet-service/internal/domain"
 	"gorm.io/gorm"
@@ -11,7 +12,11 @@ type UserRepository interface {
 	Create(ctx context.Context, user *domain.User) error
 	GetByID(ctx context.Context, id uint) (*domain.User, error)
 	GetByUsername(ctx context.Context, username string) (*domain.User, error)
+	UpdatePassword(ctx context.Context, userID uint, hashedPassword string, changedAt time.Time) error
+	UpdatePasswordHash(ctx context.Context, userID uint, hashedPassword string) error
 	List(ctx context.Context, limit, offset int) ([]*domain.User, error)
+	Page(ctx context.Context, req PageRequest) (*Page[*domain.User], error)
+	SetAdmin(ctx context.Context, userID uint, isAdmin bool) error
 }
 
 type userRepository struct {
@@ -44,6 +49,21 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*d
 	return &user, nil
 }
 
+func (r *userRepository) UpdatePassword(ctx context.Context, userID uint, hashedPassword string, changedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&domain.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"password":            hashedPassword,
+			"password_changed_at": changedAt,
+		}).Error
+}
+
+func (r *userRepository) UpdatePasswordHash(ctx context.Context, userID uint, hashedPassword string) error {
+	return r.db.WithContext(ctx).Model(&domain.User{}).
+		Where("id = ?", userID).
+		Update("password", hashedPassword).Error
+}
+
 func (r *userRepository) List(ctx context.Context, limit, offset int) ([]*domain.User, error) {
 	var users []*domain.User
 	err := r.db.WithContext(ctx).
@@ -53,3 +73,18 @@ func (r *userRepository) List(ctx context.Context, limit, offset int) ([]*domain
 		Find(&users).Error
 	return users, err
 }
+
+func (r *userRepository) Page(ctx context.Context, req PageRequest) (*Page[*domain.User], error) {
+	query := r.db.WithContext(ctx).Model(&domain.User{})
+	return paginate(query, "users", req, userCursor, "Wallets")
+}
+
+func userCursor(user *domain.User) Cursor {
+	return Cursor{CreatedAt: user.CreatedAt, ID: user.ID}
+}
+
+func (r *userRepository) SetAdmin(ctx context.Context, userID uint, isAdmin bool) error {
+	return r.db.WithContext(ctx).Model(&domain.User{}).
+		Where("id = ?", userID).
+		Update("is_admin", isAdmin).Error
+}
@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"time"
 
+	"github.com/SahandMohammed/wallet-service/internal/dialect"
 	"github.com/SahandMohammed/wallet-service/internal/domain"
 	"gorm.io/gorm"
 )
@@ -10,9 +12,26 @@ import (
 type WalletRepository interface {
 	Create(ctx context.Context, wallet *domain.Wallet) error
 	GetByID(ctx context.Context, id uint) (*domain.Wallet, error)
+	// GetForUpdate locks the wallet row until the end of the transaction
+	GetForUpdate(ctx context.Context, id uint) (*domain.Wallet, error)
 	GetByUserID(ctx context.Context, userID uint) ([]*domain.Wallet, error)
 	Update(ctx context.Context, wallet *domain.Wallet) error
 	UpdateBalance(ctx context.Context, walletID uint, newBalance int64) error
+	SetFrozen(ctx context.Context, walletID uint, frozenAt *time.Time, reason string) error
+	Ledgers(ctx context.Context, mismatchedOnly bool) ([]*WalletLedger, error)
+}
+
+// WalletLedger compares a wallet balance with the sum of its transactions
+type WalletLedger struct {
+	WalletID     uint  `json:"wallet_id"`
+	UserID       uint  `json:"user_id"`
+	Balance      int64 `json:"balance"`
+	LedgerTotal  int64 `json:"ledger_total"`
+	Transactions int64 `json:"transactions"`
+}
+
+func (l *WalletLedger) Difference() int64 {
+	return l.Balance - l.LedgerTotal
 }
 
 type walletRepository struct {
@@ -36,6 +55,15 @@ func (r *walletRepository) GetByID(ctx context.Context, id uint) (*domain.Wallet
 	return &wallet, nil
 }
 
+func (r *walletRepository) GetForUpdate(ctx context.Context, id uint) (*domain.Wallet, error) {
+	var wallet domain.Wallet
+	err := r.db.WithContext(ctx).Scopes(dialect.ForUpdate).First(&wallet, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
 func (r *walletRepository) GetByUserID(ctx context.Context, userID uint) ([]*domain.Wallet, error) {
 	var wallets []*domain.Wallet
 	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&wallets).Error
@@ -51,3 +79,33 @@ func (r *walletRepository) UpdateBalance(ctx context.Context, walletID uint, new
 		Where("id = ?", walletID).
 		Update("balance", newBalance).Error
 }
+
+func (r *walletRepository) SetFrozen(ctx context.Context, walletID uint, frozenAt *time.Time, reason string) error {
+	return r.db.WithContext(ctx).Model(&domain.Wallet{}).
+		Where("id = ?", walletID).
+		Updates(map[string]interface{}{
+			"frozen_at":     frozenAt,
+			"frozen_reason": reason,
+		}).Error
+}
+
+// Ledgers sums the transactions of every wallet. Transfers out are stored
+// as negative amounts, so the sum equals the balance of a consistent wallet.
+func (r *walletRepository) Ledgers(ctx context.Context, mismatchedOnly bool) ([]*WalletLedger, error) {
+	query := r.db.WithContext(ctx).
+		Table("wallets").
+		Select("wallets.id AS wallet_id, wallets.user_id, wallets.balance, " +
+			"COALESCE(SUM(transactions.amount), 0) AS ledger_total, COUNT(transactions.id) AS transactions").
+		Joins("LEFT JOIN transactions ON transactions.wallet_id = wallets.id").
+		Where("wallets.deleted_at IS NULL").
+		Group("wallets.id, wallets.user_id, wallets.balance").
+		Order("wallets.id")
+
+	if mismatchedOnly {
+		query = query.Having("wallets.balance <> COALESCE(SUM(transactions.amount), 0)")
+	}
+
+	var ledgers []*WalletLedger
+	err := query.Scan(&ledgers).Error
+	return ledgers, err
+}
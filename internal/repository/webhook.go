@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/dialect"
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepository interface {
+	CreateEndpoint(ctx context.Context, endpoint *domain.WebhookEndpoint) error
+	GetEndpoint(ctx context.Context, id uint) (*domain.WebhookEndpoint, error)
+	GetEndpointsByUserID(ctx context.Context, userID uint) ([]*domain.WebhookEndpoint, error)
+	GetActiveEndpointsForUsers(ctx context.Context, userIDs []uint) ([]*domain.WebhookEndpoint, error)
+	DeleteEndpoint(ctx context.Context, id, userID uint) (bool, error)
+
+	CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error
+	GetDelivery(ctx context.Context, id uint) (*domain.WebhookDelivery, error)
+	// PageDeliveries returns the page of the endpoint's deliveries selected
+	// by req, newest first
+	PageDeliveries(ctx context.Context, endpointID uint, req PageRequest) (*Page[*domain.WebhookDelivery], error)
+	ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) CreateEndpoint(ctx context.Context, endpoint *domain.WebhookEndpoint) error {
+	return r.db.WithContext(ctx).Create(endpoint).Error
+}
+
+func (r *webhookRepository) GetEndpoint(ctx context.Context, id uint) (*domain.WebhookEndpoint, error) {
+	var endpoint domain.WebhookEndpoint
+	err := r.db.WithContext(ctx).First(&endpoint, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+func (r *webhookRepository) GetEndpointsByUserID(ctx context.Context, userID uint) ([]*domain.WebhookEndpoint, error) {
+	var endpoints []*domain.WebhookEndpoint
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&endpoints).Error
+	return endpoints, err
+}
+
+func (r *webhookRepository) GetActiveEndpointsForUsers(ctx context.Context, userIDs []uint) ([]*domain.WebhookEndpoint, error) {
+	var endpoints []*domain.WebhookEndpoint
+	err := r.db.WithContext(ctx).
+		Where("user_id IN ? AND active = ?", userIDs, true).
+		Find(&endpoints).Error
+	return endpoints, err
+}
+
+func (r *webhookRepository) DeleteEndpoint(ctx context.Context, id, userID uint) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", id, userID).
+		Delete(&domain.WebhookEndpoint{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// CreateDelivery ignores duplicates of the same event for the same endpoint,
+// which happen when the outbox relay publishes an event more than once.
+func (r *webhookRepository) CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(delivery).Error
+}
+
+func (r *webhookRepository) GetDelivery(ctx context.Context, id uint) (*domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+	err := r.db.WithContext(ctx).Preload("Endpoint").First(&delivery, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *webhookRepository) PageDeliveries(ctx context.Context, endpointID uint, req PageRequest) (*Page[*domain.WebhookDelivery], error) {
+	query := r.db.WithContext(ctx).Model(&domain.WebhookDelivery{}).Where("endpoint_id = ?", endpointID)
+	return paginate(query, "webhook_deliveries", req, deliveryCursor)
+}
+
+func deliveryCursor(delivery *domain.WebhookDelivery) Cursor {
+	return Cursor{CreatedAt: delivery.CreatedAt, ID: delivery.ID}
+}
+
+// ClaimDueDeliveries picks pending deliveries that are due and pushes their
+// next attempt out by lease, so other dispatchers skip them while they are
+// being sent. A dispatcher that dies mid-send leaves them to be retried once
+// the lease runs out.
+func (r *webhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.WebhookDelivery, error) {
+	var deliveries []*domain.WebhookDelivery
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Scopes(dialect.ForUpdateSkipLocked).
+			Where("status = ? AND next_attempt_at <= ?", domain.WebhookDeliveryPending, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, 0, len(deliveries))
+		for _, delivery := range deliveries {
+			ids = append(ids, delivery.ID)
+		}
+		return tx.Model(&domain.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil || len(deliveries) == 0 {
+		return deliveries, err
+	}
+
+	// Load endpoints, including ones deleted since the delivery was queued
+	for _, delivery := range deliveries {
+		if err := r.db.WithContext(ctx).Unscoped().First(&delivery.Endpoint, delivery.EndpointID).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return deliveries, nil
+}
+
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Model(delivery).Select(
+		"status",
+		"attempts",
+		"next_attempt_at",
+		"last_status_code",
+		"last_error",
+		"delivered_at",
+	).Updates(delivery).Error
+}
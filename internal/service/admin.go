@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"errors"
+	"strconv"
 	"time"
 
 	"github.com/SahandMohammed/wallet-service/internal/domain"
@@ -9,47 +11,144 @@ import (
 )
 
 type AdminService interface {
-	ListUsers(ctx context.Context, limit, offset int) ([]*domain.User, error)
-	ListTransactions(ctx context.Context, filters AdminTransactionFilters) ([]*domain.Transaction, error)
+	// PageUsers, PageTransactions and PageAuditLogs return the page selected
+	// by req, newest first.
+	PageUsers(ctx context.Context, req repository.PageRequest) (*repository.Page[*domain.User], error)
+	PageTransactions(ctx context.Context, filters AdminTransactionFilters, req repository.PageRequest) (*repository.Page[*domain.Transaction], error)
+	PageAuditLogs(ctx context.Context, filters repository.AuditLogFilters, req repository.PageRequest) (*repository.Page[*domain.AuditLog], error)
+	IsAdmin(ctx context.Context, userID uint) (bool, error)
+	SetAdmin(ctx context.Context, userID uint, isAdmin bool) error
+	// Reconcile compares wallet balances with their transactions. Only
+	// wallets that disagree are returned unless all is set.
+	Reconcile(ctx context.Context, all bool) ([]*repository.WalletLedger, error)
+	// ExportTransactions streams matching transactions to fn in batches
+	ExportTransactions(ctx context.Context, filters AdminTransactionFilters, fn func([]*domain.Transaction) error) error
 }
 
 type AdminTransactionFilters struct {
 	UserID    *uint
+	WalletID  *uint
 	Type      *domain.TransactionType
 	StartDate *time.Time
 	EndDate   *time.Time
-	Limit     int
-	Offset    int
 }
 
 type adminService struct {
 	userRepo        repository.UserRepository
+	walletRepo      repository.WalletRepository
 	transactionRepo repository.TransactionRepository
+	auditService    AuditService
 }
 
 func NewAdminService(
 	userRepo repository.UserRepository,
+	walletRepo repository.WalletRepository,
 	transactionRepo repository.TransactionRepository,
+	auditService AuditService,
 ) AdminService {
 	return &adminService{
 		userRepo:        userRepo,
+		walletRepo:      walletRepo,
 		transactionRepo: transactionRepo,
+		auditService:    auditService,
 	}
 }
 
-func (s *adminService) ListUsers(ctx context.Context, limit, offset int) ([]*domain.User, error) {
-	return s.userRepo.List(ctx, limit, offset)
+func (s *adminService) PageUsers(ctx context.Context, req repository.PageRequest) (*repository.Page[*domain.User], error) {
+	s.auditService.Record(ctx, AuditEvent{
+		Action: AuditActionAdminListUsers,
+		After:  req,
+	})
+
+	return s.userRepo.Page(ctx, req)
+}
+
+func (s *adminService) PageTransactions(ctx context.Context, filters AdminTransactionFilters, req repository.PageRequest) (*repository.Page[*domain.Transaction], error) {
+	repoFilters := filters.repository()
+
+	s.auditService.Record(ctx, AuditEvent{
+		Action: AuditActionAdminListTxs,
+		After:  pageAuditDetails{Filters: repoFilters, Page: req},
+	})
+
+	return s.transactionRepo.Page(ctx, repoFilters, req)
+}
+
+func (s *adminService) PageAuditLogs(ctx context.Context, filters repository.AuditLogFilters, req repository.PageRequest) (*repository.Page[*domain.AuditLog], error) {
+	s.auditService.Record(ctx, AuditEvent{
+		Action: AuditActionAdminListAuditLog,
+		After:  pageAuditDetails{Filters: filters, Page: req},
+	})
+
+	return s.auditService.Page(ctx, filters, req)
+}
+
+// pageAuditDetails is what a paged listing records in the audit log
+type pageAuditDetails struct {
+	Filters interface{}
+	Page    repository.PageRequest
+}
+
+func (s *adminService) IsAdmin(ctx context.Context, userID uint) (bool, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return user.IsAdmin, nil
+}
+
+func (s *adminService) SetAdmin(ctx context.Context, userID uint, isAdmin bool) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.IsAdmin == isAdmin {
+		return nil
+	}
+
+	if err := s.userRepo.SetAdmin(ctx, userID, isAdmin); err != nil {
+		return err
+	}
+
+	action := AuditActionAdminGrant
+	if !isAdmin {
+		action = AuditActionAdminRevoke
+	}
+	s.auditService.Record(ctx, AuditEvent{
+		Action:     action,
+		TargetType: "user",
+		TargetID:   strconv.FormatUint(uint64(userID), 10),
+		Before:     map[string]interface{}{"is_admin": user.IsAdmin},
+		After:      map[string]interface{}{"is_admin": isAdmin},
+	})
+	return nil
 }
 
-func (s *adminService) ListTransactions(ctx context.Context, filters AdminTransactionFilters) ([]*domain.Transaction, error) {
-	repoFilters := repository.TransactionFilters{
-		UserID:    filters.UserID,
-		Type:      filters.Type,
-		StartDate: filters.StartDate,
-		EndDate:   filters.EndDate,
-		Limit:     filters.Limit,
-		Offset:    filters.Offset,
+func (s *adminService) Reconcile(ctx context.Context, all bool) ([]*repository.WalletLedger, error) {
+	return s.walletRepo.Ledgers(ctx, !all)
+}
+
+func (s *adminService) ExportTransactions(ctx context.Context, filters AdminTransactionFilters, fn func([]*domain.Transaction) error) error {
+	if fn == nil {
+		return errors.New("export callback is required")
 	}
 
-	return s.transactionRepo.List(ctx, repoFilters)
+	repoFilters := filters.repository()
+
+	s.auditService.Record(ctx, AuditEvent{
+		Action: AuditActionAdminExportTxs,
+		After:  repoFilters,
+	})
+
+	return s.transactionRepo.ListInBatches(ctx, repoFilters, 500, fn)
+}
+
+func (f AdminTransactionFilters) repository() repository.TransactionFilters {
+	return repository.TransactionFilters{
+		UserID:    f.UserID,
+		WalletID:  f.WalletID,
+		Type:      f.Type,
+		StartDate: f.StartDate,
+		EndDate:   f.EndDate,
+	}
 }
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/config"
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/logging"
+	"github.com/SahandMohammed/wallet-service/internal/repository"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// APIKeyPrefix marks a credential as an API key rather than a JWT
+const APIKeyPrefix = "wsk_"
+
+// The identifying prefix is APIKeyPrefix followed by 8 hex characters
+const apiKeyPrefixLength = len(APIKeyPrefix) + 8
+
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, userID uint, input CreateAPIKeyInput) (*domain.APIKey, string, error)
+	ListAPIKeys(ctx context.Context, userID uint) ([]*domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID uint) error
+	Authenticate(ctx context.Context, rawKey string) (*domain.APIKey, error)
+	SigningSecret(key *domain.APIKey) string
+}
+
+type CreateAPIKeyInput struct {
+	Name      string
+	Scopes    []domain.APIKeyScope
+	WalletIDs []uint
+	ExpiresAt *time.Time
+}
+
+type apiKeyService struct {
+	apiKeyRepo   repository.APIKeyRepository
+	walletRepo   repository.WalletRepository
+	auditService AuditService
+	config       *config.Config
+}
+
+func NewAPIKeyService(
+	apiKeyRepo repository.APIKeyRepository,
+	walletRepo repository.WalletRepository,
+	auditService AuditService,
+	config *config.Config,
+) APIKeyService {
+	return &apiKeyService{
+		apiKeyRepo:   apiKeyRepo,
+		walletRepo:   walletRepo,
+		auditService: auditService,
+		config:       config,
+	}
+}
+
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, userID uint, input CreateAPIKeyInput) (*domain.APIKey, string, error) {
+	if len(input.Scopes) == 0 {
+		return nil, "", errors.New("at least one scope is required")
+	}
+	for _, scope := range input.Scopes {
+		if !isKnownScope(scope) {
+			return nil, "", fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return nil, "", errors.New("expiry must be in the future")
+	}
+
+	// Keys can only be limited to wallets the user owns
+	for _, walletID := range input.WalletIDs {
+		wallet, err := s.walletRepo.GetByID(ctx, walletID)
+		if err != nil || wallet.UserID != userID {
+			return nil, "", fmt.Errorf("wallet %d not found", walletID)
+		}
+	}
+
+	prefixBytes := make([]byte, 4)
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return nil, "", err
+	}
+	if _, err := rand.Read(secretBytes); err != nil {
+		return nil, "", err
+	}
+
+	prefix := APIKeyPrefix + hex.EncodeToString(prefixBytes)
+	rawKey := prefix + base64.RawURLEncoding.EncodeToString(secretBytes)
+
+	key := &domain.APIKey{
+		UserID:    userID,
+		Name:      input.Name,
+		Prefix:    prefix,
+		KeyHash:   hashAPIKey(rawKey),
+		Scopes:    input.Scopes,
+		WalletIDs: input.WalletIDs,
+		ExpiresAt: input.ExpiresAt,
+	}
+
+	if err := s.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"user_id":    userID,
+		"api_key_id": key.ID,
+		"prefix":     key.Prefix,
+		"action":     "api_key_created",
+	}).Info("API key created")
+
+	s.auditService.Record(ctx, AuditEvent{
+		Action:     AuditActionAPIKeyCreate,
+		TargetType: "api_key",
+		TargetID:   strconv.FormatUint(uint64(key.ID), 10),
+		After: map[string]interface{}{
+			"name":       key.Name,
+			"prefix":     key.Prefix,
+			"scopes":     key.Scopes,
+			"wallet_ids": key.WalletIDs,
+			"expires_at": key.ExpiresAt,
+		},
+	})
+
+	return key, rawKey, nil
+}
+
+func (s *apiKeyService) ListAPIKeys(ctx context.Context, userID uint) ([]*domain.APIKey, error) {
+	return s.apiKeyRepo.GetByUserID(ctx, userID)
+}
+
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, userID, keyID uint) error {
+	revoked, err := s.apiKeyRepo.Revoke(ctx, keyID, userID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return errors.New("API key not found")
+	}
+
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"user_id":    userID,
+		"api_key_id": keyID,
+		"action":     "api_key_revoked",
+	}).Info("API key revoked")
+
+	s.auditService.Record(ctx, AuditEvent{
+		Action:     AuditActionAPIKeyRevoke,
+		TargetType: "api_key",
+		TargetID:   strconv.FormatUint(uint64(keyID), 10),
+	})
+
+	return nil
+}
+
+func (s *apiKeyService) Authenticate(ctx context.Context, rawKey string) (*domain.APIKey, error) {
+	if !strings.HasPrefix(rawKey, APIKeyPrefix) || len(rawKey) <= apiKeyPrefixLength {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.apiKeyRepo.GetByPrefix(ctx, rawKey[:apiKeyPrefixLength])
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(hashAPIKey(rawKey))) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && now.After(*key.ExpiresAt)) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	// Record usage at most once a minute to avoid a write per request
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > time.Minute {
+		if err := s.apiKeyRepo.TouchLastUsed(ctx, key.ID, now); err != nil {
+			logging.FromContext(ctx).WithError(err).WithField("api_key_id", key.ID).Warn("Failed to record API key usage")
+		}
+	}
+
+	return key, nil
+}
+
+// SigningSecret derives the request signing secret for a key from the server
+// master secret, so it never has to be stored. Rotating the master secret
+// rotates every key's signing secret.
+func (s *apiKeyService) SigningSecret(key *domain.APIKey) string {
+	mac := hmac.New(sha256.New, []byte(s.config.RequestSigningSecret))
+	mac.Write([]byte("request-signing:" + key.Prefix))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func isKnownScope(scope domain.APIKeyScope) bool {
+	for _, known := range domain.APIKeyScopes {
+		if scope == known {
+			return true
+		}
+	}
+	return false
+}
+
+func hashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/audit"
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/logging"
+	"github.com/SahandMohammed/wallet-service/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	AuditActionRegister          = "user.register"
+	AuditActionLogin             = "user.login"
+	AuditActionLoginFailed       = "user.login_failed"
+	AuditActionPasswordChange    = "user.password_change"
+	AuditActionPasswordReset     = "user.password_reset"
+	AuditActionSessionRevoke     = "session.revoke"
+	AuditActionAPIKeyCreate      = "api_key.create"
+	AuditActionAPIKeyRevoke      = "api_key.revoke"
+	AuditActionWalletCreate      = "wallet.create"
+	AuditActionDeposit           = "wallet.deposit"
+	AuditActionTransfer          = "wallet.transfer"
+	AuditActionAdjust            = "wallet.adjust"
+	AuditActionFreeze            = "wallet.freeze"
+	AuditActionUnfreeze          = "wallet.unfreeze"
+	AuditActionAdminListUsers    = "admin.list_users"
+	AuditActionAdminListTxs      = "admin.list_transactions"
+	AuditActionAdminListAuditLog = "admin.list_audit_logs"
+	AuditActionAdminGrant        = "admin.grant"
+	AuditActionAdminRevoke       = "admin.revoke"
+	AuditActionAdminExportTxs    = "admin.export_transactions"
+)
+
+// AuditEvent describes an action to be recorded. ActorID and ActorName
+// override the authenticated user from the context, for actions such as login
+// that happen before authentication.
+type AuditEvent struct {
+	Action     string
+	TargetType string
+	TargetID   string
+	Before     interface{}
+	After      interface{}
+	ActorID    *uint
+	ActorName  string
+}
+
+type AuditService interface {
+	// Record writes the entry on its own. Failures are logged but never
+	// returned, auditing must not break the audited operation.
+	Record(ctx context.Context, event AuditEvent)
+	// NewEntry builds the entry without writing it, so that callers can
+	// persist it inside their own database transaction.
+	NewEntry(ctx context.Context, event AuditEvent) *domain.AuditLog
+	Page(ctx context.Context, filters repository.AuditLogFilters, req repository.PageRequest) (*repository.Page[*domain.AuditLog], error)
+	PurgeExpired(ctx context.Context) (int64, error)
+	RunRetention(ctx context.Context, interval time.Duration)
+}
+
+type auditService struct {
+	auditRepo repository.AuditLogRepository
+	retention time.Duration
+}
+
+func NewAuditService(auditRepo repository.AuditLogRepository, retention time.Duration) AuditService {
+	return &auditService{
+		auditRepo: auditRepo,
+		retention: retention,
+	}
+}
+
+func (s *auditService) Record(ctx context.Context, event AuditEvent) {
+	entry := s.NewEntry(ctx, event)
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		logging.FromContext(ctx).WithError(err).WithFields(logrus.Fields{
+			"audit_action": entry.Action,
+			"target_type":  entry.TargetType,
+			"target_id":    entry.TargetID,
+		}).Error("Failed to write audit log entry")
+	}
+}
+
+func (s *auditService) NewEntry(ctx context.Context, event AuditEvent) *domain.AuditLog {
+	md := audit.FromContext(ctx)
+
+	entry := &domain.AuditLog{
+		ActorID:    md.ActorID,
+		ActorName:  md.ActorName,
+		Action:     event.Action,
+		TargetType: event.TargetType,
+		TargetID:   event.TargetID,
+		Before:     snapshot(event.Before),
+		After:      snapshot(event.After),
+		IPAddress:  md.IPAddress,
+		UserAgent:  truncate(md.UserAgent, 255),
+		RequestID:  md.RequestID,
+	}
+
+	if event.ActorID != nil {
+		entry.ActorID = event.ActorID
+	}
+	if event.ActorName != "" {
+		entry.ActorName = event.ActorName
+	}
+
+	return entry
+}
+
+func (s *auditService) Page(ctx context.Context, filters repository.AuditLogFilters, req repository.PageRequest) (*repository.Page[*domain.AuditLog], error) {
+	return s.auditRepo.Page(ctx, filters, req)
+}
+
+// PurgeExpired deletes entries older than the retention period. A zero
+// retention keeps entries forever.
+func (s *auditService) PurgeExpired(ctx context.Context) (int64, error) {
+	if s.retention <= 0 {
+		return 0, nil
+	}
+	return s.auditRepo.DeleteBefore(ctx, time.Now().Add(-s.retention))
+}
+
+// RunRetention purges expired entries every interval until ctx is cancelled
+func (s *auditService) RunRetention(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := s.PurgeExpired(ctx)
+		if err != nil {
+			logging.FromContext(ctx).WithError(err).Error("Failed to purge expired audit log entries")
+		} else if deleted > 0 {
+			logging.FromContext(ctx).WithField("deleted", deleted).Info("Purged expired audit log entries")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func snapshot(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/db"
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/repository"
+	"github.com/glebarez/sqlite"
+)
+
+// newAuditRepo returns the SQL audit log repository on a migrated SQLite
+// database holding one entry per age, named by its action
+func newAuditRepo(t *testing.T, ages map[string]time.Duration) repository.AuditLogRepository {
+	t.Helper()
+	gormDB := openMigrated(t, sqlite.Open(db.SQLiteDSN(filepath.Join(t.TempDir(), "wallet.db"))))
+	repo := repository.NewAuditLogRepository(gormDB)
+
+	now := time.Now()
+	for action, age := range ages {
+		if err := repo.Create(context.Background(), &domain.AuditLog{Action: action, CreatedAt: now.Add(-age)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return repo
+}
+
+func auditActions(t *testing.T, repo repository.AuditLogRepository) []string {
+	t.Helper()
+	entries, err := repo.List(context.Background(), repository.AuditLogFilters{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		actions = append(actions, entry.Action)
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+func TestPurgeExpired(t *testing.T) {
+	day := 24 * time.Hour
+	repo := newAuditRepo(t, map[string]time.Duration{
+		"expired.long_ago": 400 * day,
+		"expired.just":     30*day + time.Hour,
+		"kept.almost":      30*day - time.Hour,
+		"kept.recent":      time.Minute,
+	})
+
+	deleted, err := NewAuditService(repo, 30*day).PurgeExpired(context.Background())
+	if err != nil {
+		t.Fatalf("PurgeExpired: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("expected 2 entries deleted, got %d", deleted)
+	}
+	if actions := auditActions(t, repo); len(actions) != 2 || actions[0] != "kept.almost" || actions[1] != "kept.recent" {
+		t.Errorf("expected only the entries inside the retention period, got %v", actions)
+	}
+
+	// Nothing is left to purge on the next run
+	if deleted, err := NewAuditService(repo, 30*day).PurgeExpired(context.Background()); err != nil || deleted != 0 {
+		t.Errorf("expected nothing to purge, got %d, %v", deleted, err)
+	}
+}
+
+func TestPurgeExpiredDisabled(t *testing.T) {
+	repo := newAuditRepo(t, map[string]time.Duration{
+		"old":    10 * 365 * 24 * time.Hour,
+		"recent": time.Minute,
+	})
+
+	deleted, err := NewAuditService(repo, 0).PurgeExpired(context.Background())
+	if err != nil {
+		t.Fatalf("PurgeExpired: %v", err)
+	}
+	if deleted != 0 {
+		t.Errorf("expected a zero retention to keep every entry, %d deleted", deleted)
+	}
+	if actions := auditActions(t, repo); len(actions) != 2 {
+		t.Errorf("expected both entries kept, got %v", actions)
+	}
+}
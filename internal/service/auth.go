@@ -2,61 +2,107 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"regexp"
+	"strconv"
 	"time"
 
+	"github.com/SahandMohammed/wallet-service/internal/cache"
 	"github.com/SahandMohammed/wallet-service/internal/config"
+	"github.com/SahandMohammed/wallet-service/internal/credential"
+	"github.com/SahandMohammed/wallet-service/internal/dialect"
 	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/logging"
+	"github.com/SahandMohammed/wallet-service/internal/notification"
 	"github.com/SahandMohammed/wallet-service/internal/repository"
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/redis/go-redis/v9"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
 type AuthService interface {
 	Register(ctx context.Context, username, password string) (*domain.User, error)
-	Login(ctx context.Context, username, password string) (string, error)
-	ValidateToken(tokenString string) (*Claims, error)
+	Login(ctx context.Context, username, password string, client ClientInfo) (string, error)
+	ValidateToken(ctx context.Context, tokenString string) (*Claims, error)
+	ChangePassword(ctx context.Context, userID uint, currentPassword, newPassword string, client ClientInfo) (string, error)
+	RequestPasswordReset(ctx context.Context, username string) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
+	ListSessions(ctx context.Context, userID uint) ([]*domain.Session, error)
+	RevokeSession(ctx context.Context, userID uint, sessionID string) error
 }
 
+// ClientInfo describes the client a login comes from
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+const (
+	tokenTTL         = 24 * time.Hour
+	passwordResetTTL = 30 * time.Minute
+)
+
+var ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
 type Claims struct {
-	UserID   uint   `json:"user_id"`
-	Username string `json:"username"`
+	UserID    uint   `json:"user_id"`
+	Username  string `json:"username"`
+	SessionID string `json:"sid"`
 	jwt.RegisteredClaims
 }
 
 type authService struct {
-	userRepo    repository.UserRepository
-	config      *config.Config
-	redisClient *redis.Client
+	userRepo          repository.UserRepository
+	passwordResetRepo repository.PasswordResetRepository
+	sessionRepo       repository.SessionRepository
+	auditService      AuditService
+	notifier          notification.Notifier
+	policy            *credential.Policy
+	hasher            credential.Hasher
+	config            *config.Config
+	cache             cache.Cache
+	unitOfWork        repository.UnitOfWork
 }
 
-func NewAuthService(userRepo repository.UserRepository, config *config.Config, redisClient *redis.Client) AuthService {
+func NewAuthService(
+	userRepo repository.UserRepository,
+	passwordResetRepo repository.PasswordResetRepository,
+	sessionRepo repository.SessionRepository,
+	auditService AuditService,
+	notifier notification.Notifier,
+	policy *credential.Policy,
+	hasher credential.Hasher,
+	config *config.Config,
+	cache cache.Cache,
+	unitOfWork repository.UnitOfWork,
+) AuthService {
 	return &authService{
-		userRepo:    userRepo,
-		config:      config,
-		redisClient: redisClient,
+		userRepo:          userRepo,
+		passwordResetRepo: passwordResetRepo,
+		sessionRepo:       sessionRepo,
+		auditService:      auditService,
+		notifier:          notifier,
+		policy:            policy,
+		hasher:            hasher,
+		config:            config,
+		cache:             cache,
+		unitOfWork:        unitOfWork,
 	}
 }
 
 func (s *authService) Register(ctx context.Context, username, password string) (*domain.User, error) {
-	// Validate username length
-	if len(username) < 3 || len(username) > 50 {
-		return nil, errors.New("username must be between 3 and 50 characters")
-	}
-
-	// Validate username contains only alphabetic characters
-	if !regexp.MustCompile(`^[A-Za-z]+$`).MatchString(username) {
-		return nil, errors.New("username must contain only alphabetic characters")
+	if err := s.policy.ValidateUsername(username); err != nil {
+		return nil, err
 	}
 
-	// Validate password length
-	if len(password) < 8 || len(password) > 15 {
-		return nil, errors.New("password must be between 8 and 15 characters")
+	if err := s.policy.ValidatePassword(password); err != nil {
+		return nil, err
 	}
 
 	// Check if username already exists
@@ -69,7 +115,7 @@ func (s *authService) Register(ctx context.Context, username, password string) (
 	}
 
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := s.hasher.Hash(password)
 	if err != nil {
 		return nil, err
 	}
@@ -77,42 +123,89 @@ func (s *authService) Register(ctx context.Context, username, password string) (
 	// Create user
 	user := &domain.User{
 		Username: username,
-		Password: string(hashedPassword),
+		Password: hashedPassword,
 	}
 
 	if err := s.userRepo.Create(ctx, user); err != nil {
+		// A concurrent registration took the name after the check above
+		if dialect.IsUniqueViolation(err) {
+			return nil, errors.New("username already exists")
+		}
 		return nil, err
 	}
 
 	// Cache the user for future access
 	s.cacheUser(ctx, user)
 
+	s.auditService.Record(ctx, AuditEvent{
+		Action:     AuditActionRegister,
+		TargetType: "user",
+		TargetID:   strconv.FormatUint(uint64(user.ID), 10),
+		After:      map[string]interface{}{"id": user.ID, "username": user.Username},
+		ActorID:    &user.ID,
+		ActorName:  user.Username,
+	})
+
 	return user, nil
 }
 
-func (s *authService) Login(ctx context.Context, username, password string) (string, error) {
+func (s *authService) Login(ctx context.Context, username, password string, client ClientInfo) (string, error) {
 	// Get user by username from database
 	user, err := s.userRepo.GetByUsername(ctx, username)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.recordFailedLogin(ctx, username, nil)
 			return "", errors.New("invalid credentials")
 		}
 		return "", err
 	}
 
 	// Check password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+	if ok, err := s.hasher.Verify(password, user.Password); err != nil || !ok {
+		s.recordFailedLogin(ctx, username, &user.ID)
 		return "", errors.New("invalid credentials")
 	}
 
+	// Upgrade hashes created with an older algorithm or weaker parameters
+	if s.hasher.NeedsRehash(user.Password) {
+		s.rehashPassword(ctx, user, password)
+	}
+
 	// Cache the user for future access
 	s.cacheUser(ctx, user)
 
-	// Generate JWT token
-	return s.generateToken(user)
+	// Create a session and a JWT token bound to it
+	token, session, err := s.startSession(ctx, user, client)
+	if err != nil {
+		return "", err
+	}
+
+	s.auditService.Record(ctx, AuditEvent{
+		Action:     AuditActionLogin,
+		TargetType: "session",
+		TargetID:   session.ID,
+		After:      map[string]interface{}{"session_id": session.ID, "user_agent": session.UserAgent},
+		ActorID:    &user.ID,
+		ActorName:  user.Username,
+	})
+
+	return token, nil
+}
+
+func (s *authService) recordFailedLogin(ctx context.Context, username string, userID *uint) {
+	event := AuditEvent{
+		Action:     AuditActionLoginFailed,
+		TargetType: "user",
+		ActorID:    userID,
+		ActorName:  truncate(username, 50),
+	}
+	if userID != nil {
+		event.TargetID = strconv.FormatUint(uint64(*userID), 10)
+	}
+	s.auditService.Record(ctx, event)
 }
 
-func (s *authService) ValidateToken(tokenString string) (*Claims, error) {
+func (s *authService) ValidateToken(ctx context.Context, tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		return []byte(s.config.AppJWTSecret), nil
 	})
@@ -121,20 +214,395 @@ func (s *authService) ValidateToken(tokenString string) (*Claims, error) {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid || claims.SessionID == "" {
+		return nil, errors.New("invalid token")
+	}
+
+	// Reject tokens whose session was revoked
+	session, err := s.getSession(ctx, claims.SessionID)
+	if err != nil || session.UserID != claims.UserID {
+		return nil, errors.New("invalid token")
+	}
+	now := time.Now()
+	if !session.Active(now) {
+		return nil, errors.New("session has been revoked")
+	}
+	if now.Sub(session.LastSeenAt) > time.Minute {
+		if err := s.sessionRepo.TouchLastSeen(ctx, session.ID, now); err != nil {
+			logging.FromContext(ctx).WithError(err).WithField("session_id", session.ID).Warn("Failed to record session activity")
+		}
+		s.cache.Delete(ctx, sessionCacheKey(session.ID))
+	}
+
+	// Reject tokens issued before the last password change
+	user, err := s.getUser(ctx, claims.UserID)
+	if err != nil {
+		return nil, errors.New("invalid token")
+	}
+	if user.PasswordChangedAt != nil && claims.IssuedAt != nil &&
+		claims.IssuedAt.Time.Before(user.PasswordChangedAt.Truncate(time.Second)) {
+		return nil, errors.New("token has been revoked")
 	}
 
-	return nil, errors.New("invalid token")
+	return claims, nil
 }
 
-// generateToken creates a JWT token for the given user
-func (s *authService) generateToken(user *domain.User) (string, error) {
-	claims := &Claims{
+func (s *authService) ChangePassword(ctx context.Context, userID uint, currentPassword, newPassword string, client ClientInfo) (string, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	if ok, err := s.hasher.Verify(currentPassword, user.Password); err != nil || !ok {
+		return "", errors.New("current password is incorrect")
+	}
+
+	if err := s.policy.ValidatePassword(newPassword); err != nil {
+		return "", err
+	}
+
+	if err := s.setPassword(ctx, user, newPassword, nil); err != nil {
+		return "", err
+	}
+
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"user_id": user.ID,
+		"action":  "password_changed",
+	}).Info("Password changed")
+
+	s.auditService.Record(ctx, AuditEvent{
+		Action:     AuditActionPasswordChange,
+		TargetType: "user",
+		TargetID:   strconv.FormatUint(uint64(user.ID), 10),
+	})
+
+	// Existing sessions are now revoked, so hand the caller a fresh one
+	token, _, err := s.startSession(ctx, user, client)
+	return token, err
+}
+
+func (s *authService) RequestPasswordReset(ctx context.Context, username string) error {
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		// Don't reveal whether the username exists
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	rawToken := make([]byte, 32)
+	if _, err := rand.Read(rawToken); err != nil {
+		return err
+	}
+	token := base64.RawURLEncoding.EncodeToString(rawToken)
+
+	// Only one reset token may be outstanding per user
+	now := time.Now()
+	if err := s.passwordResetRepo.InvalidateForUser(ctx, user.ID, now); err != nil {
+		return err
+	}
+
+	resetToken := &domain.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashResetToken(token),
+		ExpiresAt: now.Add(passwordResetTTL),
+	}
+	if err := s.passwordResetRepo.Create(ctx, resetToken); err != nil {
+		return err
+	}
+
+	return s.notifier.Send(ctx, notification.Message{
+		Kind:     notification.KindPasswordReset,
 		UserID:   user.ID,
 		Username: user.Username,
+		Subject:  "Password reset requested",
+		Body:     fmt.Sprintf("Use this token to reset your password. It expires in %s.", passwordResetTTL),
+		Data: map[string]string{
+			"token":      token,
+			"expires_at": resetToken.ExpiresAt.UTC().Format(time.RFC3339),
+		},
+		CreatedAt: now.UTC(),
+	})
+}
+
+func (s *authService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	resetToken, err := s.passwordResetRepo.GetByTokenHash(ctx, hashResetToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+
+	now := time.Now()
+	if resetToken.UsedAt != nil || now.After(resetToken.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	if err := s.policy.ValidatePassword(newPassword); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, resetToken.UserID)
+	if err != nil {
+		return err
+	}
+
+	// The token is only used up if the new password is stored
+	err = s.setPassword(ctx, user, newPassword, func(tx repository.Tx, changedAt time.Time) error {
+		consumed, err := tx.PasswordResets().MarkUsed(ctx, resetToken.ID, changedAt)
+		if err != nil {
+			return err
+		}
+		if !consumed {
+			return ErrInvalidResetToken
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"user_id": user.ID,
+		"action":  "password_reset",
+	}).Info("Password reset completed")
+
+	s.auditService.Record(ctx, AuditEvent{
+		Action:     AuditActionPasswordReset,
+		TargetType: "user",
+		TargetID:   strconv.FormatUint(uint64(user.ID), 10),
+		ActorID:    &user.ID,
+		ActorName:  user.Username,
+	})
+
+	return nil
+}
+
+func (s *authService) ListSessions(ctx context.Context, userID uint) ([]*domain.Session, error) {
+	return s.sessionRepo.GetActiveByUserID(ctx, userID, time.Now())
+}
+
+func (s *authService) RevokeSession(ctx context.Context, userID uint, sessionID string) error {
+	revoked, err := s.sessionRepo.Revoke(ctx, sessionID, userID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return errors.New("session not found")
+	}
+
+	s.cache.Delete(ctx, sessionCacheKey(sessionID))
+
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"user_id":    userID,
+		"session_id": sessionID,
+		"action":     "session_revoked",
+	}).Info("Session revoked")
+
+	s.auditService.Record(ctx, AuditEvent{
+		Action:     AuditActionSessionRevoke,
+		TargetType: "session",
+		TargetID:   sessionID,
+	})
+
+	return nil
+}
+
+// startSession records a session for the client and issues a token bound to
+// it. The user is notified when the login comes from a device not seen before.
+func (s *authService) startSession(ctx context.Context, user *domain.User, client ClientInfo) (string, *domain.Session, error) {
+	deviceHash := hashDevice(client.UserAgent)
+
+	knownDevice, err := s.sessionRepo.HasDevice(ctx, user.ID, deviceHash)
+	if err != nil {
+		return "", nil, err
+	}
+	previousSessions, err := s.sessionRepo.CountByUserID(ctx, user.ID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	session := &domain.Session{
+		ID:         uuid.New().String(),
+		UserID:     user.ID,
+		UserAgent:  truncate(client.UserAgent, 255),
+		IPAddress:  client.IPAddress,
+		DeviceHash: deviceHash,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(tokenTTL),
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return "", nil, err
+	}
+
+	// The first ever login is not a "new" device
+	if !knownDevice && previousSessions > 0 {
+		s.notifyNewDevice(ctx, user, session)
+	}
+
+	token, err := s.generateToken(user, session.ID)
+	return token, session, err
+}
+
+func (s *authService) notifyNewDevice(ctx context.Context, user *domain.User, session *domain.Session) {
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"user_id":    user.ID,
+		"session_id": session.ID,
+		"ip_address": session.IPAddress,
+		"user_agent": session.UserAgent,
+		"action":     "new_device_login",
+	}).Warn("Login from new device")
+
+	err := s.notifier.Send(ctx, notification.Message{
+		Kind:     notification.KindNewDeviceLogin,
+		UserID:   user.ID,
+		Username: user.Username,
+		Subject:  "New sign-in to your account",
+		Body:     "Your account was just used to sign in from a new device. If this wasn't you, revoke the session and change your password.",
+		Data: map[string]string{
+			"session_id": session.ID,
+			"ip_address": session.IPAddress,
+			"user_agent": session.UserAgent,
+		},
+		CreatedAt: session.CreatedAt.UTC(),
+	})
+	if err != nil {
+		logging.FromContext(ctx).WithError(err).WithField("user_id", user.ID).Warn("Failed to send new device notification")
+	}
+}
+
+// revokeAllSessions logs the user out everywhere
+func (s *authService) revokeAllSessions(ctx context.Context, userID uint) error {
+	ids, err := s.sessionRepo.RevokeAllForUser(ctx, userID, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		s.cache.Delete(ctx, sessionCacheKey(id))
+	}
+	return nil
+}
+
+// getSession loads a session from the cache, falling back to the database
+func (s *authService) getSession(ctx context.Context, sessionID string) (*domain.Session, error) {
+	cacheKey := sessionCacheKey(sessionID)
+	if cached, err := s.cache.Get(ctx, cacheKey); err == nil {
+		var session domain.Session
+		if json.Unmarshal(cached, &session) == nil {
+			return &session, nil
+		}
+	}
+
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if sessionJSON, err := json.Marshal(session); err == nil {
+		s.cache.Set(ctx, cacheKey, sessionJSON, time.Minute)
+	}
+
+	return session, nil
+}
+
+// setPassword stores a new password hash and revokes all existing sessions.
+// within, when set, runs in the same unit of work as the update, so that
+// either both or neither take effect.
+func (s *authService) setPassword(ctx context.Context, user *domain.User, password string, within func(tx repository.Tx, changedAt time.Time) error) error {
+	hashedPassword, err := s.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	changedAt := time.Now()
+	err = s.unitOfWork.Do(ctx, func(tx repository.Tx) error {
+		if within != nil {
+			if err := within(tx, changedAt); err != nil {
+				return err
+			}
+		}
+		return tx.Users().UpdatePassword(ctx, user.ID, hashedPassword, changedAt)
+	})
+	if err != nil {
+		return err
+	}
+
+	user.Password = hashedPassword
+	user.PasswordChangedAt = &changedAt
+	s.cacheUser(ctx, user)
+
+	return s.revokeAllSessions(ctx, user.ID)
+}
+
+// getUser loads a user from the cache, falling back to the database
+func (s *authService) getUser(ctx context.Context, userID uint) (*domain.User, error) {
+	idKey := fmt.Sprintf("user:id:%d", userID)
+	if cached, err := s.cache.Get(ctx, idKey); err == nil {
+		var user domain.User
+		if json.Unmarshal(cached, &user) == nil {
+			return &user, nil
+		}
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cacheUser(ctx, user)
+	return user, nil
+}
+
+// rehashPassword replaces the stored hash without revoking existing tokens.
+// Failures are logged only, the user can still log in with the old hash.
+func (s *authService) rehashPassword(ctx context.Context, user *domain.User, password string) {
+	hashedPassword, err := s.hasher.Hash(password)
+	if err == nil {
+		err = s.userRepo.UpdatePasswordHash(ctx, user.ID, hashedPassword)
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithError(err).WithField("user_id", user.ID).Warn("Failed to rehash password")
+		return
+	}
+
+	user.Password = hashedPassword
+}
+
+func sessionCacheKey(sessionID string) string {
+	return fmt.Sprintf("session:%s", sessionID)
+}
+
+// hashDevice fingerprints a client by user agent. IP addresses change too
+// often on mobile networks to be part of the device identity.
+func hashDevice(userAgent string) string {
+	sum := sha256.Sum256([]byte(userAgent))
+	return hex.EncodeToString(sum[:])
+}
+
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+	return s
+}
+
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateToken creates a JWT token for the given user and session
+func (s *authService) generateToken(user *domain.User, sessionID string) (string, error) {
+	claims := &Claims{
+		UserID:    user.ID,
+		Username:  user.Username,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(tokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
@@ -148,7 +616,7 @@ func (s *authService) generateToken(user *domain.User) (string, error) {
 	return tokenString, nil
 }
 
-// cacheUser stores user data in Redis with appropriate TTL
+// cacheUser stores user data in the cache with appropriate TTL
 func (s *authService) cacheUser(ctx context.Context, user *domain.User) {
 	userJSON, err := json.Marshal(user)
 	if err != nil {
@@ -157,9 +625,9 @@ func (s *authService) cacheUser(ctx context.Context, user *domain.User) {
 
 	// Cache by username (for login)
 	usernameKey := fmt.Sprintf("user:username:%s", user.Username)
-	s.redisClient.Set(ctx, usernameKey, userJSON, 10*time.Minute)
+	s.cache.Set(ctx, usernameKey, userJSON, 10*time.Minute)
 
 	// Cache by ID (for other operations)
 	idKey := fmt.Sprintf("user:id:%d", user.ID)
-	s.redisClient.Set(ctx, idKey, userJSON, 10*time.Minute)
+	s.cache.Set(ctx, idKey, userJSON, 10*time.Minute)
 }
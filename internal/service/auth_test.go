@@ -0,0 +1,374 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/cache"
+	"github.com/SahandMohammed/wallet-service/internal/config"
+	"github.com/SahandMohammed/wallet-service/internal/credential"
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/notification"
+	"github.com/SahandMohammed/wallet-service/internal/repository"
+	"github.com/SahandMohammed/wallet-service/internal/repository/memory"
+)
+
+const testPassword = "password123"
+
+type authFixture struct {
+	service  AuthService
+	store    *memory.Store
+	cache    *cache.Memory
+	notifier *recordingNotifier
+	hasher   credential.Hasher
+}
+
+func newAuthFixture(t *testing.T, unitOfWork func(repository.UnitOfWork) repository.UnitOfWork) *authFixture {
+	t.Helper()
+	store := memory.NewStore()
+	serviceCache := cache.NewMemory()
+	uow := store.UnitOfWork()
+	if unitOfWork != nil {
+		uow = unitOfWork(uow)
+	}
+
+	cfg := config.Defaults()
+	policy, err := credential.NewPolicy(credential.PolicyConfig{
+		UsernamePattern:   cfg.UsernamePattern,
+		PasswordMinLength: cfg.PasswordMinLength,
+		PasswordMaxLength: cfg.PasswordMaxLength,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hasher, err := credential.NewHasher(credential.HasherConfig{Algorithm: credential.AlgorithmBcrypt, BcryptCost: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notifier := &recordingNotifier{}
+	return &authFixture{
+		service: NewAuthService(
+			store.Users(), store.PasswordResets(), store.Sessions(),
+			NewAuditService(store.AuditLogs(), 0),
+			notifier, policy, hasher, cfg, serviceCache, uow,
+		),
+		store:    store,
+		cache:    serviceCache,
+		notifier: notifier,
+		hasher:   hasher,
+	}
+}
+
+// recordingNotifier keeps the messages it is asked to send
+type recordingNotifier struct {
+	mu       sync.Mutex
+	messages []notification.Message
+}
+
+func (n *recordingNotifier) Send(ctx context.Context, msg notification.Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.messages = append(n.messages, msg)
+	return nil
+}
+
+// resetToken returns the token of the latest password reset message
+func (n *recordingNotifier) resetToken(t *testing.T) string {
+	t.Helper()
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for i := len(n.messages) - 1; i >= 0; i-- {
+		if n.messages[i].Kind == notification.KindPasswordReset {
+			return n.messages[i].Data["token"]
+		}
+	}
+	t.Fatal("no password reset was sent")
+	return ""
+}
+
+func (f *authFixture) register(t *testing.T, username string) *domain.User {
+	t.Helper()
+	user, err := f.service.Register(context.Background(), username, testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func (f *authFixture) login(t *testing.T, username, password string) string {
+	t.Helper()
+	token, err := f.service.Login(context.Background(), username, password, ClientInfo{UserAgent: "test"})
+	if err != nil {
+		t.Fatalf("login %s: %v", username, err)
+	}
+	return token
+}
+
+// failingPasswordTx fails the password update, after the reset token was
+// consumed in the same unit of work
+type failingPasswordTx struct {
+	repository.Tx
+}
+
+func (t failingPasswordTx) Users() repository.UserRepository {
+	return failingPasswordUsers{t.Tx.Users()}
+}
+
+type failingPasswordUsers struct {
+	repository.UserRepository
+}
+
+func (failingPasswordUsers) UpdatePassword(context.Context, uint, string, time.Time) error {
+	return errors.New("users unavailable")
+}
+
+type failingPasswordUnitOfWork struct {
+	next repository.UnitOfWork
+	fail bool
+}
+
+func (u *failingPasswordUnitOfWork) Do(ctx context.Context, fn func(tx repository.Tx) error) error {
+	return u.next.Do(ctx, func(tx repository.Tx) error {
+		if u.fail {
+			tx = failingPasswordTx{tx}
+		}
+		return fn(tx)
+	})
+}
+
+func TestFailedResetKeepsToken(t *testing.T) {
+	var uow *failingPasswordUnitOfWork
+	f := newAuthFixture(t, func(next repository.UnitOfWork) repository.UnitOfWork {
+		uow = &failingPasswordUnitOfWork{next: next}
+		return uow
+	})
+	ctx := context.Background()
+	f.register(t, "alice")
+
+	if err := f.service.RequestPasswordReset(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	token := f.notifier.resetToken(t)
+
+	uow.fail = true
+	if err := f.service.ResetPassword(ctx, token, "newpassword1"); err == nil {
+		t.Fatal("expected the reset to fail")
+	}
+	f.login(t, "alice", testPassword)
+
+	// The token was not used up by the failed attempt
+	uow.fail = false
+	if err := f.service.ResetPassword(ctx, token, "newpassword1"); err != nil {
+		t.Fatalf("retry with the same token: %v", err)
+	}
+	f.login(t, "alice", "newpassword1")
+}
+
+func TestPasswordReset(t *testing.T) {
+	f := newAuthFixture(t, nil)
+	ctx := context.Background()
+	alice := f.register(t, "alice")
+	oldToken := f.login(t, "alice", testPassword)
+
+	// Unknown users are not revealed
+	if err := f.service.RequestPasswordReset(ctx, "nobody"); err != nil {
+		t.Errorf("expected no error for an unknown user, got %v", err)
+	}
+
+	if err := f.service.RequestPasswordReset(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	first := f.notifier.resetToken(t)
+	if err := f.service.RequestPasswordReset(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	token := f.notifier.resetToken(t)
+	if err := f.service.ResetPassword(ctx, first, "newpassword1"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Errorf("expected a newer request to invalidate the older token, got %v", err)
+	}
+
+	if err := f.service.ResetPassword(ctx, token, "newpassword1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.service.ResetPassword(ctx, token, "newpassword2"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Errorf("expected the token to be single use, got %v", err)
+	}
+	f.login(t, "alice", "newpassword1")
+	if _, err := f.service.ValidateToken(ctx, oldToken); err == nil {
+		t.Error("expected the reset to revoke existing sessions")
+	}
+
+	expired := &domain.PasswordResetToken{
+		UserID:    alice.ID,
+		TokenHash: hashResetToken("expired-token"),
+		ExpiresAt: time.Now().Add(-time.Second),
+	}
+	if err := f.store.PasswordResets().Create(ctx, expired); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.service.ResetPassword(ctx, "expired-token", "newpassword2"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Errorf("expected an expired token to be rejected, got %v", err)
+	}
+	if err := f.service.ResetPassword(ctx, "unknown-token", "newpassword2"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Errorf("expected an unknown token to be rejected, got %v", err)
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	f := newAuthFixture(t, nil)
+	ctx := context.Background()
+	alice := f.register(t, "alice")
+	otherDevice := f.login(t, "alice", testPassword)
+
+	if _, err := f.service.ChangePassword(ctx, alice.ID, "wrongpassword", "newpassword1", ClientInfo{}); err == nil {
+		t.Error("expected the wrong current password to be rejected")
+	}
+
+	token, err := f.service.ChangePassword(ctx, alice.ID, testPassword, "newpassword1", ClientInfo{UserAgent: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.service.ValidateToken(ctx, otherDevice); err == nil {
+		t.Error("expected the change to revoke existing sessions")
+	}
+	if claims, err := f.service.ValidateToken(ctx, token); err != nil || claims.UserID != alice.ID {
+		t.Errorf("expected a fresh token for the caller, got %+v, %v", claims, err)
+	}
+	f.login(t, "alice", "newpassword1")
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	f := newAuthFixture(t, nil)
+	ctx := context.Background()
+	tooLong := strings.Repeat("x", 65)
+
+	for _, password := range []string{"short", tooLong} {
+		if _, err := f.service.Register(ctx, "alice", password); err == nil {
+			t.Errorf("expected registering with a %d character password to fail", len(password))
+		}
+	}
+	if _, err := f.service.Register(ctx, "a!", testPassword); err == nil {
+		t.Error("expected an invalid username to be rejected")
+	}
+
+	alice := f.register(t, "alice")
+	if _, err := f.service.ChangePassword(ctx, alice.ID, testPassword, "short", ClientInfo{}); err == nil {
+		t.Error("expected changing to a weak password to fail")
+	}
+
+	if err := f.service.RequestPasswordReset(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	token := f.notifier.resetToken(t)
+	if err := f.service.ResetPassword(ctx, token, tooLong); err == nil {
+		t.Error("expected resetting to a weak password to fail")
+	}
+
+	// Rejected passwords leave the old one and the reset token in place
+	f.login(t, "alice", testPassword)
+	if err := f.service.ResetPassword(ctx, token, "newpassword1"); err != nil {
+		t.Errorf("expected the token to survive a rejected password, got %v", err)
+	}
+}
+
+func TestLoginUpgradesHash(t *testing.T) {
+	f := newAuthFixture(t, nil)
+	ctx := context.Background()
+
+	legacy, err := credential.NewHasher(credential.HasherConfig{
+		Algorithm: credential.AlgorithmArgon2id,
+		Argon2:    credential.Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashed, err := legacy.Hash(testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &domain.User{Username: "alice", Password: hashed}
+	if err := f.store.Users().Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	if !f.hasher.NeedsRehash(hashed) {
+		t.Fatal("expected the argon2id hash to need a rehash under bcrypt")
+	}
+
+	f.login(t, "alice", testPassword)
+
+	stored, err := f.store.Users().GetByID(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Password == hashed || f.hasher.NeedsRehash(stored.Password) {
+		t.Fatalf("expected the hash to be upgraded, got %q", stored.Password)
+	}
+	if ok, err := f.hasher.Verify(testPassword, stored.Password); err != nil || !ok {
+		t.Errorf("expected the upgraded hash to verify, got %v, %v", ok, err)
+	}
+	f.login(t, "alice", testPassword)
+}
+
+func TestRevokeSession(t *testing.T) {
+	f := newAuthFixture(t, nil)
+	ctx := context.Background()
+	alice := f.register(t, "alice")
+	laptop := f.login(t, "alice", testPassword)
+	phone := f.login(t, "alice", testPassword)
+
+	claims, err := f.service.ValidateToken(ctx, laptop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.service.RevokeSession(ctx, alice.ID+1, claims.SessionID); err == nil {
+		t.Error("expected revoking another user's session to fail")
+	}
+	if err := f.service.RevokeSession(ctx, alice.ID, claims.SessionID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.service.ValidateToken(ctx, laptop); err == nil {
+		t.Error("expected the revoked session's token to be rejected")
+	}
+	if _, err := f.service.ValidateToken(ctx, phone); err != nil {
+		t.Errorf("expected the other session to stay valid, got %v", err)
+	}
+
+	sessions, err := f.service.ListSessions(ctx, alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].ID == claims.SessionID {
+		t.Errorf("expected only the other session to be listed, got %d", len(sessions))
+	}
+}
+
+func TestRevokedSessionCacheWindow(t *testing.T) {
+	f := newAuthFixture(t, nil)
+	ctx := context.Background()
+	alice := f.register(t, "alice")
+	token := f.login(t, "alice", testPassword)
+
+	claims, err := f.service.ValidateToken(ctx, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Revoked behind the service's back, the cached session is still trusted
+	// until its cache entry expires
+	if _, err := f.store.Sessions().Revoke(ctx, claims.SessionID, alice.ID, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.service.ValidateToken(ctx, token); err != nil {
+		t.Errorf("expected the cached session to be valid within the window, got %v", err)
+	}
+
+	f.cache.Delete(ctx, sessionCacheKey(claims.SessionID))
+	if _, err := f.service.ValidateToken(ctx, token); err == nil {
+		t.Error("expected the token to be rejected once the cache entry expired")
+	}
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"github.com/SahandMohammed/wallet-service/internal/cache"
+	"github.com/SahandMohammed/wallet-service/internal/config"
+	"github.com/SahandMohammed/wallet-service/internal/credential"
+	"github.com/SahandMohammed/wallet-service/internal/notification"
+	"github.com/SahandMohammed/wallet-service/internal/repository"
+	"gorm.io/gorm"
+)
+
+// Services are the application services. They are built once per process
+// and shared by the HTTP and gRPC APIs and the background workers.
+type Services struct {
+	Audit    AuditService
+	Auth     AuthService
+	Wallet   WalletService
+	Admin    AdminService
+	APIKeys  APIKeyService
+	Webhooks WebhookService
+}
+
+// NewServices builds the services on the SQL repositories of db
+func NewServices(
+	db *gorm.DB,
+	serviceCache cache.Cache,
+	notifier notification.Notifier,
+	policy *credential.Policy,
+	hasher credential.Hasher,
+	cfg *config.Config,
+) *Services {
+	userRepo := repository.NewUserRepository(db)
+	walletRepo := repository.NewWalletRepository(db)
+	transactionRepo := repository.NewTransactionRepository(db)
+	unitOfWork := repository.NewUnitOfWork(db)
+
+	auditService := NewAuditService(
+		repository.NewAuditLogRepository(db),
+		cfg.AuditRetention,
+	)
+
+	return &Services{
+		Audit: auditService,
+		Auth: NewAuthService(
+			userRepo,
+			repository.NewPasswordResetRepository(db),
+			repository.NewSessionRepository(db),
+			auditService, notifier, policy, hasher, cfg, serviceCache, unitOfWork,
+		),
+		Wallet: NewWalletService(walletRepo, transactionRepo, userRepo, auditService, serviceCache, unitOfWork, WalletCacheTTL{
+			Wallet:       cfg.WalletCacheTTL,
+			Transactions: cfg.TransactionsCacheTTL,
+		}),
+		Admin:   NewAdminService(userRepo, walletRepo, transactionRepo, auditService),
+		APIKeys: NewAPIKeyService(repository.NewAPIKeyRepository(db), walletRepo, auditService, cfg),
+		Webhooks: NewWebhookService(
+			repository.NewWebhookRepository(db),
+			walletRepo,
+			cfg.WebhookTimeout,
+			cfg.WebhookMaxAttempts,
+			cfg.WebhookAllowPrivateNetworks,
+		),
+	}
+}
@@ -5,15 +5,19 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/SahandMohammed/wallet-service/internal/cache"
 	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/events"
+	"github.com/SahandMohammed/wallet-service/internal/logging"
+	"github.com/SahandMohammed/wallet-service/internal/metrics"
 	"github.com/SahandMohammed/wallet-service/internal/repository"
 	"github.com/google/uuid"
-	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 )
 
 type WalletService interface {
@@ -22,31 +26,57 @@ type WalletService interface {
 	GetUserWallets(ctx context.Context, userID uint) ([]*domain.Wallet, error)
 	Deposit(ctx context.Context, walletID uint, amount float64, description string) (*domain.Transaction, error)
 	Transfer(ctx context.Context, fromWalletID, toWalletID uint, amount float64, description string) (*domain.Transaction, error)
-	GetTransactions(ctx context.Context, walletID uint, limit, offset int) ([]*domain.Transaction, error)
+	// PageTransactions returns the page of the wallet's transactions selected
+	// by req, newest first
+	PageTransactions(ctx context.Context, walletID uint, req repository.PageRequest) (*repository.Page[*domain.Transaction], error)
+	// Adjust posts a manual correction, positive or negative, with a reason.
+	// It is allowed on frozen wallets.
+	Adjust(ctx context.Context, walletID uint, amount float64, reason string) (*domain.Transaction, error)
+	Freeze(ctx context.Context, walletID uint, reason string) (*domain.Wallet, error)
+	Unfreeze(ctx context.Context, walletID uint) (*domain.Wallet, error)
 }
 
+var (
+	ErrWalletFrozen        = errors.New("wallet is frozen")
+	ErrInvalidAmount       = errors.New("amount must be positive")
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrSameWallet          = errors.New("cannot transfer to the same wallet")
+)
+
 type walletService struct {
 	walletRepo      repository.WalletRepository
 	transactionRepo repository.TransactionRepository
 	userRepo        repository.UserRepository
-	redisClient     *redis.Client
-	db              *gorm.DB
+	auditService    AuditService
+	cache           cache.Cache
+	unitOfWork      repository.UnitOfWork
+	cacheTTL        WalletCacheTTL
+}
+
+// WalletCacheTTL sets how long wallets and transaction pages stay cached
+type WalletCacheTTL struct {
+	Wallet       time.Duration
+	Transactions time.Duration
 }
 
 func NewWalletService(
 	walletRepo repository.WalletRepository,
 	transactionRepo repository.TransactionRepository,
 	userRepo repository.UserRepository,
-	redisClient *redis.Client,
-	db *gorm.DB,
+	auditService AuditService,
+	cache cache.Cache,
+	unitOfWork repository.UnitOfWork,
+	cacheTTL WalletCacheTTL,
 ) WalletService {
-	return &walletService{
+	return &tracedWalletService{next: &walletService{
 		walletRepo:      walletRepo,
 		transactionRepo: transactionRepo,
 		userRepo:        userRepo,
-		redisClient:     redisClient,
-		db:              db,
-	}
+		auditService:    auditService,
+		cache:           cache,
+		unitOfWork:      unitOfWork,
+		cacheTTL:        cacheTTL,
+	}}
 }
 
 func (s *walletService) CreateWallet(ctx context.Context, userID uint) (*domain.Wallet, error) {
@@ -64,32 +94,56 @@ func (s *walletService) CreateWallet(ctx context.Context, userID uint) (*domain.
 		Balance: 0,
 	}
 
-	if err := s.walletRepo.Create(ctx, wallet); err != nil {
+	// Create the wallet and its WalletCreated event atomically
+	err = s.unitOfWork.Do(ctx, func(tx repository.Tx) error {
+		if err := tx.Wallets().Create(ctx, wallet); err != nil {
+			return err
+		}
+
+		event, err := events.NewOutboxEvent(events.TypeWalletCreated, events.AggregateWallet, wallet.ID, events.WalletCreated{
+			WalletID: wallet.ID,
+			UserID:   wallet.UserID,
+		})
+		if err != nil {
+			return err
+		}
+		return tx.Outbox().Create(ctx, event)
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	// Invalidate user cache
 	s.invalidateUserCache(ctx, userID)
 
-	logrus.WithFields(logrus.Fields{
+	logging.FromContext(ctx).WithFields(logrus.Fields{
 		"user_id":   userID,
 		"wallet_id": wallet.ID,
 		"action":    "wallet_created",
 	}).Info("Wallet created successfully")
 
+	s.auditService.Record(ctx, AuditEvent{
+		Action:     AuditActionWalletCreate,
+		TargetType: "wallet",
+		TargetID:   strconv.FormatUint(uint64(wallet.ID), 10),
+		After:      map[string]interface{}{"id": wallet.ID, "user_id": wallet.UserID, "balance": wallet.Balance},
+	})
+
 	return wallet, nil
 }
 
 func (s *walletService) GetWallet(ctx context.Context, walletID uint) (*domain.Wallet, error) {
 	// Try to get from cache first
 	cacheKey := fmt.Sprintf("wallet:%d", walletID)
-	cachedWallet, err := s.redisClient.Get(ctx, cacheKey).Result()
+	cachedWallet, err := s.cache.Get(ctx, cacheKey)
 	if err == nil {
 		var wallet domain.Wallet
-		if json.Unmarshal([]byte(cachedWallet), &wallet) == nil {
+		if json.Unmarshal(cachedWallet, &wallet) == nil {
+			metrics.CacheHit(metrics.CacheWallet)
 			return &wallet, nil
 		}
 	}
+	metrics.CacheMiss(metrics.CacheWallet)
 
 	// Get from database
 	wallet, err := s.walletRepo.GetByID(ctx, walletID)
@@ -99,7 +153,7 @@ func (s *walletService) GetWallet(ctx context.Context, walletID uint) (*domain.W
 
 	// Cache the wallet
 	walletJSON, _ := json.Marshal(wallet)
-	s.redisClient.Set(ctx, cacheKey, walletJSON, 5*time.Minute)
+	s.cache.Set(ctx, cacheKey, walletJSON, s.cacheTTL.Wallet)
 
 	return wallet, nil
 }
@@ -109,29 +163,43 @@ func (s *walletService) GetUserWallets(ctx context.Context, userID uint) ([]*dom
 }
 
 func (s *walletService) Deposit(ctx context.Context, walletID uint, amount float64, description string) (*domain.Transaction, error) {
+	transaction, err := s.deposit(ctx, walletID, amount, description)
+	if err != nil {
+		metrics.RecordFailure(metrics.OperationDeposit, failureReason(err))
+		return nil, err
+	}
+	metrics.RecordDeposit(domain.Currency, domain.MinorUnitsToDollars(transaction.Amount))
+	return transaction, nil
+}
+
+func (s *walletService) deposit(ctx context.Context, walletID uint, amount float64, description string) (*domain.Transaction, error) {
 	if amount <= 0 {
-		return nil, errors.New("amount must be positive")
+		return nil, ErrInvalidAmount
 	}
 
 	amountInMinorUnits := domain.DollarsToMinorUnits(amount)
 
 	var transaction *domain.Transaction
 	var userID uint
-	err := s.db.Transaction(func(tx *gorm.DB) error {
+	err := s.unitOfWork.Do(ctx, func(tx repository.Tx) error {
 		// Get wallet with row lock
-		var wallet domain.Wallet
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&wallet, walletID).Error; err != nil {
+		wallet, err := tx.Wallets().GetForUpdate(ctx, walletID)
+		if err != nil {
 			return err
 		}
 
 		userID = wallet.UserID
 
+		if wallet.FrozenAt != nil {
+			return ErrWalletFrozen
+		}
+
 		// Calculate new balance
 		oldBalance := wallet.Balance
 		newBalance := oldBalance + amountInMinorUnits
 
 		// Update wallet balance
-		if err := tx.Model(&wallet).Update("balance", newBalance).Error; err != nil {
+		if err := tx.Wallets().UpdateBalance(ctx, walletID, newBalance); err != nil {
 			return err
 		}
 
@@ -146,7 +214,39 @@ func (s *walletService) Deposit(ctx context.Context, walletID uint, amount float
 			Description:     description,
 		}
 
-		return tx.Create(transaction).Error
+		if err := tx.Transactions().Create(ctx, transaction); err != nil {
+			return err
+		}
+
+		event, err := events.NewOutboxEvent(events.TypeDepositCompleted, events.AggregateWallet, walletID, events.DepositCompleted{
+			WalletID:        walletID,
+			UserID:          wallet.UserID,
+			Amount:          amountInMinorUnits,
+			BalanceBefore:   oldBalance,
+			BalanceAfter:    newBalance,
+			TransactionID:   transaction.ID,
+			TransactionUUID: transaction.TransactionUUID,
+			Description:     description,
+		})
+		if err != nil {
+			return err
+		}
+		if err := tx.Outbox().Create(ctx, event); err != nil {
+			return err
+		}
+
+		// Audit inside the transaction so the entry commits with the balance change
+		return tx.AuditLogs().Create(ctx, s.auditService.NewEntry(ctx, AuditEvent{
+			Action:     AuditActionDeposit,
+			TargetType: "wallet",
+			TargetID:   strconv.FormatUint(uint64(walletID), 10),
+			Before:     map[string]interface{}{"balance": oldBalance},
+			After: map[string]interface{}{
+				"balance":          newBalance,
+				"amount":           amountInMinorUnits,
+				"transaction_uuid": transaction.TransactionUUID,
+			},
+		}))
 	})
 
 	if err != nil {
@@ -157,7 +257,7 @@ func (s *walletService) Deposit(ctx context.Context, walletID uint, amount float
 	s.invalidateWalletCache(ctx, walletID)
 	s.invalidateTransactionCache(ctx, walletID)
 
-	logrus.WithFields(logrus.Fields{
+	logging.FromContext(ctx).WithFields(logrus.Fields{
 		"user_id":          userID,
 		"wallet_id":        walletID,
 		"amount":           amount,
@@ -171,30 +271,41 @@ func (s *walletService) Deposit(ctx context.Context, walletID uint, amount float
 }
 
 func (s *walletService) Transfer(ctx context.Context, fromWalletID, toWalletID uint, amount float64, description string) (*domain.Transaction, error) {
+	transaction, err := s.transfer(ctx, fromWalletID, toWalletID, amount, description)
+	if err != nil {
+		metrics.RecordFailure(metrics.OperationTransfer, failureReason(err))
+		return nil, err
+	}
+	// The returned transaction is the outgoing side, with a negative amount
+	metrics.RecordTransfer(domain.Currency, -domain.MinorUnitsToDollars(transaction.Amount))
+	return transaction, nil
+}
+
+func (s *walletService) transfer(ctx context.Context, fromWalletID, toWalletID uint, amount float64, description string) (*domain.Transaction, error) {
 	if amount <= 0 {
-		return nil, errors.New("amount must be positive")
+		return nil, ErrInvalidAmount
 	}
 
 	if fromWalletID == toWalletID {
-		return nil, errors.New("cannot transfer to the same wallet")
+		return nil, ErrSameWallet
 	}
 
 	amountInMinorUnits := domain.DollarsToMinorUnits(amount)
 
 	var fromTransaction *domain.Transaction
 	var fromUserID, toUserID uint
-	err := s.db.Transaction(func(tx *gorm.DB) error {
+	err := s.unitOfWork.Do(ctx, func(tx repository.Tx) error {
 		// Get both wallets with row locks
-		var fromWallet, toWallet domain.Wallet
-
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&fromWallet, fromWalletID).Error; err != nil {
+		fromWallet, err := tx.Wallets().GetForUpdate(ctx, fromWalletID)
+		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return errors.New("source wallet not found")
 			}
 			return err
 		}
 
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&toWallet, toWalletID).Error; err != nil {
+		toWallet, err := tx.Wallets().GetForUpdate(ctx, toWalletID)
+		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return errors.New("destination wallet not found")
 			}
@@ -204,9 +315,13 @@ func (s *walletService) Transfer(ctx context.Context, fromWalletID, toWalletID u
 		fromUserID = fromWallet.UserID
 		toUserID = toWallet.UserID
 
+		if fromWallet.FrozenAt != nil || toWallet.FrozenAt != nil {
+			return ErrWalletFrozen
+		}
+
 		// Check sufficient balance
 		if fromWallet.Balance < amountInMinorUnits {
-			return errors.New("insufficient balance")
+			return ErrInsufficientBalance
 		}
 
 		// Calculate new balances
@@ -216,10 +331,10 @@ func (s *walletService) Transfer(ctx context.Context, fromWalletID, toWalletID u
 		toNewBalance := toOldBalance + amountInMinorUnits
 
 		// Update wallet balances
-		if err := tx.Model(&fromWallet).Update("balance", fromNewBalance).Error; err != nil {
+		if err := tx.Wallets().UpdateBalance(ctx, fromWalletID, fromNewBalance); err != nil {
 			return err
 		}
-		if err := tx.Model(&toWallet).Update("balance", toNewBalance).Error; err != nil {
+		if err := tx.Wallets().UpdateBalance(ctx, toWalletID, toNewBalance); err != nil {
 			return err
 		}
 
@@ -248,10 +363,49 @@ func (s *walletService) Transfer(ctx context.Context, fromWalletID, toWalletID u
 			Description:     description,
 		}
 
-		if err := tx.Create(fromTransaction).Error; err != nil {
+		if err := tx.Transactions().Create(ctx, fromTransaction); err != nil {
+			return err
+		}
+		if err := tx.Transactions().Create(ctx, toTransaction); err != nil {
+			return err
+		}
+
+		event, err := events.NewOutboxEvent(events.TypeTransferCompleted, events.AggregateWallet, fromWalletID, events.TransferCompleted{
+			FromWalletID:        fromWalletID,
+			ToWalletID:          toWalletID,
+			FromUserID:          fromWallet.UserID,
+			ToUserID:            toWallet.UserID,
+			Amount:              amountInMinorUnits,
+			FromBalanceAfter:    fromNewBalance,
+			ToBalanceAfter:      toNewBalance,
+			FromTransactionUUID: fromTransaction.TransactionUUID,
+			ToTransactionUUID:   toTransaction.TransactionUUID,
+			Description:         description,
+		})
+		if err != nil {
+			return err
+		}
+		if err := tx.Outbox().Create(ctx, event); err != nil {
 			return err
 		}
-		return tx.Create(toTransaction).Error
+
+		// Audit inside the transaction so the entry commits with the balance change
+		return tx.AuditLogs().Create(ctx, s.auditService.NewEntry(ctx, AuditEvent{
+			Action:     AuditActionTransfer,
+			TargetType: "wallet",
+			TargetID:   strconv.FormatUint(uint64(fromWalletID), 10),
+			Before: map[string]interface{}{
+				"from_balance": fromOldBalance,
+				"to_balance":   toOldBalance,
+			},
+			After: map[string]interface{}{
+				"from_balance":     fromNewBalance,
+				"to_balance":       toNewBalance,
+				"to_wallet_id":     toWalletID,
+				"amount":           amountInMinorUnits,
+				"transaction_uuid": fromTransaction.TransactionUUID,
+			},
+		}))
 	})
 
 	if err != nil {
@@ -264,7 +418,7 @@ func (s *walletService) Transfer(ctx context.Context, fromWalletID, toWalletID u
 	s.invalidateTransactionCache(ctx, fromWalletID)
 	s.invalidateTransactionCache(ctx, toWalletID)
 
-	logrus.WithFields(logrus.Fields{
+	logging.FromContext(ctx).WithFields(logrus.Fields{
 		"from_user_id":     fromUserID,
 		"to_user_id":       toUserID,
 		"from_wallet_id":   fromWalletID,
@@ -279,49 +433,221 @@ func (s *walletService) Transfer(ctx context.Context, fromWalletID, toWalletID u
 	return fromTransaction, nil
 }
 
-func (s *walletService) GetTransactions(ctx context.Context, walletID uint, limit, offset int) ([]*domain.Transaction, error) {
-	// Try to get from cache first
-	cacheKey := fmt.Sprintf("wallet:%d:transactions:%d:%d", walletID, limit, offset)
-	if cached, err := s.redisClient.Get(ctx, cacheKey).Result(); err == nil {
-		var transactions []*domain.Transaction
-		if json.Unmarshal([]byte(cached), &transactions) == nil {
-			return transactions, nil
+func (s *walletService) PageTransactions(ctx context.Context, walletID uint, req repository.PageRequest) (*repository.Page[*domain.Transaction], error) {
+	// Cached under the wallet's transactions prefix, so that writes to the
+	// wallet invalidate it
+	cacheKey := fmt.Sprintf("wallet:%d:transactions:page:%s", walletID, pageCacheKey(req))
+	if cached, err := s.cache.Get(ctx, cacheKey); err == nil {
+		var page repository.Page[*domain.Transaction]
+		if json.Unmarshal(cached, &page) == nil {
+			metrics.CacheHit(metrics.CacheTransactions)
+			return &page, nil
 		}
 	}
+	metrics.CacheMiss(metrics.CacheTransactions)
 
-	// Get from database
-	transactions, err := s.transactionRepo.GetByWalletID(ctx, walletID, limit, offset)
+	page, err := s.transactionRepo.Page(ctx, repository.TransactionFilters{WalletID: &walletID}, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if pageJSON, err := json.Marshal(page); err == nil {
+		s.cache.Set(ctx, cacheKey, pageJSON, s.cacheTTL.Transactions)
+	}
+
+	return page, nil
+}
+
+// pageCacheKey identifies a page request in cache keys
+func pageCacheKey(req repository.PageRequest) string {
+	var cursor string
+	switch {
+	case req.After != nil:
+		cursor = repository.EncodeCursor(*req.After, false)
+	case req.Before != nil:
+		cursor = repository.EncodeCursor(*req.Before, true)
+	}
+	return fmt.Sprintf("%d:%s:%t", req.Limit, cursor, req.IncludeTotal)
+}
+
+func (s *walletService) Adjust(ctx context.Context, walletID uint, amount float64, reason string) (*domain.Transaction, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("reason is required")
+	}
+
+	amountInMinorUnits := domain.DollarsToMinorUnits(amount)
+	if amountInMinorUnits == 0 {
+		return nil, errors.New("amount must not be zero")
+	}
+
+	var transaction *domain.Transaction
+	err := s.unitOfWork.Do(ctx, func(tx repository.Tx) error {
+		wallet, err := tx.Wallets().GetForUpdate(ctx, walletID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("wallet not found")
+			}
+			return err
+		}
+
+		oldBalance := wallet.Balance
+		newBalance := oldBalance + amountInMinorUnits
+		if newBalance < 0 {
+			return ErrInsufficientBalance
+		}
+
+		if err := tx.Wallets().UpdateBalance(ctx, walletID, newBalance); err != nil {
+			return err
+		}
+
+		transaction = &domain.Transaction{
+			WalletID:        walletID,
+			Type:            domain.TransactionTypeAdjustment,
+			Amount:          amountInMinorUnits,
+			BalanceBefore:   oldBalance,
+			BalanceAfter:    newBalance,
+			TransactionUUID: uuid.New().String(),
+			Description:     reason,
+		}
+		if err := tx.Transactions().Create(ctx, transaction); err != nil {
+			return err
+		}
+
+		event, err := events.NewOutboxEvent(events.TypeBalanceAdjusted, events.AggregateWallet, walletID, events.BalanceAdjusted{
+			WalletID:        walletID,
+			UserID:          wallet.UserID,
+			Amount:          amountInMinorUnits,
+			BalanceBefore:   oldBalance,
+			BalanceAfter:    newBalance,
+			TransactionID:   transaction.ID,
+			TransactionUUID: transaction.TransactionUUID,
+			Reason:          reason,
+		})
+		if err != nil {
+			return err
+		}
+		if err := tx.Outbox().Create(ctx, event); err != nil {
+			return err
+		}
+
+		return tx.AuditLogs().Create(ctx, s.auditService.NewEntry(ctx, AuditEvent{
+			Action:     AuditActionAdjust,
+			TargetType: "wallet",
+			TargetID:   strconv.FormatUint(uint64(walletID), 10),
+			Before:     map[string]interface{}{"balance": oldBalance},
+			After: map[string]interface{}{
+				"balance":          newBalance,
+				"amount":           amountInMinorUnits,
+				"reason":           reason,
+				"transaction_uuid": transaction.TransactionUUID,
+			},
+		}))
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Cache the transactions with 2 minute TTL
-	if transactionsJSON, err := json.Marshal(transactions); err == nil {
-		s.redisClient.Set(ctx, cacheKey, transactionsJSON, 2*time.Minute)
+	s.invalidateWalletCache(ctx, walletID)
+	s.invalidateTransactionCache(ctx, walletID)
+
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"wallet_id":        walletID,
+		"amount":           amount,
+		"transaction_uuid": transaction.TransactionUUID,
+		"reason":           reason,
+		"action":           "adjustment",
+		"transaction_type": "financial",
+	}).Info("Financial transaction completed")
+
+	return transaction, nil
+}
+
+func (s *walletService) Freeze(ctx context.Context, walletID uint, reason string) (*domain.Wallet, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("reason is required")
+	}
+	now := time.Now()
+	return s.setFrozen(ctx, walletID, &now, reason, AuditActionFreeze)
+}
+
+func (s *walletService) Unfreeze(ctx context.Context, walletID uint) (*domain.Wallet, error) {
+	return s.setFrozen(ctx, walletID, nil, "", AuditActionUnfreeze)
+}
+
+func (s *walletService) setFrozen(ctx context.Context, walletID uint, frozenAt *time.Time, reason, action string) (*domain.Wallet, error) {
+	var wallet *domain.Wallet
+	err := s.unitOfWork.Do(ctx, func(tx repository.Tx) error {
+		// Lock the wallet so that the audit entry sees the state it replaces
+		var err error
+		wallet, err = tx.Wallets().GetForUpdate(ctx, walletID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("wallet not found")
+			}
+			return err
+		}
+
+		before := map[string]interface{}{"frozen_at": wallet.FrozenAt, "frozen_reason": wallet.FrozenReason}
+		if err := tx.Wallets().SetFrozen(ctx, walletID, frozenAt, reason); err != nil {
+			return err
+		}
+		wallet.FrozenAt = frozenAt
+		wallet.FrozenReason = reason
+
+		return tx.AuditLogs().Create(ctx, s.auditService.NewEntry(ctx, AuditEvent{
+			Action:     action,
+			TargetType: "wallet",
+			TargetID:   strconv.FormatUint(uint64(walletID), 10),
+			Before:     before,
+			After:      map[string]interface{}{"frozen_at": frozenAt, "frozen_reason": reason},
+		}))
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return transactions, nil
+	s.invalidateWalletCache(ctx, walletID)
+
+	return wallet, nil
+}
+
+// failureReason maps an error to one of a fixed set of metric labels
+func failureReason(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidAmount):
+		return "invalid_amount"
+	case errors.Is(err, ErrInsufficientBalance):
+		return "insufficient_balance"
+	case errors.Is(err, ErrWalletFrozen):
+		return "wallet_frozen"
+	case errors.Is(err, ErrSameWallet):
+		return "same_wallet"
+	case errors.Is(err, gorm.ErrRecordNotFound), strings.HasSuffix(err.Error(), "wallet not found"):
+		return "wallet_not_found"
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "canceled"
+	default:
+		return "internal"
+	}
 }
 
 func (s *walletService) invalidateWalletCache(ctx context.Context, walletID uint) {
 	cacheKey := fmt.Sprintf("wallet:%d", walletID)
-	s.redisClient.Del(ctx, cacheKey)
+	s.cache.Delete(ctx, cacheKey)
 }
 
 func (s *walletService) invalidateUserCache(ctx context.Context, userID uint) {
 	cacheKey := fmt.Sprintf("user:%d", userID)
-	s.redisClient.Del(ctx, cacheKey)
+	s.cache.Delete(ctx, cacheKey)
 }
 
 func (s *walletService) invalidateTransactionCache(ctx context.Context, walletID uint) {
-	// Find and delete all transaction cache keys for this wallet
-	pattern := fmt.Sprintf("wallet:%d:transactions:*", walletID)
-	iter := s.redisClient.Scan(ctx, 0, pattern, 0).Iterator()
-	for iter.Next(ctx) {
-		s.redisClient.Del(ctx, iter.Val())
-	}
-	if err := iter.Err(); err != nil {
+	// Delete every cached page of this wallet's transactions
+	prefix := fmt.Sprintf("wallet:%d:transactions:", walletID)
+	if err := s.cache.DeletePrefix(ctx, prefix); err != nil {
 		// Log error but don't fail the operation
-		logrus.WithError(err).Warn("Failed to invalidate transaction cache")
+		logging.FromContext(ctx).WithError(err).Warn("Failed to invalidate transaction cache")
 	}
 }
@@ -0,0 +1,327 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/cache"
+	"github.com/SahandMohammed/wallet-service/internal/db"
+	"github.com/SahandMohammed/wallet-service/internal/dialect"
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/migration"
+	"github.com/SahandMohammed/wallet-service/internal/repository"
+	"github.com/SahandMohammed/wallet-service/internal/repository/memory"
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// moneyBackend is the storage a wallet service runs on in the invariant test
+type moneyBackend struct {
+	users        repository.UserRepository
+	wallets      repository.WalletRepository
+	transactions repository.TransactionRepository
+	auditLogs    repository.AuditLogRepository
+	unitOfWork   repository.UnitOfWork
+}
+
+func memoryBackend(t *testing.T) moneyBackend {
+	store := memory.NewStore()
+	return moneyBackend{
+		users:        store.Users(),
+		wallets:      store.Wallets(),
+		transactions: store.Transactions(),
+		auditLogs:    store.AuditLogs(),
+		unitOfWork:   store.UnitOfWork(),
+	}
+}
+
+func sqlBackend(open func(t *testing.T) gorm.Dialector) func(t *testing.T) moneyBackend {
+	return func(t *testing.T) moneyBackend {
+		gormDB := openMigrated(t, open(t))
+		return moneyBackend{
+			users:        repository.NewUserRepository(gormDB),
+			wallets:      repository.NewWalletRepository(gormDB),
+			transactions: repository.NewTransactionRepository(gormDB),
+			auditLogs:    repository.NewAuditLogRepository(gormDB),
+			unitOfWork:   repository.NewUnitOfWork(gormDB),
+		}
+	}
+}
+
+// TestMoneyInvariants fires random deposits, transfers and withdrawals from
+// concurrent workers and then checks the ledger. It runs on the in-memory
+// store and SQLite, and on MySQL and PostgreSQL when TEST_MYSQL_DSN or
+// TEST_POSTGRES_DSN name a disposable database.
+//
+// The operations are drawn from a seed that is logged on failure. Setting
+// TEST_SEED replays the same operations, though not necessarily in the same
+// interleaving. There is no withdrawal operation, negative adjustments take
+// money out of a wallet instead.
+func TestMoneyInvariants(t *testing.T) {
+	seed := time.Now().UnixNano()
+	if value := os.Getenv("TEST_SEED"); value != "" {
+		var err error
+		if seed, err = strconv.ParseInt(value, 10, 64); err != nil {
+			t.Fatalf("invalid TEST_SEED: %v", err)
+		}
+	}
+
+	backends := []struct {
+		name       string
+		open       func(t *testing.T) moneyBackend
+		operations int
+	}{
+		{"memory", memoryBackend, 5000},
+		{dialect.SQLite, sqlBackend(func(t *testing.T) gorm.Dialector {
+			return sqlite.Open(db.SQLiteDSN(filepath.Join(t.TempDir(), "wallet.db")))
+		}), 1000},
+		{dialect.MySQL, sqlBackend(func(t *testing.T) gorm.Dialector {
+			return mysql.Open(dsnFromEnv(t, "TEST_MYSQL_DSN"))
+		}), 2000},
+		{dialect.Postgres, sqlBackend(func(t *testing.T) gorm.Dialector {
+			return postgres.Open(dsnFromEnv(t, "TEST_POSTGRES_DSN"))
+		}), 2000},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			operations := backend.operations
+			if testing.Short() {
+				operations /= 10
+			}
+			t.Cleanup(func() {
+				if t.Failed() {
+					t.Logf("reproduce the operations with TEST_SEED=%d", seed)
+				}
+			})
+			testMoneyInvariants(t, backend.open(t), seed, operations)
+		})
+	}
+}
+
+const (
+	invariantWallets = 6
+	invariantWorkers = 8
+)
+
+type moneyOperation struct {
+	kind     string
+	from, to int // wallet indexes, to is only used by transfers
+	amount   float64
+}
+
+// planOperations draws the operations from seed. Few wallets and many
+// workers keep the wallet locks contended.
+func planOperations(seed int64, n int) []moneyOperation {
+	rng := rand.New(rand.NewSource(seed))
+	operations := make([]moneyOperation, n)
+	for i := range operations {
+		op := moneyOperation{
+			from:   rng.Intn(invariantWallets),
+			to:     rng.Intn(invariantWallets),
+			amount: float64(1+rng.Intn(5000)) / 100,
+		}
+		switch roll := rng.Intn(10); {
+		case roll < 3:
+			op.kind = "deposit"
+		case roll < 8:
+			op.kind = "transfer"
+		default:
+			op.kind = "withdraw"
+		}
+		operations[i] = op
+	}
+	return operations
+}
+
+func testMoneyInvariants(t *testing.T, backend moneyBackend, seed int64, n int) {
+	ctx := context.Background()
+	svc := NewWalletService(
+		backend.wallets, backend.transactions, backend.users,
+		NewAuditService(backend.auditLogs, 0),
+		cache.NewMemory(), backend.unitOfWork,
+		WalletCacheTTL{Wallet: time.Minute, Transactions: time.Minute},
+	)
+
+	walletIDs := make([]uint, invariantWallets)
+	for i := range walletIDs {
+		user := &domain.User{Username: fmt.Sprintf("user%d", i), Password: "x"}
+		if err := backend.users.Create(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+		wallet, err := svc.CreateWallet(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		walletIDs[i] = wallet.ID
+	}
+
+	// Net money put in by deposits and taken out by withdrawals, counted from
+	// the operations that succeeded
+	var netInflow, rejected, aborted atomic.Int64
+	var failures sync.Map
+
+	operations := make(chan moneyOperation)
+	var wg sync.WaitGroup
+	for w := 0; w < invariantWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for op := range operations {
+				from, to := walletIDs[op.from], walletIDs[op.to]
+				var transaction *domain.Transaction
+				var err error
+				switch op.kind {
+				case "deposit":
+					transaction, err = svc.Deposit(ctx, from, op.amount, "deposit")
+					if err == nil {
+						netInflow.Add(transaction.Amount)
+					}
+				case "transfer":
+					_, err = svc.Transfer(ctx, from, to, op.amount, "transfer")
+				case "withdraw":
+					transaction, err = svc.Adjust(ctx, from, -op.amount, "withdrawal")
+					if err == nil {
+						netInflow.Add(transaction.Amount)
+					}
+				}
+
+				switch {
+				case err == nil:
+				case errors.Is(err, ErrInsufficientBalance), errors.Is(err, ErrSameWallet):
+					rejected.Add(1)
+				case dialect.IsRetryable(err):
+					// Still aborted after the unit of work's retries, and rolled back
+					aborted.Add(1)
+				default:
+					failures.Store(fmt.Sprintf("%s %+v", op.kind, op), err)
+				}
+			}
+		}()
+	}
+	for _, op := range planOperations(seed, n) {
+		operations <- op
+	}
+	close(operations)
+	wg.Wait()
+
+	failures.Range(func(op, err interface{}) bool {
+		t.Errorf("%s failed: %v", op, err)
+		return true
+	})
+	t.Logf("%d operations, %d rejected, %d aborted", n, rejected.Load(), aborted.Load())
+
+	checkLedger(t, backend, walletIDs, netInflow.Load())
+}
+
+// checkLedger checks the invariants on the stored wallets and transactions
+func checkLedger(t *testing.T, backend moneyBackend, walletIDs []uint, netInflow int64) {
+	t.Helper()
+	ctx := context.Background()
+
+	// Transactions in id order, which for each wallet is the order in which
+	// they held its lock
+	byWallet := map[uint][]*domain.Transaction{}
+	var transferTotal int64
+	err := backend.transactions.ListInBatches(ctx, repository.TransactionFilters{}, 500, func(batch []*domain.Transaction) error {
+		for _, transaction := range batch {
+			byWallet[transaction.WalletID] = append(byWallet[transaction.WalletID], transaction)
+			if transaction.Type == domain.TransactionTypeTransfer {
+				transferTotal += transaction.Amount
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var total int64
+	for _, walletID := range walletIDs {
+		wallet, err := backend.wallets.GetByID(ctx, walletID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += wallet.Balance
+
+		if wallet.Balance < 0 {
+			t.Errorf("wallet %d: negative balance %d", walletID, wallet.Balance)
+		}
+
+		var previous int64
+		transactions := byWallet[walletID]
+		for _, transaction := range transactions {
+			if transaction.BalanceBefore != previous {
+				t.Errorf("wallet %d: transaction %d starts at %d, the one before ended at %d",
+					walletID, transaction.ID, transaction.BalanceBefore, previous)
+			}
+			if transaction.BalanceAfter != transaction.BalanceBefore+transaction.Amount {
+				t.Errorf("wallet %d: transaction %d moves %d from %d to %d",
+					walletID, transaction.ID, transaction.Amount, transaction.BalanceBefore, transaction.BalanceAfter)
+			}
+			previous = transaction.BalanceAfter
+		}
+		if wallet.Balance != previous {
+			t.Errorf("wallet %d: balance %d, last balance after is %d", walletID, wallet.Balance, previous)
+		}
+	}
+
+	if total != netInflow {
+		t.Errorf("wallets hold %d in total, deposits less withdrawals are %d", total, netInflow)
+	}
+	if transferTotal != 0 {
+		t.Errorf("transfers created or destroyed %d", transferTotal)
+	}
+}
+
+func dsnFromEnv(t *testing.T, key string) string {
+	dsn := os.Getenv(key)
+	if dsn == "" {
+		t.Skipf("%s is not set", key)
+	}
+	return dsn
+}
+
+// openMigrated migrates the database up and back down to empty once the
+// test is done
+func openMigrated(t *testing.T, dialector gorm.Dialector) *gorm.DB {
+	t.Helper()
+	gormDB, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := migration.New(gormDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := m.Up(ctx, 0); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	t.Cleanup(func() {
+		statuses, err := m.Status(ctx)
+		if err == nil {
+			_, err = m.Down(ctx, len(statuses))
+		}
+		if err != nil {
+			t.Errorf("migrate down: %v", err)
+		}
+		if sqlDB, err := gormDB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return gormDB
+}
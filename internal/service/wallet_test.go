@@ -0,0 +1,368 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/cache"
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/events"
+	"github.com/SahandMohammed/wallet-service/internal/repository"
+	"github.com/SahandMohammed/wallet-service/internal/repository/memory"
+	"gorm.io/gorm"
+)
+
+type walletFixture struct {
+	service WalletService
+	store   *memory.Store
+	cache   *cache.Memory
+	users   int
+}
+
+func newWalletFixture(t *testing.T, unitOfWork func(repository.UnitOfWork) repository.UnitOfWork) *walletFixture {
+	t.Helper()
+	store := memory.NewStore()
+	serviceCache := cache.NewMemory()
+	uow := store.UnitOfWork()
+	if unitOfWork != nil {
+		uow = unitOfWork(uow)
+	}
+	return &walletFixture{
+		service: NewWalletService(
+			store.Wallets(), store.Transactions(), store.Users(),
+			NewAuditService(store.AuditLogs(), 0),
+			serviceCache, uow,
+			WalletCacheTTL{Wallet: time.Minute, Transactions: time.Minute},
+		),
+		store: store,
+		cache: serviceCache,
+	}
+}
+
+// wallet creates a user and a wallet holding balance, in dollars
+func (f *walletFixture) wallet(t *testing.T, balance float64) uint {
+	t.Helper()
+	ctx := context.Background()
+	f.users++
+	user := &domain.User{Username: fmt.Sprintf("user%d", f.users), Password: "x"}
+	if err := f.store.Users().Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	wallet, err := f.service.CreateWallet(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance > 0 {
+		if _, err := f.service.Deposit(ctx, wallet.ID, balance, "seed"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return wallet.ID
+}
+
+func (f *walletFixture) balance(t *testing.T, walletID uint) int64 {
+	t.Helper()
+	wallet, err := f.store.Wallets().GetByID(context.Background(), walletID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wallet.Balance
+}
+
+func (f *walletFixture) eventTypes() []string {
+	var types []string
+	for _, event := range f.store.OutboxEvents() {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+func TestCreateWallet(t *testing.T) {
+	f := newWalletFixture(t, nil)
+
+	walletID := f.wallet(t, 0)
+	if types := f.eventTypes(); len(types) != 1 || types[0] != events.TypeWalletCreated {
+		t.Errorf("expected a WalletCreated event, got %v", types)
+	}
+	if f.balance(t, walletID) != 0 {
+		t.Error("expected an empty wallet")
+	}
+
+	if _, err := f.service.CreateWallet(context.Background(), 999); err == nil || err.Error() != "user not found" {
+		t.Errorf("expected user not found, got %v", err)
+	}
+}
+
+func TestDeposit(t *testing.T) {
+	f := newWalletFixture(t, nil)
+	ctx := context.Background()
+	walletID := f.wallet(t, 10)
+
+	transaction, err := f.service.Deposit(ctx, walletID, 2.5, "top up")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transaction.Amount != 250 || transaction.BalanceBefore != 1000 || transaction.BalanceAfter != 1250 {
+		t.Errorf("unexpected transaction %+v", transaction)
+	}
+	if got := f.balance(t, walletID); got != 1250 {
+		t.Errorf("expected balance 1250, got %d", got)
+	}
+
+	entries, err := f.store.AuditLogs().List(ctx, repository.AuditLogFilters{Action: AuditActionDeposit})
+	if err != nil || len(entries) != 2 {
+		t.Errorf("expected two deposit audit entries, got %d, %v", len(entries), err)
+	}
+
+	tests := map[string]struct {
+		walletID uint
+		amount   float64
+		want     error
+	}{
+		"zero amount":     {walletID, 0, ErrInvalidAmount},
+		"negative amount": {walletID, -1, ErrInvalidAmount},
+		"missing wallet":  {999, 1, gorm.ErrRecordNotFound},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := f.service.Deposit(ctx, tt.walletID, tt.amount, ""); !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+
+	if _, err := f.service.Freeze(ctx, walletID, "investigation"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.service.Deposit(ctx, walletID, 1, ""); !errors.Is(err, ErrWalletFrozen) {
+		t.Errorf("expected frozen wallet to reject deposits, got %v", err)
+	}
+	if got := f.balance(t, walletID); got != 1250 {
+		t.Errorf("rejected deposits changed the balance to %d", got)
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	f := newWalletFixture(t, nil)
+	ctx := context.Background()
+	from, to := f.wallet(t, 10), f.wallet(t, 0)
+
+	transaction, err := f.service.Transfer(ctx, from, to, 4, "rent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transaction.Amount != -400 || transaction.WalletID != from || *transaction.ToWalletID != to {
+		t.Errorf("expected the outgoing side, got %+v", transaction)
+	}
+	if f.balance(t, from) != 600 || f.balance(t, to) != 400 {
+		t.Errorf("unexpected balances %d and %d", f.balance(t, from), f.balance(t, to))
+	}
+
+	incoming, err := f.store.Transactions().GetByWalletID(ctx, to, 10, 0)
+	if err != nil || len(incoming) != 1 || incoming[0].Amount != 400 || incoming[0].BalanceAfter != 400 {
+		t.Errorf("expected one incoming transaction, got %+v, %v", incoming, err)
+	}
+
+	tests := map[string]struct {
+		from, to uint
+		amount   float64
+		want     string
+	}{
+		"insufficient balance": {from, to, 6.01, ErrInsufficientBalance.Error()},
+		"same wallet":          {from, from, 1, ErrSameWallet.Error()},
+		"invalid amount":       {from, to, 0, ErrInvalidAmount.Error()},
+		"missing source":       {999, to, 1, "source wallet not found"},
+		"missing destination":  {from, 999, 1, "destination wallet not found"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := f.service.Transfer(ctx, tt.from, tt.to, tt.amount, ""); err == nil || err.Error() != tt.want {
+				t.Errorf("expected %q, got %v", tt.want, err)
+			}
+		})
+	}
+	if f.balance(t, from) != 600 || f.balance(t, to) != 400 {
+		t.Errorf("failed transfers changed the balances to %d and %d", f.balance(t, from), f.balance(t, to))
+	}
+}
+
+// failingTx fails the audit write, the last write of every money movement
+type failingTx struct {
+	repository.Tx
+}
+
+func (t failingTx) AuditLogs() repository.AuditLogRepository {
+	return failingAuditLogs{t.Tx.AuditLogs()}
+}
+
+type failingAuditLogs struct {
+	repository.AuditLogRepository
+}
+
+func (failingAuditLogs) Create(context.Context, *domain.AuditLog) error {
+	return errors.New("audit log unavailable")
+}
+
+type failingUnitOfWork struct {
+	next repository.UnitOfWork
+	fail bool
+}
+
+func (u *failingUnitOfWork) Do(ctx context.Context, fn func(tx repository.Tx) error) error {
+	return u.next.Do(ctx, func(tx repository.Tx) error {
+		if u.fail {
+			tx = failingTx{tx}
+		}
+		return fn(tx)
+	})
+}
+
+func TestFailedTransferRollsBack(t *testing.T) {
+	var uow *failingUnitOfWork
+	f := newWalletFixture(t, func(next repository.UnitOfWork) repository.UnitOfWork {
+		uow = &failingUnitOfWork{next: next}
+		return uow
+	})
+	ctx := context.Background()
+	from, to := f.wallet(t, 10), f.wallet(t, 0)
+	eventsBefore := len(f.store.OutboxEvents())
+
+	uow.fail = true
+	if _, err := f.service.Transfer(ctx, from, to, 4, ""); err == nil {
+		t.Fatal("expected the transfer to fail")
+	}
+
+	if f.balance(t, from) != 1000 || f.balance(t, to) != 0 {
+		t.Errorf("balances not rolled back: %d and %d", f.balance(t, from), f.balance(t, to))
+	}
+	if transactions, _ := f.store.Transactions().GetByWalletID(ctx, to, 10, 0); len(transactions) != 0 {
+		t.Errorf("transactions not rolled back: %+v", transactions)
+	}
+	if got := len(f.store.OutboxEvents()); got != eventsBefore {
+		t.Errorf("outbox events not rolled back, %d before and %d after", eventsBefore, got)
+	}
+}
+
+func TestAdjust(t *testing.T) {
+	f := newWalletFixture(t, nil)
+	ctx := context.Background()
+	walletID := f.wallet(t, 5)
+
+	if _, err := f.service.Freeze(ctx, walletID, "chargeback"); err != nil {
+		t.Fatal(err)
+	}
+	transaction, err := f.service.Adjust(ctx, walletID, -2, " chargeback ")
+	if err != nil {
+		t.Fatalf("adjustments must be allowed on frozen wallets: %v", err)
+	}
+	if transaction.Type != domain.TransactionTypeAdjustment || transaction.Description != "chargeback" || f.balance(t, walletID) != 300 {
+		t.Errorf("unexpected adjustment %+v", transaction)
+	}
+
+	if _, err := f.service.Adjust(ctx, walletID, -3.01, "chargeback"); !errors.Is(err, ErrInsufficientBalance) {
+		t.Errorf("expected insufficient balance, got %v", err)
+	}
+	if _, err := f.service.Adjust(ctx, walletID, 1, " "); err == nil {
+		t.Error("expected a reason to be required")
+	}
+	if _, err := f.service.Adjust(ctx, 999, 1, "x"); err == nil || err.Error() != "wallet not found" {
+		t.Errorf("expected wallet not found, got %v", err)
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	f := newWalletFixture(t, nil)
+	ctx := context.Background()
+	from, to := f.wallet(t, 5), f.wallet(t, 0)
+
+	wallet, err := f.service.Freeze(ctx, to, "fraud")
+	if err != nil || wallet.FrozenAt == nil || wallet.FrozenReason != "fraud" {
+		t.Fatalf("expected frozen wallet, got %+v, %v", wallet, err)
+	}
+	if _, err := f.service.Transfer(ctx, from, to, 1, ""); !errors.Is(err, ErrWalletFrozen) {
+		t.Errorf("expected transfers into a frozen wallet to fail, got %v", err)
+	}
+
+	if wallet, err = f.service.Unfreeze(ctx, to); err != nil || wallet.FrozenAt != nil {
+		t.Fatalf("expected unfrozen wallet, got %+v, %v", wallet, err)
+	}
+	if _, err := f.service.Transfer(ctx, from, to, 1, ""); err != nil {
+		t.Errorf("expected transfer after unfreezing, got %v", err)
+	}
+
+	if _, err := f.service.Freeze(ctx, to, ""); err == nil {
+		t.Error("expected a reason to be required")
+	}
+}
+
+func TestFailedFreezeRollsBack(t *testing.T) {
+	var uow *failingUnitOfWork
+	f := newWalletFixture(t, func(next repository.UnitOfWork) repository.UnitOfWork {
+		uow = &failingUnitOfWork{next: next}
+		return uow
+	})
+	ctx := context.Background()
+	walletID := f.wallet(t, 1)
+
+	uow.fail = true
+	if _, err := f.service.Freeze(ctx, walletID, "fraud"); err == nil {
+		t.Fatal("expected the freeze to fail")
+	}
+	wallet, err := f.store.Wallets().GetByID(ctx, walletID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wallet.FrozenAt != nil {
+		t.Error("expected the wallet to stay unfrozen when the audit entry fails")
+	}
+
+	uow.fail = false
+	if _, err := f.service.Freeze(ctx, walletID, "fraud"); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := f.store.AuditLogs().List(ctx, repository.AuditLogFilters{Action: AuditActionFreeze})
+	if err != nil || len(entries) != 1 {
+		t.Errorf("expected one freeze audit entry, got %d, %v", len(entries), err)
+	}
+}
+
+func TestWalletCache(t *testing.T) {
+	f := newWalletFixture(t, nil)
+	ctx := context.Background()
+	walletID := f.wallet(t, 1)
+
+	if _, err := f.service.GetWallet(ctx, walletID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.service.PageTransactions(ctx, walletID, repository.PageRequest{Limit: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if f.cache.Len() != 2 {
+		t.Fatalf("expected the wallet and a transaction page cached, got %d entries", f.cache.Len())
+	}
+
+	// Writes that bypass the service are not seen until the cache expires
+	if err := f.store.Wallets().UpdateBalance(ctx, walletID, 42); err != nil {
+		t.Fatal(err)
+	}
+	if wallet, _ := f.service.GetWallet(ctx, walletID); wallet.Balance != 100 {
+		t.Errorf("expected the cached balance, got %d", wallet.Balance)
+	}
+
+	if _, err := f.service.Deposit(ctx, walletID, 1, ""); err != nil {
+		t.Fatal(err)
+	}
+	if f.cache.Len() != 0 {
+		t.Errorf("expected deposit to invalidate the cache, %d entries left", f.cache.Len())
+	}
+	if wallet, _ := f.service.GetWallet(ctx, walletID); wallet.Balance != 142 {
+		t.Errorf("expected the stored balance, got %d", wallet.Balance)
+	}
+	page, err := f.service.PageTransactions(ctx, walletID, repository.PageRequest{Limit: 10})
+	if err != nil || len(page.Items) != 2 {
+		t.Errorf("expected both transactions, got %v, %v", page, err)
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/repository"
+	"github.com/SahandMohammed/wallet-service/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedWalletService wraps every WalletService call in a span, so that
+// the queries and Redis commands of a call are grouped under it.
+type tracedWalletService struct {
+	next WalletService
+}
+
+func (s *tracedWalletService) start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "WalletService."+name, trace.WithAttributes(attrs...))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func idAttr(key string, id uint) attribute.KeyValue {
+	return attribute.Int64(key, int64(id))
+}
+
+func (s *tracedWalletService) CreateWallet(ctx context.Context, userID uint) (*domain.Wallet, error) {
+	ctx, span := s.start(ctx, "CreateWallet", idAttr("user.id", userID))
+	wallet, err := s.next.CreateWallet(ctx, userID)
+	endSpan(span, err)
+	return wallet, err
+}
+
+func (s *tracedWalletService) GetWallet(ctx context.Context, walletID uint) (*domain.Wallet, error) {
+	ctx, span := s.start(ctx, "GetWallet", idAttr("wallet.id", walletID))
+	wallet, err := s.next.GetWallet(ctx, walletID)
+	endSpan(span, err)
+	return wallet, err
+}
+
+func (s *tracedWalletService) GetUserWallets(ctx context.Context, userID uint) ([]*domain.Wallet, error) {
+	ctx, span := s.start(ctx, "GetUserWallets", idAttr("user.id", userID))
+	wallets, err := s.next.GetUserWallets(ctx, userID)
+	endSpan(span, err)
+	return wallets, err
+}
+
+func (s *tracedWalletService) Deposit(ctx context.Context, walletID uint, amount float64, description string) (*domain.Transaction, error) {
+	ctx, span := s.start(ctx, "Deposit", idAttr("wallet.id", walletID))
+	transaction, err := s.next.Deposit(ctx, walletID, amount, description)
+	endSpan(span, err)
+	return transaction, err
+}
+
+func (s *tracedWalletService) Transfer(ctx context.Context, fromWalletID, toWalletID uint, amount float64, description string) (*domain.Transaction, error) {
+	ctx, span := s.start(ctx, "Transfer", idAttr("wallet.from_id", fromWalletID), idAttr("wallet.to_id", toWalletID))
+	transaction, err := s.next.Transfer(ctx, fromWalletID, toWalletID, amount, description)
+	endSpan(span, err)
+	return transaction, err
+}
+
+func (s *tracedWalletService) PageTransactions(ctx context.Context, walletID uint, req repository.PageRequest) (*repository.Page[*domain.Transaction], error) {
+	ctx, span := s.start(ctx, "PageTransactions", idAttr("wallet.id", walletID))
+	page, err := s.next.PageTransactions(ctx, walletID, req)
+	endSpan(span, err)
+	return page, err
+}
+
+func (s *tracedWalletService) Adjust(ctx context.Context, walletID uint, amount float64, reason string) (*domain.Transaction, error) {
+	ctx, span := s.start(ctx, "Adjust", idAttr("wallet.id", walletID))
+	transaction, err := s.next.Adjust(ctx, walletID, amount, reason)
+	endSpan(span, err)
+	return transaction, err
+}
+
+func (s *tracedWalletService) Freeze(ctx context.Context, walletID uint, reason string) (*domain.Wallet, error) {
+	ctx, span := s.start(ctx, "Freeze", idAttr("wallet.id", walletID))
+	wallet, err := s.next.Freeze(ctx, walletID, reason)
+	endSpan(span, err)
+	return wallet, err
+}
+
+func (s *tracedWalletService) Unfreeze(ctx context.Context, walletID uint) (*domain.Wallet, error) {
+	ctx, span := s.start(ctx, "Unfreeze", idAttr("wallet.id", walletID))
+	wallet, err := s.next.Unfreeze(ctx, walletID)
+	endSpan(span, err)
+	return wallet, err
+}
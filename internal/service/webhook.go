@@ -0,0 +1,481 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	mathrand "math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/events"
+	"github.com/SahandMohammed/wallet-service/internal/logging"
+	"github.com/SahandMohammed/wallet-service/internal/repository"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	WebhookEventPing = "ping"
+
+	webhookBaseBackoff = 30 * time.Second
+	webhookMaxBackoff  = 6 * time.Hour
+	webhookClaimLease  = 2 * time.Minute
+	webhookBatchSize   = 50
+)
+
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// ErrWebhookAddressNotAllowed is returned for endpoints on loopback, private,
+// link-local or unspecified addresses, which would let users make the server
+// send requests into its own network
+var ErrWebhookAddressNotAllowed = errors.New("webhook url must resolve to a public address")
+
+type WebhookService interface {
+	CreateEndpoint(ctx context.Context, userID uint, input CreateWebhookInput) (*domain.WebhookEndpoint, error)
+	ListEndpoints(ctx context.Context, userID uint) ([]*domain.WebhookEndpoint, error)
+	DeleteEndpoint(ctx context.Context, userID, endpointID uint) error
+	// PageDeliveries returns the page of an endpoint's delivery log selected
+	// by req, newest first
+	PageDeliveries(ctx context.Context, userID, endpointID uint, req repository.PageRequest) (*repository.Page[*domain.WebhookDelivery], error)
+	Redeliver(ctx context.Context, userID, endpointID, deliveryID uint) (*domain.WebhookDelivery, error)
+	Ping(ctx context.Context, userID, endpointID uint) (*domain.WebhookDelivery, error)
+
+	// Publish queues deliveries for an event, so the service can be plugged
+	// into the outbox relay as an events.Publisher.
+	Publish(ctx context.Context, envelope events.Envelope) error
+	DispatchDue(ctx context.Context) (int, error)
+	RunDispatcher(ctx context.Context, interval time.Duration)
+}
+
+type CreateWebhookInput struct {
+	URL        string
+	WalletID   *uint
+	EventTypes []string
+}
+
+// WebhookPayload is the JSON body sent to endpoints
+type WebhookPayload struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+type webhookService struct {
+	webhookRepo          repository.WebhookRepository
+	walletRepo           repository.WalletRepository
+	httpClient           *http.Client
+	maxAttempts          int
+	allowPrivateNetworks bool
+}
+
+// NewWebhookService creates the service. Unless allowPrivateNetworks is set,
+// endpoints must resolve to public addresses.
+func NewWebhookService(
+	webhookRepo repository.WebhookRepository,
+	walletRepo repository.WalletRepository,
+	timeout time.Duration,
+	maxAttempts int,
+	allowPrivateNetworks bool,
+) WebhookService {
+	return &webhookService{
+		webhookRepo:          webhookRepo,
+		walletRepo:           walletRepo,
+		httpClient:           newWebhookClient(timeout, allowPrivateNetworks),
+		maxAttempts:          maxAttempts,
+		allowPrivateNetworks: allowPrivateNetworks,
+	}
+}
+
+// newWebhookClient returns the client deliveries are sent with. The address
+// check runs on every connection, after DNS resolution, so a host name that
+// resolves to a public address when the endpoint is created cannot be
+// pointed at the internal network later.
+func newWebhookClient(timeout time.Duration, allowPrivateNetworks bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivateNetworks {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicAddress(ip) {
+				return ErrWebhookAddressNotAllowed
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Through a proxy the check would only see the proxy's address
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		// A redirect is a failed delivery, endpoints must answer themselves
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func (s *webhookService) CreateEndpoint(ctx context.Context, userID uint, input CreateWebhookInput) (*domain.WebhookEndpoint, error) {
+	parsed, err := url.Parse(input.URL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return nil, errors.New("url must be an absolute http or https URL")
+	}
+	if err := s.checkHost(ctx, parsed.Hostname()); err != nil {
+		return nil, err
+	}
+
+	for _, eventType := range input.EventTypes {
+		if !isWebhookEventType(eventType) {
+			return nil, fmt.Errorf("unknown event type %q", eventType)
+		}
+	}
+
+	if input.WalletID != nil {
+		wallet, err := s.walletRepo.GetByID(ctx, *input.WalletID)
+		if err != nil || wallet.UserID != userID {
+			return nil, errors.New("wallet not found")
+		}
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	endpoint := &domain.WebhookEndpoint{
+		UserID:     userID,
+		WalletID:   input.WalletID,
+		URL:        input.URL,
+		Secret:     "whsec_" + hex.EncodeToString(secret),
+		EventTypes: input.EventTypes,
+		Active:     true,
+	}
+
+	if err := s.webhookRepo.CreateEndpoint(ctx, endpoint); err != nil {
+		return nil, err
+	}
+
+	return endpoint, nil
+}
+
+func (s *webhookService) ListEndpoints(ctx context.Context, userID uint) ([]*domain.WebhookEndpoint, error) {
+	return s.webhookRepo.GetEndpointsByUserID(ctx, userID)
+}
+
+func (s *webhookService) DeleteEndpoint(ctx context.Context, userID, endpointID uint) error {
+	deleted, err := s.webhookRepo.DeleteEndpoint(ctx, endpointID, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (s *webhookService) PageDeliveries(ctx context.Context, userID, endpointID uint, req repository.PageRequest) (*repository.Page[*domain.WebhookDelivery], error) {
+	if _, err := s.getOwnedEndpoint(ctx, userID, endpointID); err != nil {
+		return nil, err
+	}
+	return s.webhookRepo.PageDeliveries(ctx, endpointID, req)
+}
+
+// Redeliver sends a delivery again right away, whatever its state, and gives
+// it a fresh retry budget.
+func (s *webhookService) Redeliver(ctx context.Context, userID, endpointID, deliveryID uint) (*domain.WebhookDelivery, error) {
+	if _, err := s.getOwnedEndpoint(ctx, userID, endpointID); err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.webhookRepo.GetDelivery(ctx, deliveryID)
+	if err != nil || delivery.EndpointID != endpointID {
+		return nil, errors.New("delivery not found")
+	}
+
+	delivery.Status = domain.WebhookDeliveryPending
+	delivery.Attempts = 0
+	s.deliver(ctx, delivery)
+
+	return delivery, nil
+}
+
+// Ping sends a test event once, without retries
+func (s *webhookService) Ping(ctx context.Context, userID, endpointID uint) (*domain.WebhookDelivery, error) {
+	endpoint, err := s.getOwnedEndpoint(ctx, userID, endpointID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, _ := json.Marshal(map[string]interface{}{"endpoint_id": endpoint.ID})
+	envelope := events.Envelope{
+		EventID:    uuid.New().String(),
+		Type:       WebhookEventPing,
+		OccurredAt: time.Now().UTC(),
+		Payload:    data,
+	}
+
+	// Queue it outside the dispatcher's reach, it is sent right here
+	delivery, err := s.queueDelivery(ctx, endpoint, envelope, time.Now().Add(webhookClaimLease))
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.Endpoint = *endpoint
+	delivery.Attempts = s.maxAttempts - 1
+	s.deliver(ctx, delivery)
+
+	return delivery, nil
+}
+
+func (s *webhookService) Publish(ctx context.Context, envelope events.Envelope) error {
+	subjects, err := events.Subjects(envelope)
+	if err != nil || len(subjects) == 0 {
+		return err
+	}
+
+	userIDs := make([]uint, 0, len(subjects))
+	for _, subject := range subjects {
+		userIDs = append(userIDs, subject.UserID)
+	}
+
+	endpoints, err := s.webhookRepo.GetActiveEndpointsForUsers(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, endpoint := range endpoints {
+		if !endpoint.Subscribes(envelope.Type) || !endpointMatches(endpoint, subjects) {
+			continue
+		}
+		if _, err := s.queueDelivery(ctx, endpoint, envelope, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DispatchDue sends one batch of due deliveries and returns its size
+func (s *webhookService) DispatchDue(ctx context.Context) (int, error) {
+	deliveries, err := s.webhookRepo.ClaimDueDeliveries(ctx, time.Now(), webhookClaimLease, webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, delivery := range deliveries {
+		s.deliver(ctx, delivery)
+	}
+
+	return len(deliveries), nil
+}
+
+// RunDispatcher sends due deliveries every interval until ctx is cancelled
+func (s *webhookService) RunDispatcher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		dispatched, err := s.DispatchDue(ctx)
+		if err != nil {
+			logging.FromContext(ctx).WithError(err).Error("Failed to dispatch webhook deliveries")
+		}
+
+		// Keep going while there is a backlog
+		if err == nil && dispatched == webhookBatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *webhookService) queueDelivery(ctx context.Context, endpoint *domain.WebhookEndpoint, envelope events.Envelope, nextAttemptAt time.Time) (*domain.WebhookDelivery, error) {
+	body, err := json.Marshal(WebhookPayload{
+		ID:        envelope.EventID,
+		Type:      envelope.Type,
+		CreatedAt: envelope.OccurredAt,
+		Data:      envelope.Payload,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := &domain.WebhookDelivery{
+		EndpointID:    endpoint.ID,
+		EventID:       envelope.EventID,
+		EventType:     envelope.Type,
+		Payload:       string(body),
+		Status:        domain.WebhookDeliveryPending,
+		NextAttemptAt: nextAttemptAt,
+	}
+
+	if err := s.webhookRepo.CreateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// deliver makes one attempt and records the outcome. Failed deliveries are
+// retried with exponential backoff until maxAttempts, then dead-lettered.
+func (s *webhookService) deliver(ctx context.Context, delivery *domain.WebhookDelivery) {
+	now := time.Now()
+	delivery.Attempts++
+
+	var statusCode int
+	var err error
+	if delivery.Endpoint.DeletedAt.Valid || !delivery.Endpoint.Active {
+		err = errors.New("endpoint is no longer active")
+		delivery.Attempts = s.maxAttempts
+	} else {
+		statusCode, err = s.send(ctx, &delivery.Endpoint, delivery)
+	}
+
+	delivery.LastStatusCode = statusCode
+	if err == nil {
+		delivery.Status = domain.WebhookDeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+	} else {
+		delivery.LastError = truncate(err.Error(), 255)
+		if delivery.Attempts >= s.maxAttempts {
+			delivery.Status = domain.WebhookDeliveryDead
+		} else {
+			delivery.Status = domain.WebhookDeliveryPending
+			delivery.NextAttemptAt = now.Add(webhookBackoff(delivery.Attempts))
+		}
+	}
+
+	if err := s.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+		logging.FromContext(ctx).WithError(err).WithField("delivery_id", delivery.ID).Error("Failed to record webhook delivery attempt")
+	}
+
+	if delivery.Status == domain.WebhookDeliveryDead {
+		logging.FromContext(ctx).WithFields(logrus.Fields{
+			"delivery_id": delivery.ID,
+			"endpoint_id": delivery.EndpointID,
+			"event_id":    delivery.EventID,
+			"attempts":    delivery.Attempts,
+		}).Warn("Webhook delivery moved to dead letter")
+	}
+}
+
+func (s *webhookService) send(ctx context.Context, endpoint *domain.WebhookEndpoint, delivery *domain.WebhookDelivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader([]byte(delivery.Payload)))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "wallet-service-webhooks/1.0")
+	req.Header.Set("X-Webhook-ID", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", SignWebhook(endpoint.Secret, timestamp, []byte(delivery.Payload)))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// checkHost resolves host and rejects it when any of its addresses is not
+// public. Deliveries check the address they connect to again.
+func (s *webhookService) checkHost(ctx context.Context, host string) error {
+	if s.allowPrivateNetworks {
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("could not resolve webhook host %q", host)
+	}
+	for _, addr := range addrs {
+		if !publicAddress(addr.IP) {
+			return ErrWebhookAddressNotAllowed
+		}
+	}
+	return nil
+}
+
+func (s *webhookService) getOwnedEndpoint(ctx context.Context, userID, endpointID uint) (*domain.WebhookEndpoint, error) {
+	endpoint, err := s.webhookRepo.GetEndpoint(ctx, endpointID)
+	if err != nil || endpoint.UserID != userID {
+		return nil, ErrWebhookNotFound
+	}
+	return endpoint, nil
+}
+
+// SignWebhook returns the X-Webhook-Signature header value: the hex
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with the endpoint secret.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func webhookBackoff(attempt int) time.Duration {
+	backoff := webhookMaxBackoff
+	if attempt < 20 {
+		backoff = min(webhookBaseBackoff<<(attempt-1), webhookMaxBackoff)
+	}
+	// Up to 10% jitter so retries from a burst do not arrive together
+	return backoff + time.Duration(mathrand.Int64N(int64(backoff)/10+1))
+}
+
+// publicAddress reports whether ip may receive webhooks
+func publicAddress(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast()
+}
+
+func endpointMatches(endpoint *domain.WebhookEndpoint, subjects []events.Subject) bool {
+	for _, subject := range subjects {
+		if subject.UserID != endpoint.UserID {
+			continue
+		}
+		if endpoint.WalletID == nil || *endpoint.WalletID == subject.WalletID {
+			return true
+		}
+	}
+	return false
+}
+
+func isWebhookEventType(eventType string) bool {
+	switch eventType {
+	case events.TypeWalletCreated, events.TypeDepositCompleted, events.TypeTransferCompleted, events.TypeBalanceAdjusted:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,369 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/SahandMohammed/wallet-service/internal/domain"
+	"github.com/SahandMohammed/wallet-service/internal/events"
+	"github.com/SahandMohammed/wallet-service/internal/repository"
+	"github.com/SahandMohammed/wallet-service/internal/repository/memory"
+)
+
+const webhookTestAttempts = 3
+
+type webhookFixture struct {
+	service  WebhookService
+	store    *memory.Store
+	userID   uint
+	walletID uint
+}
+
+// newWebhookFixture creates a user with one wallet. Tests that deliver to
+// an httptest receiver need allowPrivateNetworks, it listens on loopback.
+func newWebhookFixture(t *testing.T, allowPrivateNetworks bool) *webhookFixture {
+	t.Helper()
+	ctx := context.Background()
+	store := memory.NewStore()
+
+	user := &domain.User{Username: "alice", Password: "x"}
+	if err := store.Users().Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	wallet := &domain.Wallet{UserID: user.ID}
+	if err := store.Wallets().Create(ctx, wallet); err != nil {
+		t.Fatal(err)
+	}
+
+	return &webhookFixture{
+		service:  NewWebhookService(store.Webhooks(), store.Wallets(), time.Second, webhookTestAttempts, allowPrivateNetworks),
+		store:    store,
+		userID:   user.ID,
+		walletID: wallet.ID,
+	}
+}
+
+func (f *webhookFixture) endpoint(t *testing.T, url string) *domain.WebhookEndpoint {
+	t.Helper()
+	endpoint, err := f.service.CreateEndpoint(context.Background(), f.userID, CreateWebhookInput{URL: url})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return endpoint
+}
+
+// deposit publishes a DepositCompleted event for the fixture's wallet
+func (f *webhookFixture) deposit(t *testing.T) events.Envelope {
+	t.Helper()
+	event, err := events.NewOutboxEvent(events.TypeDepositCompleted, events.AggregateWallet, f.walletID, events.DepositCompleted{
+		WalletID:     f.walletID,
+		UserID:       f.userID,
+		Amount:       500,
+		BalanceAfter: 500,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	event.CreatedAt = time.Now()
+	envelope := events.EnvelopeFromOutbox(event)
+	if err := f.service.Publish(context.Background(), envelope); err != nil {
+		t.Fatal(err)
+	}
+	return envelope
+}
+
+func (f *webhookFixture) dispatch(t *testing.T, want int) {
+	t.Helper()
+	dispatched, err := f.service.DispatchDue(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dispatched != want {
+		t.Fatalf("expected %d deliveries to be dispatched, got %d", want, dispatched)
+	}
+}
+
+func (f *webhookFixture) deliveries(t *testing.T, endpointID uint) []*domain.WebhookDelivery {
+	t.Helper()
+	page, err := f.service.PageDeliveries(context.Background(), f.userID, endpointID, repository.PageRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return page.Items
+}
+
+// makeDue moves the next attempt of a delivery into the past, as if its
+// backoff had run out
+func (f *webhookFixture) makeDue(t *testing.T, delivery *domain.WebhookDelivery) {
+	t.Helper()
+	delivery.NextAttemptAt = time.Now().Add(-time.Second)
+	if err := f.store.Webhooks().UpdateDelivery(context.Background(), delivery); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+// webhookReceiver records the requests it gets and answers with status
+type webhookReceiver struct {
+	*httptest.Server
+	status   atomic.Int32
+	mu       sync.Mutex
+	requests []receivedWebhook
+}
+
+func newWebhookReceiver(t *testing.T, status int) *webhookReceiver {
+	r := &webhookReceiver{}
+	r.status.Store(int32(status))
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		r.requests = append(r.requests, receivedWebhook{header: req.Header.Clone(), body: body})
+		r.mu.Unlock()
+		w.WriteHeader(int(r.status.Load()))
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *webhookReceiver) received() []receivedWebhook {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]receivedWebhook(nil), r.requests...)
+}
+
+func TestWebhookSignature(t *testing.T) {
+	f := newWebhookFixture(t, true)
+	receiver := newWebhookReceiver(t, http.StatusNoContent)
+	endpoint := f.endpoint(t, receiver.URL)
+
+	envelope := f.deposit(t)
+	// The relay may publish an event twice, it is delivered once
+	if err := f.service.Publish(context.Background(), envelope); err != nil {
+		t.Fatal(err)
+	}
+	f.dispatch(t, 1)
+
+	received := receiver.received()
+	if len(received) != 1 {
+		t.Fatalf("expected one request, got %d", len(received))
+	}
+	header, body := received[0].header, received[0].body
+
+	timestamp := header.Get("X-Webhook-Timestamp")
+	sent, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || time.Since(time.Unix(sent, 0)) > time.Minute {
+		t.Errorf("expected a current timestamp, got %q", timestamp)
+	}
+	if got, want := header.Get("X-Webhook-Signature"), SignWebhook(endpoint.Secret, timestamp, body); got != want {
+		t.Errorf("expected signature %q, got %q", want, got)
+	}
+	if SignWebhook(endpoint.Secret, timestamp, append(body, ' ')) == header.Get("X-Webhook-Signature") {
+		t.Error("expected the signature to cover the body")
+	}
+	if header.Get("X-Webhook-Event") != events.TypeDepositCompleted || header.Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected headers %v", header)
+	}
+
+	var payload WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.ID != envelope.EventID || payload.Type != events.TypeDepositCompleted {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+
+	deliveries := f.deliveries(t, endpoint.ID)
+	if len(deliveries) != 1 || header.Get("X-Webhook-ID") != strconv.FormatUint(uint64(deliveries[0].ID), 10) {
+		t.Fatalf("expected the delivery id in the header, got %v", header.Get("X-Webhook-ID"))
+	}
+	if deliveries[0].Status != domain.WebhookDeliverySucceeded || deliveries[0].DeliveredAt == nil || deliveries[0].LastStatusCode != http.StatusNoContent {
+		t.Errorf("expected a succeeded delivery, got %+v", deliveries[0])
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	f := newWebhookFixture(t, true)
+	receiver := newWebhookReceiver(t, http.StatusInternalServerError)
+	endpoint := f.endpoint(t, receiver.URL)
+	f.deposit(t)
+
+	for attempt := 1; attempt <= webhookTestAttempts; attempt++ {
+		before := time.Now()
+		f.dispatch(t, 1)
+		delivery := f.deliveries(t, endpoint.ID)[0]
+		if delivery.Attempts != attempt || delivery.LastStatusCode != http.StatusInternalServerError {
+			t.Fatalf("attempt %d: unexpected delivery %+v", attempt, delivery)
+		}
+
+		if attempt == webhookTestAttempts {
+			if delivery.Status != domain.WebhookDeliveryDead {
+				t.Fatalf("expected the delivery to be dead-lettered, got %s", delivery.Status)
+			}
+			break
+		}
+
+		// Retried after 30s, 60s, ... with up to 10% jitter
+		backoff := webhookBaseBackoff << (attempt - 1)
+		wait := delivery.NextAttemptAt.Sub(before)
+		if delivery.Status != domain.WebhookDeliveryPending || wait < backoff || wait > backoff+backoff/10+time.Second {
+			t.Fatalf("attempt %d: expected a retry in %s, got %s in %s", attempt, backoff, delivery.Status, wait)
+		}
+		f.dispatch(t, 0)
+		f.makeDue(t, delivery)
+	}
+
+	// Dead deliveries are not picked up again
+	delivery := f.deliveries(t, endpoint.ID)[0]
+	f.makeDue(t, delivery)
+	f.dispatch(t, 0)
+	if got := len(receiver.received()); got != webhookTestAttempts {
+		t.Errorf("expected %d requests, got %d", webhookTestAttempts, got)
+	}
+
+	// Redelivery sends it right away with a fresh retry budget
+	receiver.status.Store(http.StatusOK)
+	redelivered, err := f.service.Redeliver(context.Background(), f.userID, endpoint.ID, delivery.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redelivered.Status != domain.WebhookDeliverySucceeded || redelivered.Attempts != 1 || redelivered.DeliveredAt == nil {
+		t.Errorf("expected the redelivery to succeed, got %+v", redelivered)
+	}
+	if got := len(receiver.received()); got != webhookTestAttempts+1 {
+		t.Errorf("expected %d requests, got %d", webhookTestAttempts+1, got)
+	}
+
+	if _, err := f.service.Redeliver(context.Background(), f.userID+1, endpoint.ID, delivery.ID); !errors.Is(err, ErrWebhookNotFound) {
+		t.Errorf("expected other users to be refused, got %v", err)
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		3:  2 * time.Minute,
+		10: 256 * time.Minute,
+		11: webhookMaxBackoff,
+		40: webhookMaxBackoff,
+	}
+	for attempt, want := range tests {
+		for i := 0; i < 20; i++ {
+			if got := webhookBackoff(attempt); got < want || got > want+want/10 {
+				t.Errorf("attempt %d: expected %s plus up to 10%%, got %s", attempt, want, got)
+			}
+		}
+	}
+}
+
+func TestWebhookPing(t *testing.T) {
+	f := newWebhookFixture(t, true)
+	receiver := newWebhookReceiver(t, http.StatusOK)
+	endpoint := f.endpoint(t, receiver.URL)
+	ctx := context.Background()
+
+	delivery, err := f.service.Ping(ctx, f.userID, endpoint.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivery.Status != domain.WebhookDeliverySucceeded || delivery.EventType != WebhookEventPing {
+		t.Errorf("expected a succeeded ping, got %+v", delivery)
+	}
+	if received := receiver.received(); len(received) != 1 || received[0].header.Get("X-Webhook-Event") != WebhookEventPing {
+		t.Fatalf("expected one ping request, got %d", len(received))
+	}
+
+	// Pings are not retried
+	receiver.status.Store(http.StatusServiceUnavailable)
+	if delivery, err = f.service.Ping(ctx, f.userID, endpoint.ID); err != nil {
+		t.Fatal(err)
+	}
+	if delivery.Status != domain.WebhookDeliveryDead || delivery.LastStatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected a failed ping to be dead, got %+v", delivery)
+	}
+	f.makeDue(t, delivery)
+	f.dispatch(t, 0)
+
+	if _, err := f.service.Ping(ctx, f.userID+1, endpoint.ID); !errors.Is(err, ErrWebhookNotFound) {
+		t.Errorf("expected other users to be refused, got %v", err)
+	}
+
+	// Deleted endpoints are not sent to again
+	if err := f.service.DeleteEndpoint(ctx, f.userID, endpoint.ID); err != nil {
+		t.Fatal(err)
+	}
+	before := len(receiver.received())
+	if delivery, err = f.service.Redeliver(ctx, f.userID, endpoint.ID, delivery.ID); err == nil {
+		t.Errorf("expected deleted endpoints to be refused, got %+v", delivery)
+	}
+	if got := len(receiver.received()); got != before {
+		t.Errorf("expected no request to a deleted endpoint, got %d", got-before)
+	}
+}
+
+func TestWebhookAddresses(t *testing.T) {
+	f := newWebhookFixture(t, false)
+	ctx := context.Background()
+
+	blocked := []string{
+		"http://127.0.0.1/hook",
+		"http://localhost:8080/hook",
+		"http://10.0.0.1/hook",
+		"http://172.16.0.1/hook",
+		"https://192.168.1.10/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://0.0.0.0/hook",
+		"http://[::1]/hook",
+		"http://[fe80::1]/hook",
+		"http://[fd00::1]/hook",
+	}
+	for _, url := range blocked {
+		if _, err := f.service.CreateEndpoint(ctx, f.userID, CreateWebhookInput{URL: url}); !errors.Is(err, ErrWebhookAddressNotAllowed) {
+			t.Errorf("%s: expected the address to be refused, got %v", url, err)
+		}
+	}
+	if _, err := f.service.CreateEndpoint(ctx, f.userID, CreateWebhookInput{URL: "ftp://example.com/hook"}); err == nil {
+		t.Error("expected other schemes to be refused")
+	}
+	if _, err := f.service.CreateEndpoint(ctx, f.userID, CreateWebhookInput{URL: "https://93.184.215.14/hook"}); err != nil {
+		t.Errorf("expected a public address to be accepted, got %v", err)
+	}
+}
+
+func TestWebhookDeliveryAddressCheck(t *testing.T) {
+	f := newWebhookFixture(t, false)
+	receiver := newWebhookReceiver(t, http.StatusOK)
+	ctx := context.Background()
+
+	// An endpoint whose host resolved to a public address when it was
+	// created, and to loopback by the time it is delivered to
+	endpoint := &domain.WebhookEndpoint{UserID: f.userID, URL: receiver.URL, Secret: "whsec_test", Active: true}
+	if err := f.store.Webhooks().CreateEndpoint(ctx, endpoint); err != nil {
+		t.Fatal(err)
+	}
+
+	delivery, err := f.service.Ping(ctx, f.userID, endpoint.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivery.Status != domain.WebhookDeliveryDead || !strings.Contains(delivery.LastError, ErrWebhookAddressNotAllowed.Error()) {
+		t.Errorf("expected the connection to be refused, got %+v", delivery)
+	}
+	if got := len(receiver.received()); got != 0 {
+		t.Errorf("expected no request to reach the receiver, got %d", got)
+	}
+}
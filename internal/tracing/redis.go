@@ -0,0 +1,12 @@
+package tracing
+
+import (
+	"github.com/redis/go-redis/extra/redisotel/v9"
+	"github.com/redis/go-redis/v9"
+)
+
+// InstrumentRedis starts a client span for every command. Command
+// arguments are left out, they hold cached records and tokens.
+func InstrumentRedis(client *redis.Client) error {
+	return redisotel.InstrumentTracing(client, redisotel.WithDBStatement(false))
+}
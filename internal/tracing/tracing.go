@@ -0,0 +1,116 @@
+// Package tracing sets up OpenTelemetry tracing and instruments GORM and
+// Redis clients. Spans are propagated in W3C trace-context headers.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName is reported unless OTEL_SERVICE_NAME is set
+const ServiceName = "wallet-service"
+
+const instrumentationName = "github.com/SahandMohammed/wallet-service"
+
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http"
+)
+
+type Config struct {
+	// Exporter is none, otlp or stdout
+	Exporter string
+	// OTLPProtocol is grpc or http. The endpoint, headers and TLS settings
+	// come from the standard OTEL_EXPORTER_OTLP_* variables.
+	OTLPProtocol string
+	// File receives the stdout exporter's spans, standard output when empty
+	File string
+	// SampleRatio is the share of new traces that are recorded. Requests
+	// that arrive with a sampled parent are always recorded.
+	SampleRatio float64
+}
+
+// Setup installs the W3C propagator and, unless the exporter is none, a
+// global tracer provider. The returned function flushes pending spans and
+// must be called before the process exits.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var (
+		exporter sdktrace.SpanExporter
+		closer   io.Closer
+		err      error
+	)
+	switch cfg.Exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		switch cfg.OTLPProtocol {
+		case ProtocolGRPC, "":
+			exporter, err = otlptracegrpc.New(ctx)
+		case ProtocolHTTP:
+			exporter, err = otlptracehttp.New(ctx)
+		default:
+			return nil, fmt.Errorf("unknown OTLP protocol %q", cfg.OTLPProtocol)
+		}
+	case ExporterStdout:
+		var w io.Writer = os.Stdout
+		if cfg.File != "" {
+			f, ferr := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+			if ferr != nil {
+				return nil, fmt.Errorf("failed to open trace file: %w", ferr)
+			}
+			w, closer = f, f
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(w))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	res, err := resource.Merge(
+		resource.NewSchemaless(semconv.ServiceName(ServiceName)),
+		resource.Environment(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			closer.Close()
+		}
+		return err
+	}, nil
+}
+
+// Tracer returns the tracer for spans started by this service
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
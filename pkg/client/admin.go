@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// The admin methods require a token for an admin user.
+
+const dateLayout = "2006-01-02"
+
+// ListUsers returns users together with their wallets
+func (c *Client) ListUsers(ctx context.Context, page Page) ([]User, *PageInfo, error) {
+	var users []User
+	info, err := c.doPage(ctx, request{method: http.MethodGet, path: "/admin/users", query: page.values()}, &users)
+	if err != nil {
+		return nil, nil, err
+	}
+	return users, info, nil
+}
+
+// ListAllTransactions returns transactions across all wallets
+func (c *Client) ListAllTransactions(ctx context.Context, filters AdminTransactionFilters) ([]Transaction, *PageInfo, error) {
+	query := filters.Page.values()
+	if filters.UserID != nil {
+		query.Set("user_id", strconv.FormatUint(uint64(*filters.UserID), 10))
+	}
+	if filters.Type != "" {
+		query.Set("type", string(filters.Type))
+	}
+	setDate(query.Set, "start_date", filters.StartDate)
+	setDate(query.Set, "end_date", filters.EndDate)
+
+	var txs []Transaction
+	info, err := c.doPage(ctx, request{method: http.MethodGet, path: "/admin/transactions", query: query}, &txs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return txs, info, nil
+}
+
+func (c *Client) ListAuditLogs(ctx context.Context, filters AuditLogFilters) ([]AuditLog, *PageInfo, error) {
+	query := filters.Page.values()
+	if filters.ActorID != nil {
+		query.Set("actor_id", strconv.FormatUint(uint64(*filters.ActorID), 10))
+	}
+	for name, value := range map[string]string{
+		"action":      filters.Action,
+		"target_type": filters.TargetType,
+		"target_id":   filters.TargetID,
+		"request_id":  filters.RequestID,
+	} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	setDate(query.Set, "start_date", filters.StartDate)
+	setDate(query.Set, "end_date", filters.EndDate)
+
+	var entries []AuditLog
+	info, err := c.doPage(ctx, request{method: http.MethodGet, path: "/admin/audit-logs", query: query}, &entries)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entries, info, nil
+}
+
+func setDate(set func(key, value string), name string, date *time.Time) {
+	if date != nil {
+		set(name, date.Format(dateLayout))
+	}
+}
@@ -0,0 +1,46 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+type credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// Register creates a user account. It does not log in.
+func (c *Client) Register(ctx context.Context, username, password string) (*User, error) {
+	var user User
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/auth/register",
+		body:   credentials{Username: username, Password: password},
+		public: true,
+	}, &user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Login exchanges credentials for a JWT, which the client uses for later
+// requests. The token is also returned.
+func (c *Client) Login(ctx context.Context, username, password string) (string, error) {
+	var out struct {
+		Token string `json:"token"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/auth/login",
+		body:   credentials{Username: username, Password: password},
+		public: true,
+	}, &out)
+	if err != nil {
+		return "", err
+	}
+
+	c.setToken(out.Token)
+	return out.Token, nil
+}
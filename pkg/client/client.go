@@ -0,0 +1,353 @@
+// Package client is a typed Go client for the wallet service HTTP API.
+//
+// A client authenticates with a JWT, renewed automatically when credentials
+// are configured, or with an API key whose money movement requests are
+// signed. Errors returned by the server are mapped to *APIError values that
+// match the sentinel errors of this package with errors.Is.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTimeout = 30 * time.Second
+
+	// Tokens are renewed this long before they expire
+	refreshMargin = time.Minute
+)
+
+// Client calls the wallet service. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	userAgent  string
+
+	username string
+	password string
+
+	apiKey        string
+	signingSecret string
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+
+	// refreshMu makes concurrent callers share a single login
+	refreshMu sync.Mutex
+}
+
+type Option func(*Client)
+
+// WithHTTPClient replaces the default client, which has a 30 second timeout
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithCredentials makes the client log in on first use and log in again
+// whenever its token is about to expire or is rejected.
+func WithCredentials(username, password string) Option {
+	return func(c *Client) {
+		c.username = username
+		c.password = password
+	}
+}
+
+// WithToken sets a JWT obtained elsewhere
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.setToken(token)
+	}
+}
+
+// WithAPIKey authenticates with an API key. Deposits and transfers are signed
+// with signingSecret, both are returned when the key is created.
+func WithAPIKey(apiKey, signingSecret string) Option {
+	return func(c *Client) {
+		c.apiKey = apiKey
+		c.signingSecret = signingSecret
+	}
+}
+
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// New returns a client for the service at baseURL, e.g. http://localhost:8080
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme must be http or https", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		userAgent:  "wallet-service-go-client/1.0",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Token returns the current JWT, if any
+func (c *Client) Token() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.token
+}
+
+type envelope struct {
+	Data       json.RawMessage `json:"data"`
+	Pagination *PageInfo       `json:"pagination"`
+	Error      string          `json:"error"`
+}
+
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   interface{}
+
+	// public requests are sent without credentials
+	public bool
+	// signed requests carry a signature when an API key is used
+	signed         bool
+	idempotencyKey string
+}
+
+// do sends the request and decodes the data field of the response into out.
+// A JWT rejected by the server is renewed once when credentials are set.
+func (c *Client) do(ctx context.Context, req request, out interface{}) error {
+	_, err := c.doPage(ctx, req, out)
+	return err
+}
+
+// doPage is do for listings, it also returns the pagination of the response
+func (c *Client) doPage(ctx context.Context, req request, out interface{}) (*PageInfo, error) {
+	var body []byte
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := c.send(ctx, req, body, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var apiErr *APIError
+	if resp.err != nil && errors.As(resp.err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized &&
+		!req.public && c.apiKey == "" && c.username != "" {
+		if resp, err = c.send(ctx, req, body, true); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.err != nil {
+		return nil, resp.err
+	}
+	if out != nil && len(resp.data) > 0 && string(resp.data) != "null" {
+		if err := json.Unmarshal(resp.data, out); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	if resp.pagination == nil {
+		return &PageInfo{}, nil
+	}
+	return resp.pagination, nil
+}
+
+type response struct {
+	data       json.RawMessage
+	pagination *PageInfo
+	err        error
+}
+
+func (c *Client) send(ctx context.Context, req request, body []byte, forceLogin bool) (*response, error) {
+	u := *c.baseURL
+	u.Path += req.path
+	if len(req.query) > 0 {
+		u.RawQuery = req.query.Encode()
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("User-Agent", c.userAgent)
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if req.idempotencyKey != "" {
+		httpReq.Header.Set("Idempotency-Key", req.idempotencyKey)
+	}
+
+	if !req.public {
+		if err := c.authorize(ctx, httpReq, req, body, forceLogin); err != nil {
+			return nil, err
+		}
+	}
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	raw, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var env envelope
+	if len(raw) > 0 {
+		// Non-JSON bodies, e.g. from a proxy, are reported as the status text
+		_ = json.Unmarshal(raw, &env)
+	}
+
+	if httpResp.StatusCode >= 300 {
+		return &response{err: newAPIError(httpResp, env.Error)}, nil
+	}
+	return &response{data: env.Data, pagination: env.Pagination}, nil
+}
+
+func (c *Client) authorize(ctx context.Context, httpReq *http.Request, req request, body []byte, forceLogin bool) error {
+	if c.apiKey != "" {
+		httpReq.Header.Set("X-API-Key", c.apiKey)
+		if req.signed {
+			return c.sign(httpReq, body)
+		}
+		return nil
+	}
+
+	token, err := c.validToken(ctx, forceLogin)
+	if err != nil {
+		return err
+	}
+	if token == "" {
+		return ErrNotAuthenticated
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// validToken returns the current token, logging in first when it is missing
+// or about to expire and credentials are configured.
+func (c *Client) validToken(ctx context.Context, forceLogin bool) (string, error) {
+	token, fresh := c.currentToken()
+	if (fresh && !forceLogin) || c.username == "" {
+		return token, nil
+	}
+
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	// Another caller may have logged in while we waited
+	if current, fresh := c.currentToken(); fresh && current != token {
+		return current, nil
+	}
+
+	if _, err := c.Login(ctx, c.username, c.password); err != nil {
+		return "", err
+	}
+	return c.Token(), nil
+}
+
+func (c *Client) currentToken() (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fresh := c.token != "" && (c.tokenExpiry.IsZero() || time.Until(c.tokenExpiry) > refreshMargin)
+	return c.token, fresh
+}
+
+func (c *Client) setToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
+	c.tokenExpiry = tokenExpiry(token)
+}
+
+// sign adds the request signature headers expected for API key requests
+func (c *Client) sign(httpReq *http.Request, body []byte) error {
+	nonceBytes := make([]byte, 16)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		return err
+	}
+	nonce := hex.EncodeToString(nonceBytes)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	bodyHash := sha256.Sum256(body)
+	canonical := strings.Join([]string{
+		strings.ToUpper(httpReq.Method),
+		httpReq.URL.RequestURI(),
+		timestamp,
+		nonce,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+
+	mac := hmac.New(sha256.New, []byte(c.signingSecret))
+	mac.Write([]byte(canonical))
+
+	httpReq.Header.Set("X-Signature", hex.EncodeToString(mac.Sum(nil)))
+	httpReq.Header.Set("X-Signature-Timestamp", timestamp)
+	httpReq.Header.Set("X-Signature-Nonce", nonce)
+	return nil
+}
+
+// tokenExpiry reads the exp claim of a JWT without verifying it. The zero
+// time is returned when it cannot be read.
+func tokenExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}
+	}
+	var claims struct {
+		ExpiresAt int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.ExpiresAt == 0 {
+		return time.Time{}
+	}
+	return time.Unix(claims.ExpiresAt, 0)
+}
+
+// NewIdempotencyKey returns a random key for Deposit and Transfer. Keep it
+// to retry a request that may or may not have been applied.
+func NewIdempotencyKey() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic("client: failed to generate idempotency key: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
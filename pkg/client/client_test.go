@@ -0,0 +1,183 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// fakeServer issues numbered tokens and rejects any token but the latest
+type fakeServer struct {
+	mu       sync.Mutex
+	logins   int
+	keys     []string
+	rejectAt int
+}
+
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	write := func(status int, body interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(body)
+	}
+
+	switch r.URL.Path {
+	case "/auth/login":
+		var creds credentials
+		_ = json.NewDecoder(r.Body).Decode(&creds)
+		if creds.Password != "secret" {
+			write(http.StatusUnauthorized, map[string]string{"error": "Invalid credentials"})
+			return
+		}
+		s.logins++
+		write(http.StatusOK, map[string]interface{}{"data": map[string]string{"token": tokenFor(s.logins)}})
+	case "/wallets/deposit":
+		if r.Header.Get("Authorization") != "Bearer "+tokenFor(s.logins) {
+			write(http.StatusUnauthorized, map[string]string{"error": "Token has been revoked"})
+			return
+		}
+		s.keys = append(s.keys, r.Header.Get("Idempotency-Key"))
+		if len(s.keys) == s.rejectAt {
+			// Simulate the session being revoked between requests
+			s.logins++
+			write(http.StatusUnauthorized, map[string]string{"error": "Token has been revoked"})
+			return
+		}
+		write(http.StatusOK, map[string]interface{}{"data": map[string]interface{}{
+			"transaction_id": 7, "wallet_id": 1, "type": "deposit", "amount": 12.5,
+		}})
+	case "/wallets/1/transactions":
+		// Two pages of one transaction each
+		query := r.URL.Query()
+		if query.Get("limit") != "1" || (query.Get("cursor") != "" && query.Get("cursor") != "older") {
+			write(http.StatusBadRequest, map[string]string{"error": "Invalid cursor"})
+			return
+		}
+		pagination := map[string]interface{}{"limit": 1, "next_cursor": "older"}
+		id := 9
+		if query.Get("cursor") == "older" {
+			pagination = map[string]interface{}{"limit": 1, "prev_cursor": "newer"}
+			id = 8
+		}
+		if query.Get("include_total") == "true" {
+			pagination["total"] = 2
+		}
+		write(http.StatusOK, map[string]interface{}{
+			"data":       []map[string]interface{}{{"transaction_id": id, "wallet_id": 1}},
+			"pagination": pagination,
+		})
+	case "/wallets/2":
+		write(http.StatusNotFound, map[string]string{"error": "Wallet not found"})
+	case "/wallets/3":
+		w.Header().Set("Retry-After", "30")
+		write(http.StatusTooManyRequests, map[string]string{"error": "Rate limit exceeded"})
+	default:
+		write(http.StatusNotFound, map[string]string{"error": "Not found"})
+	}
+}
+
+func tokenFor(n int) string {
+	return "token-" + string(rune('0'+n))
+}
+
+func newTestClient(t *testing.T, server *fakeServer, opts ...Option) *Client {
+	t.Helper()
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	c, err := New(ts.URL, opts...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return c
+}
+
+func TestLoginOnFirstUseAndAfterRejection(t *testing.T) {
+	server := &fakeServer{rejectAt: 2}
+	c := newTestClient(t, server, WithCredentials("alice", "secret"))
+	ctx := context.Background()
+
+	tx, err := c.Deposit(ctx, DepositInput{WalletID: 1, Amount: 12.5})
+	if err != nil {
+		t.Fatalf("first deposit: %v", err)
+	}
+	if tx.ID != 7 || tx.Amount != 12.5 || tx.Type != TransactionTypeDeposit {
+		t.Errorf("unexpected transaction %+v", tx)
+	}
+
+	key := NewIdempotencyKey()
+	if _, err := c.Deposit(ctx, DepositInput{WalletID: 1, Amount: 12.5, IdempotencyKey: key}); err != nil {
+		t.Fatalf("second deposit: %v", err)
+	}
+
+	if server.logins != 3 {
+		t.Errorf("expected a login on first use and after the rejection, got %d logins", server.logins)
+	}
+	if len(server.keys) != 3 || server.keys[1] != key || server.keys[2] != key {
+		t.Errorf("expected the retry to reuse the idempotency key, got %v", server.keys)
+	}
+	if server.keys[0] == "" || server.keys[0] == key {
+		t.Errorf("expected a generated idempotency key, got %q", server.keys[0])
+	}
+}
+
+func TestErrorMapping(t *testing.T) {
+	c := newTestClient(t, &fakeServer{}, WithToken("token-0"))
+	ctx := context.Background()
+
+	_, err := c.GetWallet(ctx, 2)
+	if !errors.Is(err, ErrWalletNotFound) {
+		t.Errorf("expected ErrWalletNotFound, got %v", err)
+	}
+
+	_, err = c.GetWallet(ctx, 3)
+	var apiErr *APIError
+	if !errors.Is(err, ErrRateLimited) || !errors.As(err, &apiErr) || apiErr.RetryAfter.Seconds() != 30 {
+		t.Errorf("expected ErrRateLimited with Retry-After, got %v", err)
+	}
+
+	bad := newTestClient(t, &fakeServer{}, WithCredentials("alice", "wrong"))
+	if _, err := bad.ListWallets(ctx); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected ErrInvalidCredentials, got %v", err)
+	}
+
+	anonymous := newTestClient(t, &fakeServer{})
+	if _, err := anonymous.ListWallets(ctx); !errors.Is(err, ErrNotAuthenticated) {
+		t.Errorf("expected ErrNotAuthenticated, got %v", err)
+	}
+}
+
+func TestPagination(t *testing.T) {
+	c := newTestClient(t, &fakeServer{}, WithToken("token-0"))
+	ctx := context.Background()
+
+	txs, info, err := c.ListTransactions(ctx, 1, Page{Limit: 1, IncludeTotal: true})
+	if err != nil {
+		t.Fatalf("first page: %v", err)
+	}
+	if len(txs) != 1 || txs[0].ID != 9 || info.PrevCursor != "" || info.Total == nil || *info.Total != 2 {
+		t.Errorf("unexpected first page %+v %+v", txs, info)
+	}
+
+	next, ok := info.Next()
+	if !ok || next.Cursor != "older" {
+		t.Fatalf("expected a next page, got %+v", next)
+	}
+	txs, info, err = c.ListTransactions(ctx, 1, next)
+	if err != nil {
+		t.Fatalf("second page: %v", err)
+	}
+	if len(txs) != 1 || txs[0].ID != 8 || info.PrevCursor != "newer" || info.Total != nil {
+		t.Errorf("unexpected second page %+v %+v", txs, info)
+	}
+	if _, ok := info.Next(); ok {
+		t.Error("expected the second page to be the last")
+	}
+}
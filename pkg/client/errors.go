@@ -0,0 +1,111 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotAuthenticated is returned before sending a protected request
+	// when the client has neither a token, credentials nor an API key.
+	ErrNotAuthenticated = errors.New("client is not authenticated")
+
+	ErrUnauthorized        = errors.New("unauthorized")
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrTokenRevoked        = errors.New("token has been revoked")
+	ErrForbidden           = errors.New("access denied")
+	ErrNotFound            = errors.New("not found")
+	ErrWalletNotFound      = errors.New("wallet not found")
+	ErrValidation          = errors.New("validation failed")
+	ErrInvalidAmount       = errors.New("amount must be positive")
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrSameWallet          = errors.New("cannot transfer to the same wallet")
+	ErrWalletFrozen        = errors.New("wallet is frozen")
+	ErrUsernameTaken       = errors.New("username already exists")
+	ErrRateLimited         = errors.New("rate limit exceeded")
+	ErrIdempotencyConflict = errors.New("request with this idempotency key is in progress")
+	ErrIdempotencyMismatch = errors.New("idempotency key was already used with a different request")
+	ErrServer              = errors.New("server error")
+)
+
+// APIError is an error response from the service. It matches one of the
+// sentinel errors above with errors.Is.
+type APIError struct {
+	StatusCode int
+	// Message is the error string returned by the server
+	Message string
+	// RetryAfter is set for rate limited requests
+	RetryAfter time.Duration
+
+	kind error
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return "wallet service: " + http.StatusText(e.StatusCode)
+	}
+	return "wallet service: " + e.Message
+}
+
+func (e *APIError) Unwrap() error {
+	return e.kind
+}
+
+// messageErrors maps error strings returned by the service
+var messageErrors = map[string]error{
+	"invalid credentials":                ErrInvalidCredentials,
+	"token has been revoked":             ErrTokenRevoked,
+	"session has been revoked":           ErrTokenRevoked,
+	"access denied":                      ErrForbidden,
+	"access denied to source wallet":     ErrForbidden,
+	"wallet not found":                   ErrWalletNotFound,
+	"source wallet not found":            ErrWalletNotFound,
+	"destination wallet not found":       ErrWalletNotFound,
+	"amount must be positive":            ErrInvalidAmount,
+	"insufficient balance":               ErrInsufficientBalance,
+	"cannot transfer to the same wallet": ErrSameWallet,
+	"wallet is frozen":                   ErrWalletFrozen,
+	"username already exists":            ErrUsernameTaken,
+}
+
+func newAPIError(resp *http.Response, message string) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    message,
+	}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	lower := strings.ToLower(message)
+	if kind, ok := messageErrors[lower]; ok {
+		apiErr.kind = kind
+		return apiErr
+	}
+	if strings.HasPrefix(lower, "validation failed") {
+		apiErr.kind = ErrValidation
+		return apiErr
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		apiErr.kind = ErrUnauthorized
+	case resp.StatusCode == http.StatusForbidden:
+		apiErr.kind = ErrForbidden
+	case resp.StatusCode == http.StatusNotFound:
+		apiErr.kind = ErrNotFound
+	case resp.StatusCode == http.StatusConflict:
+		apiErr.kind = ErrIdempotencyConflict
+	case resp.StatusCode == http.StatusUnprocessableEntity:
+		apiErr.kind = ErrIdempotencyMismatch
+	case resp.StatusCode == http.StatusTooManyRequests:
+		apiErr.kind = ErrRateLimited
+	case resp.StatusCode >= http.StatusInternalServerError:
+		apiErr.kind = ErrServer
+	}
+	return apiErr
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Amounts are in dollars, like the HTTP API.
+
+type User struct {
+	ID        uint      `json:"id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+	// IsAdmin and Wallets are only set by ListUsers
+	IsAdmin bool     `json:"is_admin,omitempty"`
+	Wallets []Wallet `json:"wallets,omitempty"`
+}
+
+type Wallet struct {
+	ID        uint      `json:"id"`
+	UserID    uint      `json:"user_id"`
+	Balance   float64   `json:"balance"`
+	CreatedAt time.Time `json:"created_at"`
+	// Frozen wallets are only reported by ListUsers
+	FrozenAt     *time.Time `json:"frozen_at,omitempty"`
+	FrozenReason string     `json:"frozen_reason,omitempty"`
+}
+
+type TransactionType string
+
+const (
+	TransactionTypeDeposit  TransactionType = "deposit"
+	TransactionTypeTransfer TransactionType = "transfer"
+	TransactionTypeWithdraw TransactionType = "withdraw"
+	// Manual corrections made by operators, positive or negative
+	TransactionTypeAdjustment TransactionType = "adjustment"
+)
+
+type Transaction struct {
+	ID              uint            `json:"transaction_id"`
+	WalletID        uint            `json:"wallet_id"`
+	Type            TransactionType `json:"type"`
+	Amount          float64         `json:"amount"`
+	BalanceBefore   float64         `json:"balance_before"`
+	BalanceAfter    float64         `json:"balance_after"`
+	FromWalletID    *uint           `json:"from_wallet_id,omitempty"`
+	ToWalletID      *uint           `json:"to_wallet_id,omitempty"`
+	TransactionUUID string          `json:"transaction_uuid"`
+	Description     string          `json:"description"`
+	CreatedAt       time.Time       `json:"created_at"`
+
+	// Set by admin listings only
+	Wallet *TransactionWallet `json:"wallet,omitempty"`
+	User   *TransactionUser   `json:"user,omitempty"`
+}
+
+type TransactionWallet struct {
+	ID     uint `json:"id"`
+	UserID uint `json:"user_id"`
+}
+
+type TransactionUser struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+}
+
+type AuditLog struct {
+	ID         uint   `json:"id"`
+	ActorID    *uint  `json:"actor_id,omitempty"`
+	ActorName  string `json:"actor_name"`
+	Action     string `json:"action"`
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+	// Before and After hold the JSON state of the target around the action
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	IPAddress string          `json:"ip_address"`
+	UserAgent string          `json:"user_agent"`
+	RequestID string          `json:"request_id"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type DepositInput struct {
+	WalletID    uint
+	Amount      float64
+	Description string
+	// IdempotencyKey makes the deposit safe to retry. A random key is used
+	// when empty, which only protects the client's own retries.
+	IdempotencyKey string
+}
+
+type TransferInput struct {
+	FromWalletID uint
+	ToWalletID   uint
+	Amount       float64
+	Description  string
+	// IdempotencyKey makes the transfer safe to retry. A random key is used
+	// when empty, which only protects the client's own retries.
+	IdempotencyKey string
+}
+
+// Page selects a page of a listing, newest first. Zero values use the
+// server defaults and start at the newest entry.
+type Page struct {
+	Limit int
+	// Cursor is the NextCursor or PrevCursor of an earlier page
+	Cursor string
+	// IncludeTotal asks the server to count the matching entries
+	IncludeTotal bool
+}
+
+// PageInfo locates a page in its listing. NextCursor is empty on the last
+// page and PrevCursor on the first one. Total is only set when the page was
+// requested with IncludeTotal.
+type PageInfo struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor"`
+	PrevCursor string `json:"prev_cursor"`
+	Total      *int64 `json:"total"`
+}
+
+// Next returns the page after this one, or false on the last page
+func (p *PageInfo) Next() (Page, bool) {
+	return Page{Limit: p.Limit, Cursor: p.NextCursor}, p.NextCursor != ""
+}
+
+type AdminTransactionFilters struct {
+	Page
+	UserID *uint
+	Type   TransactionType
+	// Dates are inclusive and compared by day
+	StartDate *time.Time
+	EndDate   *time.Time
+}
+
+type AuditLogFilters struct {
+	Page
+	ActorID    *uint
+	Action     string
+	TargetType string
+	TargetID   string
+	RequestID  string
+	StartDate  *time.Time
+	EndDate    *time.Time
+}
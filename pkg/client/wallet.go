@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// CreateWallet creates a wallet for the authenticated user
+func (c *Client) CreateWallet(ctx context.Context) (*Wallet, error) {
+	var wallet Wallet
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/wallets"}, &wallet); err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+func (c *Client) GetWallet(ctx context.Context, walletID uint) (*Wallet, error) {
+	var wallet Wallet
+	if err := c.do(ctx, request{method: http.MethodGet, path: fmt.Sprintf("/wallets/%d", walletID)}, &wallet); err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+// ListWallets returns the wallets of the authenticated user
+func (c *Client) ListWallets(ctx context.Context) ([]Wallet, error) {
+	var wallets []Wallet
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/wallets"}, &wallets); err != nil {
+		return nil, err
+	}
+	return wallets, nil
+}
+
+// Deposit adds funds to a wallet. Retrying with the same IdempotencyKey
+// returns the original transaction instead of depositing twice.
+func (c *Client) Deposit(ctx context.Context, input DepositInput) (*Transaction, error) {
+	body := struct {
+		WalletID    uint    `json:"wallet_id"`
+		Amount      float64 `json:"amount"`
+		Description string  `json:"description,omitempty"`
+	}{input.WalletID, input.Amount, input.Description}
+
+	var tx Transaction
+	err := c.do(ctx, request{
+		method:         http.MethodPost,
+		path:           "/wallets/deposit",
+		body:           body,
+		signed:         true,
+		idempotencyKey: idempotencyKey(input.IdempotencyKey),
+	}, &tx)
+	if err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// Transfer moves funds between wallets. Retrying with the same
+// IdempotencyKey returns the original transaction instead of transferring
+// twice. The returned transaction is the debit on the source wallet.
+func (c *Client) Transfer(ctx context.Context, input TransferInput) (*Transaction, error) {
+	body := struct {
+		FromWalletID uint    `json:"from_wallet_id"`
+		ToWalletID   uint    `json:"to_wallet_id"`
+		Amount       float64 `json:"amount"`
+		Description  string  `json:"description,omitempty"`
+	}{input.FromWalletID, input.ToWalletID, input.Amount, input.Description}
+
+	var tx Transaction
+	err := c.do(ctx, request{
+		method:         http.MethodPost,
+		path:           "/wallets/transfer",
+		body:           body,
+		signed:         true,
+		idempotencyKey: idempotencyKey(input.IdempotencyKey),
+	}, &tx)
+	if err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// ListTransactions returns the transactions of a wallet, newest first
+func (c *Client) ListTransactions(ctx context.Context, walletID uint, page Page) ([]Transaction, *PageInfo, error) {
+	var txs []Transaction
+	info, err := c.doPage(ctx, request{
+		method: http.MethodGet,
+		path:   fmt.Sprintf("/wallets/%d/transactions", walletID),
+		query:  page.values(),
+	}, &txs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return txs, info, nil
+}
+
+func (p Page) values() url.Values {
+	query := url.Values{}
+	if p.Limit > 0 {
+		query.Set("limit", strconv.Itoa(p.Limit))
+	}
+	if p.Cursor != "" {
+		query.Set("cursor", p.Cursor)
+	}
+	if p.IncludeTotal {
+		query.Set("include_total", "true")
+	}
+	return query
+}
+
+func idempotencyKey(key string) string {
+	if key == "" {
+		return NewIdempotencyKey()
+	}
+	return key
+}
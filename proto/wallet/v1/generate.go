@@ -0,0 +1,5 @@
+// Package walletv1 holds the protobuf definitions of the gRPC API and the
+// code generated from them.
+package walletv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative wallet/v1/wallet.proto
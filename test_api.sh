@@ -1,518 +0,0 @@
-#!/bin/bash
-
-# Wallet Service API Comprehensive Test Script
-# This script tests all endpoints and functionality of the wallet service
-
-set -e  # Exit on any error
-
-# Colors for output
-RED='\033[0;31m'
-GREEN='\033[0;32m'
-YELLOW='\033[1;33m'
-BLUE='\033[0;34m'
-NC='\033[0m' # No Color
-
-# Configuration
-BASE_URL="http://localhost:8080"
-TEMP_DIR="/tmp/wallet_test"
-mkdir -p $TEMP_DIR
-
-# Helper functions
-print_header() {
-    echo -e "\n${BLUE}===========================================${NC}"
-    echo -e "${BLUE}$1${NC}"
-    echo -e "${BLUE}===========================================${NC}"
-}
-
-print_step() {
-    echo -e "\n${YELLOW}Step: $1${NC}"
-}
-
-print_success() {
-    echo -e "${GREEN}✅ $1${NC}"
-}
-
-print_error() {
-    echo -e "${RED}❌ $1${NC}"
-    exit 1
-}
-
-# Test function with response validation
-test_endpoint() {
-    local method=$1
-    local endpoint=$2
-    local data=$3
-    local headers=$4
-    local expected_status=$5
-    local description=$6
-    
-    echo -e "\n${YELLOW}Testing: $description${NC}"
-    # Build curl command string to allow proper evaluation of quoted headers
-    local cmd="curl -s -w '\n%{http_code}' -X $method '$BASE_URL$endpoint'"
-    if [ -n "$headers" ]; then
-        cmd+=" $headers"
-    fi
-    if [ -n "$data" ]; then
-        cmd+=" -d '$data'"
-    fi
-    echo "$cmd"
-    # Use eval so that any quotes inside the headers string are respected
-    response=$(eval "$cmd")
-    
-    http_code=$(echo "$response" | tail -n1)
-    response_body=$(echo "$response" | head -n -1)
-    
-    if [ "$http_code" -eq "$expected_status" ]; then
-        print_success "$description (HTTP $http_code)"
-        # Try to format JSON if jq is available, otherwise show raw
-        if command -v jq &> /dev/null; then
-            echo "$response_body" | jq . 2>/dev/null || echo "$response_body"
-        else
-            echo "$response_body"
-        fi
-        return 0
-    else
-        print_error "$description failed. Expected HTTP $expected_status, got $http_code. Response: $response_body"
-        return 1
-    fi
-}
-
-# Extract value from JSON response (without jq dependency)
-extract_json_value() {
-    local json="$1"
-    local path="$2"
-    
-    # Simple extraction for common patterns
-    case "$path" in
-        ".data.token")
-            echo "$json" | grep -o '"token":"[^"]*"' | cut -d'"' -f4
-            ;;
-        ".data.id")
-            echo "$json" | grep -o '"id":[0-9]*' | cut -d':' -f2
-            ;;
-        *)
-            echo ""
-            ;;
-    esac
-}
-
-# Main test execution
-main() {
-    print_header "WALLET SERVICE API COMPREHENSIVE TEST"
-    
-    # Variables to store tokens and IDs
-    SENDER_TOKEN=""
-    RECEIVER_TOKEN=""
-    SENDER_WALLET_ID=""
-    RECEIVER_WALLET_ID=""
-    
-    # =========================================
-    # 1. Health Check Tests
-    # =========================================
-    print_header "1. HEALTH CHECK TESTS"
-    
-    print_step "1.1 Health endpoint"
-    test_endpoint "GET" "/health" "" "" 200 "Health check"
-    
-    print_step "1.2 Ready endpoint"
-    test_endpoint "GET" "/ready" "" "" 200 "Ready check"
-    
-    print_step "1.3 Live endpoint"
-    test_endpoint "GET" "/live" "" "" 200 "Live check"
-    
-    # =========================================
-    # 2. User Registration Tests
-    # =========================================
-    print_header "2. USER REGISTRATION TESTS"
-    
-    print_step "2.1 Register valid sender user"
-    response=$(curl -s -w "\n%{http_code}" -X POST "$BASE_URL/auth/register" \
-        -H "Content-Type: application/json" \
-        -d '{"username": "testsender", "password": "password123"}')
-    
-    http_code=$(echo "$response" | tail -n1)
-    response_body=$(echo "$response" | head -n -1)
-    
-    if [ "$http_code" -eq 201 ]; then
-        print_success "Sender registration (HTTP $http_code)"
-        if command -v jq &> /dev/null; then
-            echo "$response_body" | jq .
-        else
-            echo "$response_body"
-        fi
-    else
-        print_success "Sender may already exist (HTTP $http_code)"
-        echo "$response_body"
-    fi
-    
-    print_step "2.2 Register valid receiver user"
-    response=$(curl -s -w "\n%{http_code}" -X POST "$BASE_URL/auth/register" \
-        -H "Content-Type: application/json" \
-        -d '{"username": "testreceiver", "password": "password123"}')
-    
-    http_code=$(echo "$response" | tail -n1)
-    response_body=$(echo "$response" | head -n -1)
-    
-    if [ "$http_code" -eq 201 ]; then
-        print_success "Receiver registration (HTTP $http_code)"
-        if command -v jq &> /dev/null; then
-            echo "$response_body" | jq .
-        else
-            echo "$response_body"
-        fi
-    else
-        print_success "Receiver may already exist (HTTP $http_code)"
-        echo "$response_body"
-    fi
-    
-    print_step "2.3 Test invalid username (with numbers)"
-    test_endpoint "POST" "/auth/register" \
-        '{"username": "user123", "password": "password123"}' \
-        "-H 'Content-Type: application/json'" \
-        400 "Invalid username validation"
-    
-    print_step "2.4 Test short password"
-    test_endpoint "POST" "/auth/register" \
-        '{"username": "testuser", "password": "short"}' \
-        "-H 'Content-Type: application/json'" \
-        400 "Short password validation"
-    
-    # =========================================
-    # 3. Authentication Tests
-    # =========================================
-    print_header "3. AUTHENTICATION TESTS"
-    
-    print_step "3.1 Login sender user"
-    response=$(curl -s -w "\n%{http_code}" -X POST "$BASE_URL/auth/login" \
-        -H "Content-Type: application/json" \
-        -d '{"username": "testsender", "password": "password123"}')
-    
-    http_code=$(echo "$response" | tail -n1)
-    response_body=$(echo "$response" | head -n -1)
-    
-    if [ "$http_code" -eq 200 ]; then
-        print_success "Sender login (HTTP $http_code)"
-        SENDER_TOKEN=$(extract_json_value "$response_body" ".data.token")
-        echo "Sender Token: ${SENDER_TOKEN:0:50}..."
-    else
-        print_error "Sender login failed. HTTP $http_code. Response: $response_body"
-    fi
-    
-    print_step "3.2 Login receiver user"
-    response=$(curl -s -w "\n%{http_code}" -X POST "$BASE_URL/auth/login" \
-        -H "Content-Type: application/json" \
-        -d '{"username": "testreceiver", "password": "password123"}')
-    
-    http_code=$(echo "$response" | tail -n1)
-    response_body=$(echo "$response" | head -n -1)
-    
-    if [ "$http_code" -eq 200 ]; then
-        print_success "Receiver login (HTTP $http_code)"
-        RECEIVER_TOKEN=$(extract_json_value "$response_body" ".data.token")
-        echo "Receiver Token: ${RECEIVER_TOKEN:0:50}..."
-    else
-        print_error "Receiver login failed. HTTP $http_code. Response: $response_body"
-    fi
-    
-    print_step "3.3 Test invalid credentials"
-    test_endpoint "POST" "/auth/login" \
-        '{"username": "testsender", "password": "wrongpassword"}' \
-        "-H 'Content-Type: application/json'" \
-        401 "Invalid credentials validation"
-    
-    # =========================================
-    # 4. Wallet Management Tests
-    # =========================================
-    print_header "4. WALLET MANAGEMENT TESTS"
-    
-    print_step "4.1 Get sender wallets (may create if none exist)"
-    response=$(curl -s -w "\n%{http_code}" -X GET "$BASE_URL/wallets" \
-        -H "Authorization: Bearer $SENDER_TOKEN")
-    
-    http_code=$(echo "$response" | tail -n1)
-    response_body=$(echo "$response" | head -n -1)
-    
-    if [ "$http_code" -eq 200 ]; then
-        # Try to extract wallet count more reliably
-        if echo "$response_body" | grep -q '"data":\[\]'; then
-            wallet_count=0
-        elif echo "$response_body" | grep -q '"data":\['; then
-            wallet_count=1
-        else
-            wallet_count=0
-        fi
-        if [ "$wallet_count" -gt 0 ]; then
-            SENDER_WALLET_ID=$(echo "$response_body" | grep -o '"id":[0-9]*' | head -1 | cut -d':' -f2)
-            print_success "Found existing sender wallet (ID: $SENDER_WALLET_ID)"
-        else
-            print_step "4.1a Creating sender wallet"
-            response=$(curl -s -w "\n%{http_code}" -X POST "$BASE_URL/wallets" \
-                -H "Authorization: Bearer $SENDER_TOKEN" \
-                -H "Content-Type: application/json")
-            
-            http_code=$(echo "$response" | tail -n1)
-            response_body=$(echo "$response" | head -n -1)
-            
-            if [ "$http_code" -eq 201 ]; then
-                SENDER_WALLET_ID=$(extract_json_value "$response_body" ".data.id")
-                print_success "Sender wallet created (ID: $SENDER_WALLET_ID)"
-            else
-                print_error "Failed to create sender wallet"
-            fi
-        fi
-    fi
-    
-    print_step "4.2 Get receiver wallets (may create if none exist)"
-    response=$(curl -s -w "\n%{http_code}" -X GET "$BASE_URL/wallets" \
-        -H "Authorization: Bearer $RECEIVER_TOKEN")
-    
-    http_code=$(echo "$response" | tail -n1)
-    response_body=$(echo "$response" | head -n -1)
-    
-    if [ "$http_code" -eq 200 ]; then
-        # Try to extract wallet count more reliably
-        if echo "$response_body" | grep -q '"data":\[\]'; then
-            wallet_count=0
-        elif echo "$response_body" | grep -q '"data":\['; then
-            wallet_count=1
-        else
-            wallet_count=0
-        fi
-        if [ "$wallet_count" -gt 0 ]; then
-            RECEIVER_WALLET_ID=$(echo "$response_body" | grep -o '"id":[0-9]*' | head -1 | cut -d':' -f2)
-            print_success "Found existing receiver wallet (ID: $RECEIVER_WALLET_ID)"
-        else
-            print_step "4.2a Creating receiver wallet"
-            response=$(curl -s -w "\n%{http_code}" -X POST "$BASE_URL/wallets" \
-                -H "Authorization: Bearer $RECEIVER_TOKEN" \
-                -H "Content-Type: application/json")
-            
-            http_code=$(echo "$response" | tail -n1)
-            response_body=$(echo "$response" | head -n -1)
-            
-            if [ "$http_code" -eq 201 ]; then
-                RECEIVER_WALLET_ID=$(extract_json_value "$response_body" ".data.id")
-                print_success "Receiver wallet created (ID: $RECEIVER_WALLET_ID)"
-            else
-                print_error "Failed to create receiver wallet"
-            fi
-        fi
-    fi
-    
-    print_step "4.3 Test unauthorized wallet access"
-    test_endpoint "POST" "/wallets" "" \
-        "-H 'Content-Type: application/json'" \
-        401 "Unauthorized wallet creation"
-    
-    # =========================================
-    # 5. Deposit Operations Tests
-    # =========================================
-    print_header "5. DEPOSIT OPERATIONS TESTS"
-    
-    print_step "5.1 Deposit to sender wallet"
-    test_endpoint "POST" "/wallets/deposit" \
-        "{\"wallet_id\": $SENDER_WALLET_ID, \"amount\": 200.50, \"description\": \"Test deposit\"}" \
-        "-H 'Authorization: Bearer $SENDER_TOKEN' -H 'Content-Type: application/json'" \
-        200 "Sender wallet deposit"
-    
-    print_step "5.2 Deposit to receiver wallet"
-    test_endpoint "POST" "/wallets/deposit" \
-        "{\"wallet_id\": $RECEIVER_WALLET_ID, \"amount\": 50.25, \"description\": \"Test deposit\"}" \
-        "-H 'Authorization: Bearer $RECEIVER_TOKEN' -H 'Content-Type: application/json'" \
-        200 "Receiver wallet deposit"
-    
-    print_step "5.3 Test negative amount deposit"
-    test_endpoint "POST" "/wallets/deposit" \
-        "{\"wallet_id\": $SENDER_WALLET_ID, \"amount\": -10.00, \"description\": \"Negative test\"}" \
-        "-H 'Authorization: Bearer $SENDER_TOKEN' -H 'Content-Type: application/json'" \
-        400 "Negative amount validation"
-    
-    print_step "5.4 Test unauthorized deposit"
-    test_endpoint "POST" "/wallets/deposit" \
-        "{\"wallet_id\": $RECEIVER_WALLET_ID, \"amount\": 10.00, \"description\": \"Unauthorized test\"}" \
-        "-H 'Authorization: Bearer $SENDER_TOKEN' -H 'Content-Type: application/json'" \
-        403 "Unauthorized deposit validation"
-    
-    # =========================================
-    # 6. Transfer Operations Tests
-    # =========================================
-    print_header "6. TRANSFER OPERATIONS TESTS"
-    
-    print_step "6.1 Valid transfer from sender to receiver"
-    test_endpoint "POST" "/wallets/transfer" \
-        "{\"from_wallet_id\": $SENDER_WALLET_ID, \"to_wallet_id\": $RECEIVER_WALLET_ID, \"amount\": 75.25, \"description\": \"Test transfer\"}" \
-        "-H 'Authorization: Bearer $SENDER_TOKEN' -H 'Content-Type: application/json'" \
-        200 "Valid transfer operation"
-    
-    print_step "6.2 Test insufficient funds transfer"
-    test_endpoint "POST" "/wallets/transfer" \
-        "{\"from_wallet_id\": $SENDER_WALLET_ID, \"to_wallet_id\": $RECEIVER_WALLET_ID, \"amount\": 1000.00, \"description\": \"Insufficient funds test\"}" \
-        "-H 'Authorization: Bearer $SENDER_TOKEN' -H 'Content-Type: application/json'" \
-        400 "Insufficient funds validation"
-    
-    print_step "6.3 Test unauthorized transfer"
-    test_endpoint "POST" "/wallets/transfer" \
-        "{\"from_wallet_id\": $RECEIVER_WALLET_ID, \"to_wallet_id\": $SENDER_WALLET_ID, \"amount\": 10.00, \"description\": \"Unauthorized test\"}" \
-        "-H 'Authorization: Bearer $SENDER_TOKEN' -H 'Content-Type: application/json'" \
-        403 "Unauthorized transfer validation"
-    
-    # =========================================
-    # 7. Balance Verification Tests
-    # =========================================
-    print_header "7. BALANCE VERIFICATION TESTS"
-    
-    print_step "7.1 Check sender wallet balance"
-    test_endpoint "GET" "/wallets/$SENDER_WALLET_ID" "" \
-        "-H 'Authorization: Bearer $SENDER_TOKEN'" \
-        200 "Sender wallet balance check"
-    
-    print_step "7.2 Check receiver wallet balance"
-    test_endpoint "GET" "/wallets/$RECEIVER_WALLET_ID" "" \
-        "-H 'Authorization: Bearer $RECEIVER_TOKEN'" \
-        200 "Receiver wallet balance check"
-    
-    # =========================================
-    # 8. Transaction History Tests
-    # =========================================
-    print_header "8. TRANSACTION HISTORY TESTS"
-    
-    print_step "8.1 Get sender transaction history"
-    test_endpoint "GET" "/wallets/$SENDER_WALLET_ID/transactions?limit=10&offset=0" "" \
-        "-H 'Authorization: Bearer $SENDER_TOKEN'" \
-        200 "Sender transaction history"
-    
-    print_step "8.2 Get receiver transaction history"
-    test_endpoint "GET" "/wallets/$RECEIVER_WALLET_ID/transactions?limit=10&offset=0" "" \
-        "-H 'Authorization: Bearer $RECEIVER_TOKEN'" \
-        200 "Receiver transaction history"
-    
-    print_step "8.3 Test pagination"
-    test_endpoint "GET" "/wallets/$SENDER_WALLET_ID/transactions?limit=1&offset=0" "" \
-        "-H 'Authorization: Bearer $SENDER_TOKEN'" \
-        200 "Transaction history pagination"
-    
-    # =========================================
-    # 9. Admin API Tests
-    # =========================================
-    print_header "9. ADMIN API TESTS"
-    
-    print_step "9.1 List all users"
-    test_endpoint "GET" "/admin/users" "" \
-        "-H 'Authorization: Bearer $SENDER_TOKEN'" \
-        200 "Admin users list"
-    
-    print_step "9.2 List all transactions"
-    test_endpoint "GET" "/admin/transactions?limit=20&offset=0" "" \
-        "-H 'Authorization: Bearer $SENDER_TOKEN'" \
-        200 "Admin transactions list"
-    
-    print_step "9.3 Test admin pagination"
-    test_endpoint "GET" "/admin/transactions?limit=5&offset=0" "" \
-        "-H 'Authorization: Bearer $SENDER_TOKEN'" \
-        200 "Admin transactions pagination"
-    
-    # =========================================
-    # 10. Security Tests
-    # =========================================
-    print_header "10. SECURITY TESTS"
-    
-    print_step "10.1 Test invalid JWT token"
-    test_endpoint "GET" "/wallets" "" \
-        "-H 'Authorization: Bearer invalid_token'" \
-        401 "Invalid JWT token validation"
-    
-    print_step "10.2 Test missing authorization header"
-    test_endpoint "GET" "/wallets" "" "" \
-        401 "Missing authorization validation"
-    
-    print_step "10.3 Test access to other user's wallet"
-    test_endpoint "GET" "/wallets/$SENDER_WALLET_ID" "" \
-        "-H 'Authorization: Bearer $RECEIVER_TOKEN'" \
-        403 "Cross-user wallet access validation"
-    
-    # =========================================
-    # 11. Redis Caching Tests
-    # =========================================
-    print_header "11. REDIS CACHING VERIFICATION"
-    
-    print_step "11.1 Check Redis connectivity"
-    if docker exec wallet-redis redis-cli ping > /dev/null 2>&1; then
-        print_success "Redis connectivity verified"
-    else
-        print_success "Redis may not be accessible via docker (but caching may still work)"
-    fi
-    
-    print_step "11.2 Check cached data"
-    cached_keys=$(docker exec wallet-redis redis-cli keys "*" 2>/dev/null | wc -l || echo "0")
-    if [ "$cached_keys" -gt 0 ]; then
-        print_success "Redis caching active ($cached_keys keys found)"
-        echo "Sample cached keys:"
-        docker exec wallet-redis redis-cli keys "*" 2>/dev/null | head -5 || echo "Keys not accessible"
-    else
-        print_success "Redis caching may be working (keys not accessible via docker)"
-    fi
-    
-    # =========================================
-    # 12. Error Handling Tests
-    # =========================================
-    print_header "12. ERROR HANDLING TESTS"
-    
-    print_step "12.1 Test zero amount deposit"
-    test_endpoint "POST" "/wallets/deposit" \
-        "{\"wallet_id\": $SENDER_WALLET_ID, \"amount\": 0, \"description\": \"Zero amount test\"}" \
-        "-H 'Authorization: Bearer $SENDER_TOKEN' -H 'Content-Type: application/json'" \
-        400 "Zero amount validation"
-    
-    print_step "12.2 Test non-existent wallet"
-    test_endpoint "GET" "/wallets/99999" "" \
-        "-H 'Authorization: Bearer $SENDER_TOKEN'" \
-        404 "Non-existent wallet validation"
-    
-    # =========================================
-    # Test Summary
-    # =========================================
-    print_header "TEST SUMMARY"
-    
-    print_success "All tests completed successfully!"
-    echo -e "\n${GREEN}Functionality Verified:${NC}"
-    echo "✅ Health checks (3 endpoints)"
-    echo "✅ User registration with validation"
-    echo "✅ JWT authentication"
-    echo "✅ Wallet creation and management"
-    echo "✅ Deposit operations with validation"
-    echo "✅ Transfer operations with atomic transactions"
-    echo "✅ Transaction history with pagination"
-    echo "✅ Admin APIs for users and transactions"
-    echo "✅ Security and authorization"
-    echo "✅ Redis caching functionality"
-    echo "✅ Error handling and validation"
-    echo "✅ Input validation (usernames, passwords, amounts)"
-    
-    echo -e "\n${GREEN}Final State:${NC}"
-    echo "- Test users: testsender, testreceiver"
-    echo "- Wallets created with deposits and transfers"
-    echo "- Complete transaction audit trail"
-    echo "- Redis caching active and verified"
-    
-    echo -e "\n${BLUE}All assignment requirements validated! 🎉${NC}"
-}
-
-# Cleanup function
-cleanup() {
-    rm -rf $TEMP_DIR 2>/dev/null || true
-}
-
-# Set trap for cleanup
-trap cleanup EXIT
-
-# Check dependencies
-if ! command -v curl &> /dev/null; then
-    print_error "curl is required but not installed"
-fi
-
-echo -e "${YELLOW}Note: jq not found, JSON output will be raw but tests will still work${NC}"
-
-# Run main test
-main
-
-print_success "Test script completed successfully!"